@@ -4,10 +4,14 @@
 package main_test
 
 import (
+	"bufio"
 	"bytes"
 	_ "embed"
+	"errors"
 	"fmt"
 	"io"
+	"net"
+	"net/textproto"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -76,8 +80,158 @@ func TestE2E(t *testing.T) {
 		expected += "Content-Length: " + strconv.Itoa(len(s)) + "\r\nContent-Type: application/vscode-jsonrpc; charset=utf-8\r\n\r\n" + s
 	}
 
-	expect(fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"result":{"capabilities":{"textDocumentSync":{"openClose":true,"change":2},"completionProvider":{},"hoverProvider":true},"serverInfo":{"name":"gdshader-language-server","version":%q}}}`, strings.TrimSpace(version)))
+	expect(fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"result":{"capabilities":{"positionEncoding":"utf-16","textDocumentSync":{"openClose":true,"change":2},"completionProvider":{},"hoverProvider":true,"definitionProvider":true,"referencesProvider":true,"inlayHintProvider":true,"documentFormattingProvider":true,"documentRangeFormattingProvider":true,"documentOnTypeFormattingProvider":{"firstTriggerCharacter":"}","moreTriggerCharacter":[";"]},"codeActionProvider":{"codeActionKinds":["quickfix","refactor.extract","source.migrate"]},"diagnosticProvider":{"interFileDependencies":false,"workspaceDiagnostics":false},"documentLinkProvider":{},"executeCommandProvider":{"commands":["gdshader.findIncludingShaders"]}},"serverInfo":{"name":"gdshader-language-server","version":%q}}}`, strings.TrimSpace(version)))
 	expect(`{"jsonrpc":"2.0","id":2,"result":null}`)
 
 	g.Expect(stdout.String()).To(BeComparableTo(string(expected)), "Output does not match expected")
 }
+
+func TestE2E_Listen(t *testing.T) {
+	binPath := filepath.Join(t.TempDir(), "gdshader-language-server")
+	NewWithT(t).Expect(exec.Command("go", "build", "-cover", "-o", binPath, ".").Run()).To(Succeed(), "Failed to build server")
+
+	freePort := func() string {
+		listener := lo.Must(net.Listen("tcp", "127.0.0.1:0"))
+		defer listener.Close()
+		return listener.Addr().String()
+	}
+
+	tests := []struct {
+		name    string
+		network string
+		address string
+	}{
+		{name: "TCP", network: "tcp", address: freePort()},
+		{name: "Unix", network: "unix", address: filepath.Join(t.TempDir(), "gdshader.sock")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			const coverDir = "tmp/cover/e2e"
+			lo.Must0(os.MkdirAll(coverDir, 0o700))
+			cmd := exec.Command(binPath, "-debug", "-listen", tt.network+"://"+tt.address)
+			cmd.Env = []string{"GOCOVERDIR=" + coverDir}
+			cmd.Stderr = os.Stderr
+			g.Expect(cmd.Start()).To(Succeed(), "Failed to start server")
+			t.Cleanup(func() { _ = cmd.Process.Kill() })
+
+			// Each client has its own session, so both can initialize.
+			var clients []net.Conn
+			for range 2 {
+				var conn net.Conn
+				g.Eventually(func() (err error) {
+					conn, err = net.Dial(tt.network, tt.address)
+					return err
+				}).Should(Succeed(), "Failed to connect")
+				t.Cleanup(func() { _ = conn.Close() })
+				clients = append(clients, conn)
+			}
+
+			for _, conn := range clients {
+				send := func(s string) {
+					lo.Must(io.WriteString(conn, "Content-Length: "+strconv.Itoa(len(s))+"\r\n\r\n"+s))
+				}
+				reader := textproto.NewReader(bufio.NewReader(conn))
+				receive := func() string {
+					header := lo.Must(reader.ReadMIMEHeader())
+					payload := make([]byte, lo.Must(strconv.Atoi(header.Get("Content-Length"))))
+					lo.Must(io.ReadFull(reader.R, payload))
+					return string(payload)
+				}
+
+				send(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`)
+				g.Expect(receive()).To(HavePrefix(`{"jsonrpc":"2.0","id":1,"result":{"capabilities":`))
+				send(`{"jsonrpc":"2.0","id":2,"method":"shutdown"}`)
+				g.Expect(receive()).To(Equal(`{"jsonrpc":"2.0","id":2,"result":null}`))
+				send(`{"jsonrpc":"2.0","method":"exit"}`)
+				_, err := reader.ReadMIMEHeader()
+				g.Expect(err).To(MatchError(io.EOF), "Server did not close the connection")
+			}
+
+			// The server keeps listening until it is interrupted.
+			g.Expect(cmd.Process.Signal(os.Interrupt)).To(Succeed())
+			select {
+			case err := <-lo.Async(cmd.Wait):
+				g.Expect(err).ToNot(HaveOccurred(), "Server exited with error")
+			case <-time.After(5 * time.Second):
+				t.Fatal("Server did not exit in time")
+			}
+		})
+	}
+}
+
+func TestE2E_ExitWithoutShutdown(t *testing.T) {
+	g := NewWithT(t)
+
+	binPath := filepath.Join(t.TempDir(), "gdshader-language-server")
+	g.Expect(exec.Command("go", "build", "-cover", "-o", binPath, ".").Run()).To(Succeed(), "Failed to build server")
+
+	const coverDir = "tmp/cover/e2e"
+	lo.Must0(os.MkdirAll(coverDir, 0o700))
+	cmd := exec.Command(binPath)
+	cmd.Env = []string{"GOCOVERDIR=" + coverDir}
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = strings.NewReader("Content-Length: 33\r\n\r\n" + `{"jsonrpc":"2.0","method":"exit"}`)
+	g.Expect(cmd.Start()).To(Succeed(), "Failed to start server")
+
+	select {
+	case err := <-lo.Async(cmd.Wait):
+		g.Expect(err).To(HaveOccurred(), "Server exited without error")
+		var exitErr *exec.ExitError
+		g.Expect(errors.As(err, &exitErr)).To(BeTrue())
+		g.Expect(exitErr.ExitCode()).To(Equal(1))
+	case <-time.After(5 * time.Second):
+		t.Fatal("Server did not exit in time")
+	}
+}
+
+func TestE2E_Replay(t *testing.T) {
+	binPath := filepath.Join(t.TempDir(), "gdshader-language-server")
+	NewWithT(t).Expect(exec.Command("go", "build", "-cover", "-o", binPath, ".").Run()).To(Succeed(), "Failed to build server")
+
+	const coverDir = "tmp/cover/e2e"
+	lo.Must0(os.MkdirAll(coverDir, 0o700))
+
+	replay := func(t *testing.T, recording string) {
+		t.Helper()
+		var stdout bytes.Buffer
+		cmd := exec.Command(binPath, "replay", recording)
+		cmd.Env = []string{"GOCOVERDIR=" + coverDir}
+		cmd.Stderr = os.Stderr
+		cmd.Stdout = &stdout
+		NewWithT(t).Expect(cmd.Run()).To(Succeed(), "Responses differ from the recording:\n%s", stdout.String())
+	}
+
+	// Recorded sessions are regression tests.
+	for _, recording := range lo.Must(filepath.Glob("testdata/sessions/*.jsonl")) {
+		t.Run(filepath.Base(recording), func(t *testing.T) {
+			replay(t, recording)
+		})
+	}
+
+	t.Run("Record", func(t *testing.T) {
+		g := NewWithT(t)
+		recording := filepath.Join(t.TempDir(), "session.jsonl")
+		cmd := exec.Command(binPath, "-record", recording)
+		cmd.Env = []string{"GOCOVERDIR=" + coverDir}
+		cmd.Stderr = os.Stderr
+		var stdin strings.Builder
+		for _, s := range []string{
+			`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+			`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+			`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"file:///a.gdshader","text":"shader_type spatial;\nvoid fragment(){}\n"}}}`,
+			`{"jsonrpc":"2.0","id":2,"method":"textDocument/formatting","params":{"textDocument":{"uri":"file:///a.gdshader"},"options":{"tabSize":4}}}`,
+			`{"jsonrpc":"2.0","id":3,"method":"shutdown"}`,
+			`{"jsonrpc":"2.0","method":"exit"}`,
+		} {
+			stdin.WriteString("Content-Length: " + strconv.Itoa(len(s)) + "\r\n\r\n" + s)
+		}
+		cmd.Stdin = strings.NewReader(stdin.String())
+		g.Expect(cmd.Run()).To(Succeed(), "Server exited with error")
+
+		g.Expect(strings.Count(string(lo.Must(os.ReadFile(recording))), "\n")).To(BeNumerically(">=", 9), "Recording is missing messages")
+		replay(t, recording)
+	})
+}
@@ -3,7 +3,6 @@ module github.com/armsnyder/gdshader-language-server
 go 1.24.4
 
 require (
-	github.com/alecthomas/participle/v2 v2.1.4
 	github.com/google/go-cmp v0.7.0
 	github.com/onsi/gomega v1.39.1
 	github.com/samber/lo v1.52.0
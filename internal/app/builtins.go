@@ -0,0 +1,316 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package app
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+)
+
+type builtinParam struct {
+	typ  string
+	name string
+}
+
+type builtinFunction struct {
+	name       string
+	returnType string
+	params     []builtinParam
+}
+
+// Generic types used in built-in function signatures. They are resolved
+// against the argument types at the call site.
+const (
+	genFloat   = "vec_type"
+	genInt     = "vec_int_type"
+	genUint    = "vec_uint_type"
+	genBool    = "vec_bool_type"
+	genMatrix  = "mat_type"
+	genSampled = "gvec4_type"
+)
+
+// https://docs.godotengine.org/en/stable/tutorials/shaders/shader_reference/shader_functions.html
+var builtinFunctionSignatures = []string{
+	// Trigonometric functions
+	"vec_type radians(vec_type degrees)",
+	"vec_type degrees(vec_type radians)",
+	"vec_type sin(vec_type x)",
+	"vec_type cos(vec_type x)",
+	"vec_type tan(vec_type x)",
+	"vec_type asin(vec_type x)",
+	"vec_type acos(vec_type x)",
+	"vec_type atan(vec_type y_over_x)",
+	"vec_type atan(vec_type y, vec_type x)",
+	"vec_type sinh(vec_type x)",
+	"vec_type cosh(vec_type x)",
+	"vec_type tanh(vec_type x)",
+	"vec_type asinh(vec_type x)",
+	"vec_type acosh(vec_type x)",
+	"vec_type atanh(vec_type x)",
+
+	// Exponential and common math functions
+	"vec_type pow(vec_type x, vec_type y)",
+	"vec_type exp(vec_type x)",
+	"vec_type exp2(vec_type x)",
+	"vec_type log(vec_type x)",
+	"vec_type log2(vec_type x)",
+	"vec_type sqrt(vec_type x)",
+	"vec_type inversesqrt(vec_type x)",
+	"vec_type abs(vec_type x)",
+	"vec_int_type abs(vec_int_type x)",
+	"vec_type sign(vec_type x)",
+	"vec_int_type sign(vec_int_type x)",
+	"vec_type floor(vec_type x)",
+	"vec_type round(vec_type x)",
+	"vec_type roundEven(vec_type x)",
+	"vec_type trunc(vec_type x)",
+	"vec_type ceil(vec_type x)",
+	"vec_type fract(vec_type x)",
+	"vec_type mod(vec_type x, vec_type y)",
+	"vec_type mod(vec_type x, float y)",
+	"vec_type modf(vec_type x, out vec_type i)",
+	"vec_type min(vec_type a, vec_type b)",
+	"vec_type min(vec_type a, float b)",
+	"vec_int_type min(vec_int_type a, vec_int_type b)",
+	"vec_uint_type min(vec_uint_type a, vec_uint_type b)",
+	"vec_type max(vec_type a, vec_type b)",
+	"vec_type max(vec_type a, float b)",
+	"vec_int_type max(vec_int_type a, vec_int_type b)",
+	"vec_uint_type max(vec_uint_type a, vec_uint_type b)",
+	"vec_type clamp(vec_type x, vec_type min, vec_type max)",
+	"vec_type clamp(vec_type x, float min, float max)",
+	"vec_int_type clamp(vec_int_type x, vec_int_type min, vec_int_type max)",
+	"vec_uint_type clamp(vec_uint_type x, vec_uint_type min, vec_uint_type max)",
+	"vec_type mix(vec_type a, vec_type b, vec_type c)",
+	"vec_type mix(vec_type a, vec_type b, float c)",
+	"vec_type mix(vec_type a, vec_type b, vec_bool_type c)",
+	"vec_type fma(vec_type a, vec_type b, vec_type c)",
+	"vec_type step(vec_type edge, vec_type x)",
+	"vec_type step(float edge, vec_type x)",
+	"vec_type smoothstep(vec_type edge0, vec_type edge1, vec_type x)",
+	"vec_type smoothstep(float edge0, float edge1, vec_type x)",
+	"vec_bool_type isnan(vec_type x)",
+	"vec_bool_type isinf(vec_type x)",
+	"vec_int_type floatBitsToInt(vec_type x)",
+	"vec_uint_type floatBitsToUint(vec_type x)",
+	"vec_type intBitsToFloat(vec_int_type x)",
+	"vec_type uintBitsToFloat(vec_uint_type x)",
+	"vec_type ldexp(vec_type x, vec_int_type exp)",
+	"vec_type frexp(vec_type x, out vec_int_type exp)",
+
+	// Geometric functions
+	"float length(vec_type x)",
+	"float distance(vec_type a, vec_type b)",
+	"float dot(vec_type a, vec_type b)",
+	"vec3 cross(vec3 a, vec3 b)",
+	"vec_type normalize(vec_type x)",
+	"vec3 reflect(vec3 I, vec3 N)",
+	"vec3 refract(vec3 I, vec3 N, float eta)",
+	"vec_type faceforward(vec_type N, vec_type I, vec_type Nref)",
+	"mat_type matrixCompMult(mat_type x, mat_type y)",
+	"mat_type outerProduct(vec_type column, vec_type row)",
+	"mat_type transpose(mat_type m)",
+	"float determinant(mat_type m)",
+	"mat_type inverse(mat_type m)",
+
+	// Comparison functions
+	"vec_bool_type lessThan(vec_type x, vec_type y)",
+	"vec_bool_type greaterThan(vec_type x, vec_type y)",
+	"vec_bool_type lessThanEqual(vec_type x, vec_type y)",
+	"vec_bool_type greaterThanEqual(vec_type x, vec_type y)",
+	"vec_bool_type equal(vec_type x, vec_type y)",
+	"vec_bool_type notEqual(vec_type x, vec_type y)",
+	"bool any(vec_bool_type x)",
+	"bool all(vec_bool_type x)",
+	"vec_bool_type not(vec_bool_type x)",
+
+	// Texture functions
+	"ivec2 textureSize(sampler2D s, int lod)",
+	"ivec3 textureSize(sampler3D s, int lod)",
+	"vec2 textureQueryLod(sampler2D s, vec2 p)",
+	"int textureQueryLevels(sampler2D s)",
+	"gvec4_type texture(sampler2D s, vec2 p)",
+	"gvec4_type texture(sampler2D s, vec2 p, float bias)",
+	"gvec4_type textureProj(sampler2D s, vec3 p)",
+	"gvec4_type textureProj(sampler2D s, vec3 p, float bias)",
+	"gvec4_type textureLod(sampler2D s, vec2 p, float lod)",
+	"gvec4_type textureProjLod(sampler2D s, vec3 p, float lod)",
+	"gvec4_type textureGrad(sampler2D s, vec2 p, vec2 dPdx, vec2 dPdy)",
+	"gvec4_type textureProjGrad(sampler2D s, vec3 p, vec2 dPdx, vec2 dPdy)",
+	"gvec4_type texelFetch(sampler2D s, ivec2 p, int lod)",
+	"gvec4_type textureGather(sampler2D s, vec2 p, int comps)",
+	"vec_type dFdx(vec_type p)",
+	"vec_type dFdxCoarse(vec_type p)",
+	"vec_type dFdxFine(vec_type p)",
+	"vec_type dFdy(vec_type p)",
+	"vec_type dFdyCoarse(vec_type p)",
+	"vec_type dFdyFine(vec_type p)",
+	"vec_type fwidth(vec_type p)",
+	"vec_type fwidthCoarse(vec_type p)",
+	"vec_type fwidthFine(vec_type p)",
+
+	// Packing and unpacking functions
+	"uint packHalf2x16(vec2 v)",
+	"vec2 unpackHalf2x16(uint v)",
+	"uint packUnorm2x16(vec2 v)",
+	"vec2 unpackUnorm2x16(uint v)",
+	"uint packSnorm2x16(vec2 v)",
+	"vec2 unpackSnorm2x16(uint v)",
+	"uint packUnorm4x8(vec4 v)",
+	"vec4 unpackUnorm4x8(uint v)",
+	"uint packSnorm4x8(vec4 v)",
+	"vec4 unpackSnorm4x8(uint v)",
+
+	// Bitwise functions
+	"vec_int_type bitfieldExtract(vec_int_type value, int offset, int bits)",
+	"vec_int_type bitfieldInsert(vec_int_type base, vec_int_type insert, int offset, int bits)",
+	"vec_int_type bitfieldReverse(vec_int_type value)",
+	"vec_int_type bitCount(vec_int_type value)",
+	"vec_int_type findLSB(vec_int_type value)",
+	"vec_int_type findMSB(vec_int_type value)",
+}
+
+// builtinFunctions maps each built-in function name to its overloads.
+var builtinFunctions = func() map[string][]builtinFunction {
+	functions := make(map[string][]builtinFunction)
+	for _, signature := range builtinFunctionSignatures {
+		fn := parseBuiltinSignature(signature)
+		functions[fn.name] = append(functions[fn.name], fn)
+	}
+	return functions
+}()
+
+func parseBuiltinSignature(signature string) builtinFunction {
+	head, rest, _ := strings.Cut(signature, "(")
+	returnType, name, _ := strings.Cut(head, " ")
+	fn := builtinFunction{name: name, returnType: returnType}
+	for param := range strings.SplitSeq(strings.TrimSuffix(rest, ")"), ", ") {
+		fields := strings.Fields(param)
+		fn.params = append(fn.params, builtinParam{typ: fields[len(fields)-2], name: fields[len(fields)-1]})
+	}
+	return fn
+}
+
+// builtinOverload picks the overload of a built-in function that best
+// matches the argument count and, where known, the argument types.
+func builtinOverload(name string, argTypes []string) (builtinFunction, bool) {
+	var best builtinFunction
+	bestScore := -1
+	for _, fn := range builtinFunctions[name] {
+		if len(fn.params) != len(argTypes) {
+			continue
+		}
+		score := 0
+		for i, param := range fn.params {
+			if typeMatchesParam(argTypes[i], param.typ) {
+				score++
+			}
+		}
+		if score > bestScore {
+			best, bestScore = fn, score
+		}
+	}
+	return best, bestScore >= 0
+}
+
+func typeMatchesParam(argType, paramType string) bool {
+	switch paramType {
+	case genFloat:
+		return scalarType(argType) == "float" && !isMatrix(argType)
+	case genInt:
+		return scalarType(argType) == "int"
+	case genUint:
+		return scalarType(argType) == "uint"
+	case genBool:
+		return scalarType(argType) == "bool"
+	case genMatrix:
+		return isMatrix(argType)
+	case "sampler2D":
+		return strings.HasSuffix(argType, "sampler2D")
+	}
+	return argType == paramType
+}
+
+// resolveReturnType substitutes the generic return type of a built-in
+// function based on the argument types at the call site.
+func (fn builtinFunction) resolveReturnType(argTypes []string) string {
+	size := 0
+	for i, param := range fn.params {
+		if !strings.HasPrefix(param.typ, "vec_") && param.typ != genMatrix {
+			continue
+		}
+		if isMatrix(argTypes[i]) {
+			if fn.returnType == genMatrix {
+				return argTypes[i]
+			}
+			continue
+		}
+		size = max(size, componentCount(argTypes[i]))
+	}
+
+	switch fn.returnType {
+	case genFloat:
+		return vectorType("float", size)
+	case genInt:
+		return vectorType("int", size)
+	case genUint:
+		return vectorType("uint", size)
+	case genBool:
+		return vectorType("bool", size)
+	case genMatrix:
+		if size < 2 {
+			return ""
+		}
+		return "mat" + strconv.Itoa(size)
+	case genSampled:
+		switch {
+		case len(argTypes) > 0 && strings.HasPrefix(argTypes[0], "isampler"):
+			return "ivec4"
+		case len(argTypes) > 0 && strings.HasPrefix(argTypes[0], "usampler"):
+			return "uvec4"
+		}
+		return "vec4"
+	}
+	return fn.returnType
+}
+
+// builtinVariableType returns the type of a built-in variable, such as
+// VERTEX, in the given context. It returns an empty string if the variable
+// is not available.
+func builtinVariableType(c completionContext, name string) string {
+	for _, item := range completionItems {
+		if item.item.Kind != lsp.CompletionConstant || item.item.Label != name || !item.predicate(c) {
+			continue
+		}
+		if fields := strings.Fields(item.item.Detail); len(fields) == 3 {
+			return fields[1]
+		}
+	}
+	return ""
+}
+
+// builtinVariableNames returns the names of the built-in variables that are
+// available in the given context.
+func builtinVariableNames(c completionContext) []string {
+	var names []string
+	for _, item := range completionItems {
+		if item.item.Kind == lsp.CompletionConstant && item.predicate(c) {
+			names = append(names, item.item.Label)
+		}
+	}
+	return names
+}
+
+// builtinVariableWritable reports whether a built-in variable is an output
+// in the given context.
+func builtinVariableWritable(c completionContext, name string) bool {
+	for _, item := range completionItems {
+		if item.item.Kind == lsp.CompletionConstant && item.item.Label == name && item.predicate(c) {
+			return strings.HasPrefix(item.item.Detail, "out ") || strings.HasPrefix(item.item.Detail, "inout ")
+		}
+	}
+	return false
+}
@@ -0,0 +1,541 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/armsnyder/gdshader-language-server/internal/ast"
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+)
+
+// checkSyntax reports parse errors. A missing semicolon can be inserted.
+func checkSyntax(a *analysis) []diagnostic {
+	var diagnostics []diagnostic
+	for _, err := range a.parseErrors {
+		d := diagnostic{
+			Span:     err.Span,
+			severity: lsp.SeverityError,
+			code:     "syntax",
+			message:  err.Message,
+		}
+		if err.Expected == ";" {
+			d.code = "missing-semicolon"
+			d.fixes = []fix{{
+				title:     "Insert missing semicolon",
+				edits:     []ast.TextEdit{{Span: ast.Span{Start: err.Start, End: err.Start}, NewText: ";"}},
+				preferred: true,
+			}}
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics
+}
+
+// shaderTypes are the valid values of shader_type.
+var shaderTypes = []string{"spatial", "canvas_item", "particles", "sky", "fog"}
+
+// stageFunctions are the processor functions of each shader type.
+var stageFunctions = map[string][]string{
+	"spatial":     {"vertex", "fragment", "light"},
+	"canvas_item": {"vertex", "fragment", "light"},
+	"particles":   {"start", "process"},
+	"sky":         {"sky"},
+	"fog":         {"fog"},
+}
+
+// checkShaderType reports a missing shader_type declaration. The shader
+// type that matches the processor functions in the file is preferred.
+// Include files are the opposite, since they take the shader type of the
+// shader that includes them.
+func checkShaderType(a *analysis) []diagnostic {
+	if a.include {
+		return checkIncludeShaderType(a)
+	}
+
+	guess := "spatial"
+	for _, decl := range a.file.Declarations {
+		if decl.ShaderType != nil {
+			return nil
+		}
+		if decl.FunctionDecl != nil {
+			for _, typ := range []string{"particles", "sky", "fog"} {
+				if slices.Contains(stageFunctions[typ], decl.FunctionDecl.Name) {
+					guess = typ
+				}
+			}
+		}
+	}
+
+	lineEnd := bytes.IndexByte(a.text, '\n')
+	if lineEnd < 0 {
+		lineEnd = len(a.text)
+	}
+
+	d := diagnostic{
+		Span:     ast.Span{Start: 0, End: lineEnd},
+		severity: lsp.SeverityError,
+		code:     "missing-shader-type",
+		message:  "Missing shader_type declaration.",
+	}
+	for _, typ := range shaderTypes {
+		d.fixes = append(d.fixes, fix{
+			title:     "Add shader_type " + typ,
+			edits:     []ast.TextEdit{{NewText: "shader_type " + typ + ";\n\n"}},
+			preferred: typ == guess,
+		})
+	}
+	return []diagnostic{d}
+}
+
+func checkIncludeShaderType(a *analysis) []diagnostic {
+	var diagnostics []diagnostic
+	for _, decl := range a.file.Declarations {
+		if decl.ShaderType != nil {
+			diagnostics = append(diagnostics, diagnostic{
+				Span:     decl.Span,
+				severity: lsp.SeverityError,
+				code:     "include-shader-type",
+				message:  "Shader include files can't declare a shader_type.",
+				fixes: []fix{{
+					title:     "Remove shader_type",
+					edits:     []ast.TextEdit{{Span: lineExtent(a.text, decl.Span)}},
+					preferred: true,
+				}},
+			})
+		}
+	}
+	return diagnostics
+}
+
+// checkIntLiterals reports int literals where a float is required. Godot
+// never converts an int to a float implicitly, so "float x = 1;" and
+// "pow(x, 2)" are errors.
+func checkIntLiterals(a *analysis) []diagnostic {
+	var diagnostics []diagnostic
+	seen := make(map[int]bool)
+
+	report := func(expr ast.Expr) {
+		lit := intLiteral(expr)
+		if lit == nil || seen[lit.Start] {
+			return
+		}
+		seen[lit.Start] = true
+		float := lit.Value + ".0"
+		diagnostics = append(diagnostics, diagnostic{
+			Span:     lit.Span,
+			severity: lsp.SeverityError,
+			code:     "int-literal",
+			message:  fmt.Sprintf("Expected a float, but '%s' is an int.", lit.Value),
+			fixes: []fix{{
+				title:     fmt.Sprintf("Change '%s' to '%s'", lit.Value, float),
+				edits:     []ast.TextEdit{{Span: lit.Span, NewText: float}},
+				preferred: true,
+			}},
+		})
+	}
+
+	for _, decl := range a.file.Declarations {
+		returnType := ""
+		if decl.FunctionDecl != nil {
+			returnType = decl.FunctionDecl.ReturnType
+		}
+
+		inspectWithEnv(a.typeEnv(decl.Start), decl, func(node ast.Node, env *typeEnv) bool {
+			switch n := node.(type) {
+			case *ast.UniformDecl:
+				if n.Type == "float" && n.ArraySize == nil {
+					report(n.Default)
+				}
+			case *ast.VarDecl:
+				for _, v := range n.Vars {
+					if n.Type == "float" && v.ArraySize == nil {
+						report(v.Init)
+					}
+				}
+			case *ast.ReturnStmt:
+				if returnType == "float" {
+					report(n.Value)
+				}
+			case *ast.AssignExpr:
+				typ := env.typeOf(n.Left)
+				if typ == "float" || (n.Op != "=" && isFloatType(typ)) {
+					report(n.Right)
+				}
+			case *ast.BinaryExpr:
+				switch n.Op {
+				case "+", "-", "*", "/", "<", ">", "<=", ">=", "==", "!=":
+					if isFloatType(env.typeOf(n.Y)) {
+						report(n.X)
+					}
+					if isFloatType(env.typeOf(n.X)) {
+						report(n.Y)
+					}
+				}
+			case *ast.CallExpr:
+				for _, arg := range intLiteralArgs(env, n) {
+					report(arg)
+				}
+			}
+			return true
+		})
+	}
+
+	return diagnostics
+}
+
+// intLiteral returns the decimal int literal in an expression such as "2" or
+// "-2", or nil if the expression is something else.
+func intLiteral(expr ast.Expr) *ast.Literal {
+	switch x := expr.(type) {
+	case *ast.Literal:
+		if x.Kind == ast.LiteralInt && !strings.HasPrefix(strings.ToLower(x.Value), "0x") {
+			return x
+		}
+	case *ast.UnaryExpr:
+		if x.Op == "-" || x.Op == "+" {
+			return intLiteral(x.X)
+		}
+	case *ast.ParenExpr:
+		return intLiteral(x.X)
+	}
+	return nil
+}
+
+func isFloatType(typ string) bool {
+	return scalarType(typ) == "float" && !isArray(typ)
+}
+
+// intLiteralArgs returns the int literal arguments of a call that would be
+// valid if they were floats.
+func intLiteralArgs(env *typeEnv, call *ast.CallExpr) []ast.Expr {
+	callee := call.Callee()
+	if callee == nil {
+		return nil
+	}
+	name := callee.Name
+
+	if name != "float" && scalarType(name) == "float" {
+		// Constructors of float vectors and matrices.
+		return call.Args
+	}
+
+	if _, ok := env.functions[name]; ok {
+		return nil
+	}
+
+	argTypes := make([]string, len(call.Args))
+	floatTypes := make([]string, len(call.Args))
+	var ints []ast.Expr
+	for i, arg := range call.Args {
+		argTypes[i] = env.typeOf(arg)
+		floatTypes[i] = argTypes[i]
+		if argTypes[i] == "" {
+			return nil
+		}
+		if intLiteral(arg) != nil {
+			floatTypes[i] = "float"
+			ints = append(ints, arg)
+		}
+	}
+
+	if len(ints) == 0 || matchesBuiltinOverload(name, argTypes) || !matchesBuiltinOverload(name, floatTypes) {
+		return nil
+	}
+	return ints
+}
+
+// matchesBuiltinOverload reports whether the argument types match every
+// parameter of an overload of a built-in function.
+func matchesBuiltinOverload(name string, argTypes []string) bool {
+	for _, fn := range builtinFunctions[name] {
+		if len(fn.params) != len(argTypes) {
+			continue
+		}
+		matches := true
+		for i, param := range fn.params {
+			matches = matches && typeMatchesParam(argTypes[i], param.typ)
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+// checkUnknownNames reports names that are not declared. A built-in of
+// another processor function can be moved to that function, and a
+// misspelled built-in can be corrected to the closest match. Unknown names
+// that look like nothing else are left to the Godot editor, since the
+// built-ins of some shader types are not known yet.
+func checkUnknownNames(a *analysis) []diagnostic {
+	var diagnostics []diagnostic
+
+	for _, decl := range a.file.Declarations {
+		function := decl.FunctionDecl
+		if function == nil || function.Body == nil {
+			continue
+		}
+
+		callees := make(map[*ast.Ident]bool)
+		inspectWithEnv(a.typeEnv(function.Body.Start), function.Body, func(node ast.Node, env *typeEnv) bool {
+			var d *diagnostic
+			switch n := node.(type) {
+			case *ast.CallExpr:
+				if callee := n.Callee(); callee != nil {
+					callees[callee] = true
+					d = checkUnknownFunction(env, callee)
+				}
+			case *ast.Ident:
+				if !callees[n] {
+					d = checkUnknownVariable(a, env, function, n)
+				}
+			}
+			if d != nil {
+				diagnostics = append(diagnostics, *d)
+			}
+			return true
+		})
+	}
+
+	return diagnostics
+}
+
+func checkUnknownFunction(env *typeEnv, ident *ast.Ident) *diagnostic {
+	if _, ok := env.functions[ident.Name]; ok || env.isType(ident.Name) {
+		return nil
+	}
+	if _, ok := builtinFunctions[ident.Name]; ok {
+		return nil
+	}
+
+	candidates := slices.Concat(slices.Collect(maps.Keys(builtinFunctions)), slices.Collect(maps.Keys(dataTypes)), slices.Collect(maps.Keys(env.functions)))
+	match, ok := closestMatch(ident.Name, candidates)
+	if !ok {
+		return nil
+	}
+
+	return &diagnostic{
+		Span:     ident.Span,
+		severity: lsp.SeverityError,
+		code:     "unknown-function",
+		message:  fmt.Sprintf("Unknown function '%s'.", ident.Name),
+		fixes:    []fix{renameFix(ident, match)},
+	}
+}
+
+func checkUnknownVariable(a *analysis, env *typeEnv, function *ast.FunctionDecl, ident *ast.Ident) *diagnostic {
+	if !hasBuiltinVariables(env.context.shaderType) {
+		return nil
+	}
+	if _, ok := env.vars[ident.Name]; ok || env.isType(ident.Name) {
+		return nil
+	}
+	if _, ok := builtinConstants[ident.Name]; ok {
+		return nil
+	}
+	if isGodot3Builtin(ident.Name) {
+		// Reported by checkGodot3.
+		return nil
+	}
+	if _, ok := env.functions[ident.Name]; ok {
+		return nil
+	}
+	builtins := builtinVariableNames(env.context)
+	if slices.Contains(builtins, ident.Name) {
+		return nil
+	}
+
+	written := isAssigned(function.Body, ident)
+	var stages []string
+	for _, stage := range stageFunctions[env.context.shaderType] {
+		c := completionContext{shaderType: env.context.shaderType, functionName: stage}
+		if slices.Contains(builtinVariableNames(c), ident.Name) && (!written || builtinVariableWritable(c, ident.Name)) {
+			stages = append(stages, stage+"()")
+		}
+	}
+
+	if len(stages) > 0 {
+		d := &diagnostic{
+			Span:     ident.Span,
+			severity: lsp.SeverityError,
+			code:     "wrong-stage",
+			message:  fmt.Sprintf("'%s' is only available in %s.", ident.Name, strings.Join(stages, " and ")),
+		}
+		if slices.Contains(stageFunctions[env.context.shaderType], function.Name) {
+			for _, stage := range stages {
+				if f, ok := moveStatementFix(a, function, ident.Start, strings.TrimSuffix(stage, "()")); ok {
+					d.fixes = append(d.fixes, f)
+				}
+			}
+		}
+		return d
+	}
+
+	candidates := slices.Concat(builtins, slices.Collect(maps.Keys(env.vars)), slices.Collect(maps.Keys(builtinConstants)))
+	match, ok := closestMatch(ident.Name, candidates)
+	if !ok {
+		return nil
+	}
+
+	return &diagnostic{
+		Span:     ident.Span,
+		severity: lsp.SeverityError,
+		code:     "unknown-identifier",
+		message:  fmt.Sprintf("Unknown identifier '%s'.", ident.Name),
+		fixes:    []fix{renameFix(ident, match)},
+	}
+}
+
+// isAssigned reports whether ident is the variable that an assignment or
+// increment in node writes to.
+func isAssigned(node ast.Node, ident *ast.Ident) bool {
+	assigned := false
+	ast.Inspect(node, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.AssignExpr:
+			assigned = assigned || baseIdent(n.Left) == ident
+		case *ast.UnaryExpr:
+			assigned = assigned || ((n.Op == "++" || n.Op == "--") && baseIdent(n.X) == ident)
+		}
+		return !assigned
+	})
+	return assigned
+}
+
+// baseIdent returns the variable that is accessed by an expression such as
+// "v.xy" or "a[i]".
+func baseIdent(expr ast.Expr) *ast.Ident {
+	switch x := expr.(type) {
+	case *ast.Ident:
+		return x
+	case *ast.MemberExpr:
+		return baseIdent(x.X)
+	case *ast.IndexExpr:
+		return baseIdent(x.X)
+	case *ast.ParenExpr:
+		return baseIdent(x.X)
+	}
+	return nil
+}
+
+func hasBuiltinVariables(shaderType string) bool {
+	return len(builtinVariableNames(completionContext{shaderType: shaderType})) > 0
+}
+
+func renameFix(ident *ast.Ident, name string) fix {
+	return fix{
+		title:     fmt.Sprintf("Change to '%s'", name),
+		edits:     []ast.TextEdit{{Span: ident.Span, NewText: name}},
+		preferred: true,
+	}
+}
+
+// moveStatementFix moves the top-level statement of a function that
+// contains offset to the end of another function, which is created if it
+// does not exist.
+func moveStatementFix(a *analysis, from *ast.FunctionDecl, offset int, to string) (fix, bool) {
+	i := slices.IndexFunc(from.Body.Stmts, func(stmt *ast.Stmt) bool { return stmt.Contains(offset) })
+	if i < 0 {
+		return fix{}, false
+	}
+	stmt := from.Body.Stmts[i]
+	text := string(a.text[stmt.Start:stmt.End])
+	edits := []ast.TextEdit{{Span: lineExtent(a.text, stmt.Span)}}
+
+	var target *ast.FunctionDecl
+	for _, decl := range a.file.Declarations {
+		if decl.FunctionDecl != nil && decl.FunctionDecl.Name == to {
+			target = decl.FunctionDecl
+		}
+	}
+
+	switch {
+	case target == nil:
+		prefix := "\n"
+		if !bytes.HasSuffix(a.text, []byte("\n")) {
+			prefix = "\n\n"
+		}
+		insert := ast.Span{Start: len(a.text), End: len(a.text)}
+		edits = append(edits, ast.TextEdit{Span: insert, NewText: prefix + "void " + to + "() {\n\t" + text + "\n}\n"})
+	case target.Body == nil || a.text[target.Body.End-1] != '}':
+		return fix{}, false
+	default:
+		closing := target.Body.End - 1
+		lineStart := bytes.LastIndexByte(a.text[:closing], '\n') + 1
+		if len(bytes.TrimSpace(a.text[lineStart:closing])) == 0 {
+			edits = append(edits, ast.TextEdit{Span: ast.Span{Start: lineStart, End: lineStart}, NewText: "\t" + text + "\n"})
+		} else {
+			edits = append(edits, ast.TextEdit{Span: ast.Span{Start: closing, End: closing}, NewText: "\n\t" + text + "\n"})
+		}
+	}
+
+	return fix{title: fmt.Sprintf("Move statement to %s()", to), edits: edits}, true
+}
+
+// lineExtent grows a span to cover its whole lines, including the final
+// newline, if nothing else is on those lines.
+func lineExtent(text []byte, span ast.Span) ast.Span {
+	start := bytes.LastIndexByte(text[:span.Start], '\n') + 1
+	end := len(text)
+	if i := bytes.IndexByte(text[span.End:], '\n'); i >= 0 {
+		end = span.End + i + 1
+	}
+	if len(bytes.TrimSpace(text[start:span.Start])) > 0 || len(bytes.TrimSpace(text[span.End:end])) > 0 {
+		return span
+	}
+	return ast.Span{Start: start, End: end}
+}
+
+// closestMatch returns the candidate that is most similar to name, if any is
+// similar enough to be a likely misspelling.
+func closestMatch(name string, candidates []string) (string, bool) {
+	slices.Sort(candidates)
+	limit := 1
+	if len(name) > 4 {
+		limit = 2
+	}
+
+	best, bestDistance := "", limit+1
+	for _, candidate := range candidates {
+		if candidate == name {
+			continue
+		}
+		if d := editDistance(strings.ToLower(name), strings.ToLower(candidate)); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best, best != ""
+}
+
+// editDistance is the number of single-character insertions, deletions,
+// substitutions and adjacent transpositions needed to turn a into b.
+func editDistance(a, b string) int {
+	rows := make([][]int, len(a)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(b)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(a)][len(b)]
+}
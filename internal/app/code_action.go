@@ -0,0 +1,73 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package app
+
+import (
+	"context"
+	"slices"
+
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+)
+
+// CodeAction implements lsp.CodeActionHandler. Every fix of a diagnostic in
+// the range is offered as a quick fix.
+func (h *Handler) CodeAction(ctx context.Context, params lsp.CodeActionParams) ([]lsp.CodeAction, error) {
+	uri := params.TextDocument.URI
+	a, diagnostics, err := h.analyze(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := a.doc.RangeToOffsets(params.Range)
+	if err != nil {
+		return nil, err
+	}
+
+	actions := []lsp.CodeAction{}
+
+	if wantCodeAction(params.Context.Only, lsp.CodeActionQuickFix) {
+		for _, d := range diagnostics {
+			if d.Start > end || d.End < start {
+				continue
+			}
+			for _, f := range d.fixes {
+				actions = append(actions, lsp.CodeAction{
+					Title:       f.title,
+					Kind:        lsp.CodeActionQuickFix,
+					Diagnostics: []lsp.Diagnostic{d.toLSP(a.doc)},
+					IsPreferred: f.preferred,
+					Edit:        &lsp.WorkspaceEdit{Changes: map[string][]lsp.TextEdit{uri: toLSPEdits(a.doc, f.edits)}},
+				})
+			}
+		}
+	}
+
+	selection := trimSelection(a.text, start, end)
+	if selection.Start < selection.End && wantCodeAction(params.Context.Only, lsp.CodeActionRefactorExtract) {
+		if edits, ok := extractVariableEdits(a, selection); ok {
+			actions = append(actions, refactorAction(uri, a.doc, "Extract to local variable", lsp.CodeActionRefactorExtract, edits))
+		}
+		if edits, ok := extractFunctionEdits(a, selection); ok {
+			actions = append(actions, refactorAction(uri, a.doc, "Extract to function", lsp.CodeActionRefactorExtract, edits))
+		}
+	}
+
+	if wantCodeAction(params.Context.Only, lsp.CodeActionRefactorExtract) {
+		actions = append(actions, promoteToUniformActions(uri, a, selection)...)
+	}
+
+	if wantCodeAction(params.Context.Only, codeActionMigrate) {
+		if action, ok := migrateAction(uri, a, diagnostics); ok {
+			actions = append(actions, action)
+		}
+	}
+
+	return actions, nil
+}
+
+// wantCodeAction reports whether the client asked for code actions of the
+// given kind.
+func wantCodeAction(only []lsp.CodeActionKind, kind lsp.CodeActionKind) bool {
+	return len(only) == 0 || slices.ContainsFunc(only, func(k lsp.CodeActionKind) bool { return k.Contains(kind) })
+}
@@ -0,0 +1,484 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package app_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/armsnyder/gdshader-language-server/internal/app"
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+	"github.com/armsnyder/gdshader-language-server/internal/lsptest"
+)
+
+func TestHandler_CodeAction(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		line     int
+		// only defaults to quick fixes.
+		only []lsp.CodeActionKind
+		// want maps the title of each expected code action to the document
+		// after applying it.
+		want map[string]string
+	}{
+		{
+			name:     "MissingShaderType",
+			document: "void sky() {\n}\n",
+			line:     0,
+			only:     []lsp.CodeActionKind{lsp.CodeActionQuickFix},
+			want: map[string]string{
+				"Add shader_type spatial":     "shader_type spatial;\n\nvoid sky() {\n}\n",
+				"Add shader_type canvas_item": "shader_type canvas_item;\n\nvoid sky() {\n}\n",
+				"Add shader_type particles":   "shader_type particles;\n\nvoid sky() {\n}\n",
+				"Add shader_type sky":         "shader_type sky;\n\nvoid sky() {\n}\n",
+				"Add shader_type fog":         "shader_type fog;\n\nvoid sky() {\n}\n",
+			},
+		},
+		{
+			name:     "MissingSemicolon",
+			document: "shader_type spatial;\nvoid fragment() {\n\tALBEDO = vec3(1.0)\n\tALPHA = 1.0;\n}\n",
+			line:     2,
+			want: map[string]string{
+				"Insert missing semicolon": "shader_type spatial;\nvoid fragment() {\n\tALBEDO = vec3(1.0);\n\tALPHA = 1.0;\n}\n",
+			},
+		},
+		{
+			name:     "IntLiteralToFloat",
+			document: "shader_type spatial;\nvoid fragment() {\n\tfloat x = pow(UV.x, 2) * 3.0;\n}\n",
+			line:     2,
+			want: map[string]string{
+				"Change '2' to '2.0'": "shader_type spatial;\nvoid fragment() {\n\tfloat x = pow(UV.x, 2.0) * 3.0;\n}\n",
+			},
+		},
+		{
+			name:     "MisspelledBuiltin",
+			document: "shader_type spatial;\nvoid fragment() {\n\tALBDEO = vec3(lenght(UV));\n}\n",
+			line:     2,
+			want: map[string]string{
+				"Change to 'ALBEDO'": "shader_type spatial;\nvoid fragment() {\n\tALBEDO = vec3(lenght(UV));\n}\n",
+				"Change to 'length'": "shader_type spatial;\nvoid fragment() {\n\tALBDEO = vec3(length(UV));\n}\n",
+			},
+		},
+		{
+			name:     "MoveToStageFunction",
+			document: "shader_type spatial;\nvoid vertex() {\n\tVERTEX.y += 1.0;\n\tALBEDO = vec3(1.0);\n}\n\nvoid fragment() {\n\tROUGHNESS = 0.5;\n}\n",
+			line:     3,
+			want: map[string]string{
+				"Move statement to fragment()": "shader_type spatial;\nvoid vertex() {\n\tVERTEX.y += 1.0;\n}\n\nvoid fragment() {\n\tROUGHNESS = 0.5;\n\tALBEDO = vec3(1.0);\n}\n",
+			},
+		},
+		{
+			name:     "MoveToNewStageFunction",
+			document: "shader_type spatial;\nvoid vertex() {\n\tALBEDO = vec3(1.0);\n}\n",
+			line:     2,
+			want: map[string]string{
+				"Move statement to fragment()": "shader_type spatial;\nvoid vertex() {\n}\n\nvoid fragment() {\n\tALBEDO = vec3(1.0);\n}\n",
+			},
+		},
+		{
+			name:     "OnlyOtherKinds",
+			document: "void fragment() {\n}\n",
+			line:     0,
+			only:     []lsp.CodeActionKind{lsp.CodeActionRefactor},
+			want:     map[string]string{},
+		},
+		{
+			name:     "NoProblems",
+			document: "shader_type spatial;\nvoid fragment() {\n\tALBEDO = vec3(UV, 1.0);\n}\n",
+			line:     2,
+			want:     map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			h := openDocument(t, tt.document)
+			only := tt.only
+			if only == nil {
+				only = []lsp.CodeActionKind{lsp.CodeActionQuickFix}
+			}
+
+			actions, err := h.CodeAction(t.Context(), lsp.CodeActionParams{
+				TextDocument: lsp.TextDocumentIdentifier{URI: testURI},
+				Range:        lsp.Range{Start: lsp.Position{Line: tt.line}, End: lsp.Position{Line: tt.line + 1}},
+				Context:      lsp.CodeActionContext{Only: only},
+			})
+			g.Expect(err).ToNot(HaveOccurred(), "CodeAction error")
+
+			got := make(map[string]string)
+			for _, action := range actions {
+				g.Expect(action.Edit).ToNot(BeNil(), "Missing edit for %q", action.Title)
+				got[action.Title] = applyTextEdits(tt.document, action.Edit.Changes[testURI])
+			}
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+// editedShader is longer than the gap of a gap buffer after its third
+// line, so that typing there and reading the document in between moves
+// the gap around.
+var editedShader = func() string {
+	var b strings.Builder
+	b.WriteString("shader_type spatial;\nvoid fragment() {\n\n")
+	for i := range 20 {
+		fmt.Fprintf(&b, "\tROUGHNESS = 0.%d;\n", i)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}()
+
+func TestHandler_CodeAction_Edits(t *testing.T) {
+	g := NewWithT(t)
+	c := startSession(t, lsptest.Options{})
+	c.Open(testURI, editedShader)
+	g.Expect(c.WaitDiagnostics(testURI)).To(BeEmpty())
+
+	for _, typed := range []struct {
+		character int
+		text      string
+	}{
+		{0, "\tfloat x = "},
+		{len("\tfloat x = "), "pow(UV.x, 2)"},
+		{len("\tfloat x = pow(UV.x, 2)"), ";"},
+	} {
+		at := lsp.Position{Line: 2, Character: typed.character}
+		c.Edit(testURI, lsp.Range{Start: at, End: at}, typed.text)
+		c.WaitDiagnostics(testURI)
+	}
+	g.Expect(c.Diagnostics(testURI)).To(ConsistOf(HaveField("Code", "int-literal")))
+
+	actions := c.CodeActions(testURI, lsp.Range{Start: lsp.Position{Line: 2}, End: lsp.Position{Line: 3}})
+	g.Expect(actions).To(HaveLen(1))
+	g.Expect(actions[0].Title).To(Equal("Change '2' to '2.0'"))
+	g.Expect(actions[0].Edit.Changes[testURI]).To(Equal([]lsp.TextEdit{{
+		Range:   lsp.Range{Start: lsp.Position{Line: 2, Character: 21}, End: lsp.Position{Line: 2, Character: 22}},
+		NewText: "2.0",
+	}}))
+}
+
+func TestHandler_Diagnostic(t *testing.T) {
+	g := NewWithT(t)
+	h := openDocument(t, "void vertex() {\n\tfloat x = 1;\n}\n")
+
+	report, err := h.Diagnostic(t.Context(), lsp.DocumentDiagnosticParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: testURI},
+	})
+	g.Expect(err).ToNot(HaveOccurred(), "Diagnostic error")
+	g.Expect(report).To(Equal(&lsp.FullDocumentDiagnosticReport{
+		Kind: "full",
+		Items: []lsp.Diagnostic{
+			{
+				Range:    lsp.Range{End: lsp.Position{Character: 15}},
+				Severity: lsp.SeverityError,
+				Code:     "missing-shader-type",
+				Source:   "gdshader",
+				Message:  "Missing shader_type declaration.",
+			},
+			{
+				Range:    lsp.Range{Start: lsp.Position{Line: 1, Character: 11}, End: lsp.Position{Line: 1, Character: 12}},
+				Severity: lsp.SeverityError,
+				Code:     "int-literal",
+				Source:   "gdshader",
+				Message:  "Expected a float, but '1' is an int.",
+			},
+		},
+	}))
+}
+
+func TestHandler_Diagnostic_Edits(t *testing.T) {
+	g := NewWithT(t)
+	c := startSession(t, lsptest.Options{})
+	c.Open(testURI, editedShader)
+	g.Expect(c.WaitDiagnostics(testURI)).To(BeEmpty())
+
+	c.Edit(testURI, lsp.Range{Start: lsp.Position{Line: 2}, End: lsp.Position{Line: 2}}, "\tfloat x = 1;")
+	g.Expect(c.WaitDiagnostics(testURI)).To(ConsistOf(HaveField("Range", lsp.Range{
+		Start: lsp.Position{Line: 2, Character: 11},
+		End:   lsp.Position{Line: 2, Character: 12},
+	})))
+
+	// The declaration moves down as lines are typed above it.
+	c.Edit(testURI, lsp.Range{Start: lsp.Position{Line: 1}, End: lsp.Position{Line: 1}}, "\n")
+	c.Edit(testURI, lsp.Range{Start: lsp.Position{Line: 1}, End: lsp.Position{Line: 1}}, "uniform float y;")
+	g.Expect(c.WaitDiagnostics(testURI)).To(ConsistOf(HaveField("Range", lsp.Range{
+		Start: lsp.Position{Line: 3, Character: 11},
+		End:   lsp.Position{Line: 3, Character: 12},
+	})))
+
+	c.Edit(testURI, lsp.Range{Start: lsp.Position{Line: 3, Character: 11}, End: lsp.Position{Line: 3, Character: 12}}, "1.0")
+	g.Expect(c.WaitDiagnostics(testURI)).To(BeEmpty())
+}
+
+func TestHandler_Diagnostic_Include(t *testing.T) {
+	g := NewWithT(t)
+	var h app.Handler
+	const uri = "file:///lighting.gdshaderinc"
+
+	err := h.DidOpenTextDocument(t.Context(), lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{URI: uri, LanguageID: "gdshaderinc", Text: "shader_type spatial;\nfloat half(float x) {\n\treturn x * 0.5;\n}\n"},
+	})
+	g.Expect(err).ToNot(HaveOccurred(), "DidOpenTextDocument error")
+
+	report, err := h.Diagnostic(t.Context(), lsp.DocumentDiagnosticParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+	})
+	g.Expect(err).ToNot(HaveOccurred(), "Diagnostic error")
+	g.Expect(report.Items).To(Equal([]lsp.Diagnostic{{
+		Range:    lsp.Range{End: lsp.Position{Character: 20}},
+		Severity: lsp.SeverityError,
+		Code:     "include-shader-type",
+		Source:   "gdshader",
+		Message:  "Shader include files can't declare a shader_type.",
+	}}))
+}
+
+// fakeClient records the notifications that are sent to it. Diagnostics
+// are published in the background, so the notifications are read with
+// received.
+type fakeClient struct {
+	mu            sync.Mutex
+	notifications []any
+}
+
+func (c *fakeClient) Notify(_ context.Context, _ string, params any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.notifications = append(c.notifications, params)
+	return nil
+}
+
+func (c *fakeClient) received() []any {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.notifications)
+}
+
+func (c *fakeClient) Request(_ context.Context, method string, _, _ any) error {
+	return &lsp.ResponseError{Code: lsp.CodeMethodNotFound, Message: method}
+}
+
+func TestHandler_PublishDiagnostics(t *testing.T) {
+	g := NewWithT(t)
+	client := &fakeClient{}
+	h := app.Handler{Client: client}
+	version := func(v int) *int { return &v }
+
+	err := h.Initialize(t.Context(), lsp.InitializeParams{}, &lsp.ServerCapabilities{})
+	g.Expect(err).ToNot(HaveOccurred(), "Initialize error")
+
+	err = h.DidOpenTextDocument(t.Context(), lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{URI: testURI, LanguageID: "gdshader", Version: 1, Text: "shader_type spatial;\n"},
+	})
+	g.Expect(err).ToNot(HaveOccurred(), "DidOpenTextDocument error")
+	g.Eventually(client.received).Should(HaveLen(1))
+
+	err = h.DidChangeTextDocument(t.Context(), lsp.DidChangeTextDocumentParams{
+		TextDocument:   lsp.VersionedTextDocumentIdentifier{URI: testURI, Version: 2},
+		ContentChanges: []lsp.TextDocumentContentChangeEvent{{Text: "float x = 1;\n"}},
+	})
+	g.Expect(err).ToNot(HaveOccurred(), "DidChangeTextDocument error")
+	g.Eventually(client.received).Should(HaveLen(2))
+
+	err = h.DidCloseTextDocument(t.Context(), lsp.DidCloseTextDocumentParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: testURI},
+	})
+	g.Expect(err).ToNot(HaveOccurred(), "DidCloseTextDocument error")
+
+	notifications := client.received()
+	g.Expect(notifications).To(HaveLen(3))
+	g.Expect(notifications[0]).To(Equal(lsp.PublishDiagnosticsParams{URI: testURI, Version: version(1), Diagnostics: []lsp.Diagnostic{}}))
+	g.Expect(notifications[1]).To(WithTransform(func(p lsp.PublishDiagnosticsParams) *int { return p.Version }, Equal(version(2))))
+	g.Expect(notifications[1]).To(WithTransform(func(p lsp.PublishDiagnosticsParams) []lsp.Diagnostic { return p.Diagnostics }, HaveLen(2)))
+	g.Expect(notifications[2]).To(Equal(lsp.PublishDiagnosticsParams{URI: testURI, Diagnostics: []lsp.Diagnostic{}}))
+}
+
+func TestHandler_PublishDiagnostics_NewerVersion(t *testing.T) {
+	g := NewWithT(t)
+	client := &fakeClient{}
+	h := app.Handler{Client: client}
+
+	err := h.Initialize(t.Context(), lsp.InitializeParams{}, &lsp.ServerCapabilities{})
+	g.Expect(err).ToNot(HaveOccurred(), "Initialize error")
+
+	err = h.DidOpenTextDocument(t.Context(), lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{URI: testURI, LanguageID: "gdshader", Version: 1, Text: "shader_type spatial;\n"},
+	})
+	g.Expect(err).ToNot(HaveOccurred(), "DidOpenTextDocument error")
+	for version := 2; version <= 10; version++ {
+		err = h.DidChangeTextDocument(t.Context(), lsp.DidChangeTextDocumentParams{
+			TextDocument:   lsp.VersionedTextDocumentIdentifier{URI: testURI, Version: version},
+			ContentChanges: []lsp.TextDocumentContentChangeEvent{{Text: strings.Repeat("float x = 1;\n", version)}},
+		})
+		g.Expect(err).ToNot(HaveOccurred(), "DidChangeTextDocument error")
+	}
+
+	// Publishing for older versions is cancelled, and never overtakes the
+	// newer versions.
+	latest := func() *int {
+		notifications := client.received()
+		if len(notifications) == 0 {
+			return nil
+		}
+		return notifications[len(notifications)-1].(lsp.PublishDiagnosticsParams).Version
+	}
+	g.Eventually(latest).Should(HaveValue(Equal(10)))
+	var versions []int
+	for _, params := range client.received() {
+		versions = append(versions, *params.(lsp.PublishDiagnosticsParams).Version)
+	}
+	g.Expect(slices.IsSorted(versions)).To(BeTrue(), "versions %v", versions)
+}
+
+func TestHandler_DidChangeTextDocument_Failed(t *testing.T) {
+	g := NewWithT(t)
+	client := &fakeClient{}
+	h := app.Handler{Client: client}
+
+	err := h.Initialize(t.Context(), lsp.InitializeParams{}, &lsp.ServerCapabilities{})
+	g.Expect(err).ToNot(HaveOccurred(), "Initialize error")
+
+	err = h.DidOpenTextDocument(t.Context(), lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{URI: testURI, LanguageID: "gdshader", Version: 1, Text: "shader_type spatial;\n"},
+	})
+	g.Expect(err).ToNot(HaveOccurred(), "DidOpenTextDocument error")
+
+	err = h.DidChangeTextDocument(t.Context(), lsp.DidChangeTextDocumentParams{
+		TextDocument: lsp.VersionedTextDocumentIdentifier{URI: testURI, Version: 2},
+		ContentChanges: []lsp.TextDocumentContentChangeEvent{{
+			Range: &lsp.Range{Start: lsp.Position{Line: 5}, End: lsp.Position{Line: 5}},
+			Text:  "void fragment() {}\n",
+		}},
+	})
+	g.Expect(err).To(MatchError(lsp.ErrChangeFailed))
+
+	lost := lsp.ShowMessageParams{
+		Type:    lsp.MessageError,
+		Message: "Lost track of the changes to " + testURI + ". Close and reopen it to continue.",
+	}
+	shown := func() int {
+		var n int
+		for _, params := range client.received() {
+			if params == lost {
+				n++
+			}
+		}
+		return n
+	}
+	g.Expect(shown()).To(Equal(1))
+
+	// The changes after it are dropped too, which the user is told about.
+	for _, change := range []struct {
+		version int
+		want    error
+	}{{1, lsp.ErrOutOfOrder}, {3, lsp.ErrStale}} {
+		err = h.DidChangeTextDocument(t.Context(), lsp.DidChangeTextDocumentParams{
+			TextDocument: lsp.VersionedTextDocumentIdentifier{URI: testURI, Version: change.version},
+			ContentChanges: []lsp.TextDocumentContentChangeEvent{{
+				Range: &lsp.Range{Start: lsp.Position{Line: 1}, End: lsp.Position{Line: 1}},
+				Text:  "void fragment() {}\n",
+			}},
+		})
+		g.Expect(err).To(MatchError(change.want), "Version %d", change.version)
+	}
+	g.Expect(shown()).To(Equal(3))
+}
+
+// blockingClient blocks every notification until its context is
+// cancelled, like a client that stopped reading.
+type blockingClient struct {
+	fakeClient
+	notifying chan struct{}
+	inFlight  atomic.Int32
+}
+
+func (c *blockingClient) Notify(ctx context.Context, _ string, _ any) error {
+	c.inFlight.Add(1)
+	defer c.inFlight.Add(-1)
+	c.notifying <- struct{}{}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestHandler_Shutdown(t *testing.T) {
+	g := NewWithT(t)
+	dir := writeFiles(t, map[string]string{"water.gdshader": "shader_type spatial;\n"})
+	client := &blockingClient{notifying: make(chan struct{}, 16)}
+	h := app.Handler{Client: client}
+
+	err := h.Initialize(t.Context(), lsp.InitializeParams{
+		WorkspaceFolders: []lsp.WorkspaceFolder{{URI: lsp.PathToURI(dir), Name: "water"}},
+	}, &lsp.ServerCapabilities{})
+	g.Expect(err).ToNot(HaveOccurred(), "Initialize error")
+	g.Expect(h.Initialized(t.Context())).To(Succeed())
+	err = h.DidOpenTextDocument(t.Context(), lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{URI: testURI, Version: 1, Text: "shader_type spatial;\n"},
+	})
+	g.Expect(err).ToNot(HaveOccurred(), "DidOpenTextDocument error")
+	g.Eventually(client.notifying).Should(Receive())
+
+	// Nothing is sent to the client once it has shut down.
+	g.Expect(h.Shutdown(t.Context())).To(Succeed())
+	g.Expect(client.inFlight.Load()).To(BeZero())
+	for len(client.notifying) > 0 {
+		<-client.notifying
+	}
+	g.Consistently(client.notifying).ShouldNot(Receive())
+}
+
+func TestHandler_PublishDiagnostics_Pull(t *testing.T) {
+	g := NewWithT(t)
+	client := &fakeClient{}
+	h := app.Handler{Client: client}
+
+	err := h.Initialize(t.Context(), lsp.InitializeParams{Capabilities: lsp.ClientCapabilities{
+		TextDocument: &lsp.TextDocumentClientCapabilities{Diagnostic: &lsp.DiagnosticClientCapabilities{}},
+	}}, &lsp.ServerCapabilities{})
+	g.Expect(err).ToNot(HaveOccurred(), "Initialize error")
+
+	err = h.DidOpenTextDocument(t.Context(), lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{URI: testURI, Text: "float x = 1;\n"},
+	})
+	g.Expect(err).ToNot(HaveOccurred(), "DidOpenTextDocument error")
+	g.Expect(client.received()).To(BeEmpty())
+}
+
+// applyTextEdits applies non-overlapping edits to a document that only
+// contains ASCII characters.
+func applyTextEdits(document string, edits []lsp.TextEdit) string {
+	lineStarts := []int{0}
+	for i, c := range document {
+		if c == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	offset := func(pos lsp.Position) int {
+		return lineStarts[pos.Line] + pos.Character
+	}
+
+	// Edits are applied from the end, so that offsets stay valid. Inserts at
+	// the same position keep their order.
+	edits = slices.Clone(edits)
+	slices.SortStableFunc(edits, func(a, b lsp.TextEdit) int { return offset(a.Range.Start) - offset(b.Range.Start) })
+	slices.Reverse(edits)
+
+	var sb strings.Builder
+	for _, edit := range edits {
+		sb.Reset()
+		sb.WriteString(document[:offset(edit.Range.Start)])
+		sb.WriteString(edit.NewText)
+		sb.WriteString(document[offset(edit.Range.End):])
+		document = sb.String()
+	}
+	return document
+}
@@ -269,18 +269,20 @@ var completionItems = func() []completionItemPredicate {
 	}
 
 	// Function uniform hints.
-	functionUniformHints := map[string]string{
-		"hint_enum":  "Displays int input as a dropdown widget in the editor.",
-		"hint_range": "Displays float input as a slider in the editor.",
+	functionUniformHints := map[string]struct{ snippet, doc string }{
+		"hint_enum":  {`hint_enum("${1:Option}")`, "Displays int input as a dropdown widget in the editor."},
+		"hint_range": {"hint_range(${1:0.0}, ${2:1.0})", "Displays float input as a slider in the editor."},
 	}
 
-	for label, doc := range functionUniformHints {
+	for label, hint := range functionUniformHints {
 		items = append(items, completionItemPredicate{
 			predicate: and(ifFirstTokenOneOf("uniform"), ifTokensContain(":")),
 			item: lsp.CompletionItem{
-				Label:         label,
-				Kind:          lsp.CompletionFunction,
-				Documentation: &lsp.MarkupContent{Kind: lsp.MarkupMarkdown, Value: doc},
+				Label:            label,
+				Kind:             lsp.CompletionFunction,
+				Documentation:    &lsp.MarkupContent{Kind: lsp.MarkupMarkdown, Value: hint.doc},
+				InsertText:       hint.snippet,
+				InsertTextFormat: lsp.InsertTextSnippet,
 			},
 		})
 	}
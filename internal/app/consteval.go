@@ -0,0 +1,278 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package app
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/armsnyder/gdshader-language-server/internal/ast"
+)
+
+// constValue is the result of evaluating a constant expression. Scalars have
+// one component. Booleans are stored as 0 or 1.
+type constValue struct {
+	typ        string
+	components []float64
+}
+
+// builtinConstants are the constants that are available in every shader.
+var builtinConstants = map[string]float64{
+	"PI":  math.Pi,
+	"TAU": 2 * math.Pi,
+	"E":   math.E,
+}
+
+var constMathFunctions = map[string]func(float64) float64{
+	"radians":     func(x float64) float64 { return x * math.Pi / 180 },
+	"degrees":     func(x float64) float64 { return x * 180 / math.Pi },
+	"sin":         math.Sin,
+	"cos":         math.Cos,
+	"tan":         math.Tan,
+	"asin":        math.Asin,
+	"acos":        math.Acos,
+	"atan":        math.Atan,
+	"exp":         math.Exp,
+	"exp2":        math.Exp2,
+	"log":         math.Log,
+	"log2":        math.Log2,
+	"sqrt":        math.Sqrt,
+	"inversesqrt": func(x float64) float64 { return 1 / math.Sqrt(x) },
+	"abs":         math.Abs,
+	"floor":       math.Floor,
+	"ceil":        math.Ceil,
+	"round":       math.Round,
+	"trunc":       math.Trunc,
+	"fract":       func(x float64) float64 { return x - math.Floor(x) },
+	"sign": func(x float64) float64 {
+		switch {
+		case x > 0:
+			return 1
+		case x < 0:
+			return -1
+		}
+		return 0
+	},
+}
+
+var constBinaryFunctions = map[string]func(a, b float64) float64{
+	"+":   func(a, b float64) float64 { return a + b },
+	"-":   func(a, b float64) float64 { return a - b },
+	"*":   func(a, b float64) float64 { return a * b },
+	"/":   func(a, b float64) float64 { return a / b },
+	"min": math.Min,
+	"max": math.Max,
+	"pow": math.Pow,
+	"mod": func(a, b float64) float64 { return a - b*math.Floor(a/b) },
+}
+
+// maxConstDepth bounds recursion through constants that reference each
+// other, which also protects against cycles.
+const maxConstDepth = 32
+
+// evalConst evaluates a constant expression. It returns false if the
+// expression is not constant or uses unsupported operations.
+func (e *typeEnv) evalConst(expr ast.Expr) (constValue, bool) {
+	return e.evalConstDepth(expr, 0)
+}
+
+func (e *typeEnv) evalConstDepth(expr ast.Expr, depth int) (constValue, bool) {
+	if depth > maxConstDepth {
+		return constValue{}, false
+	}
+	depth++
+
+	switch x := expr.(type) {
+	case *ast.Literal:
+		return evalLiteral(x)
+	case *ast.Ident:
+		if init, ok := e.consts[x.Name]; ok {
+			v, ok := e.evalConstDepth(init, depth)
+			v.typ = e.vars[x.Name]
+			return v, ok
+		}
+		if v, ok := builtinConstants[x.Name]; ok {
+			return constValue{typ: "float", components: []float64{v}}, true
+		}
+	case *ast.ParenExpr:
+		return e.evalConstDepth(x.X, depth)
+	case *ast.UnaryExpr:
+		v, ok := e.evalConstDepth(x.X, depth)
+		if !ok || x.Postfix {
+			return constValue{}, false
+		}
+		switch x.Op {
+		case "+":
+			return v, true
+		case "-":
+			return v.mapComponents(func(f float64) float64 { return -f }), true
+		}
+	case *ast.BinaryExpr:
+		fn, ok := constBinaryFunctions[x.Op]
+		if !ok {
+			return constValue{}, false
+		}
+		return e.evalConstBinary(fn, x.X, x.Y, depth)
+	case *ast.CallExpr:
+		return e.evalConstCall(x, depth)
+	case *ast.MemberExpr:
+		v, ok := e.evalConstDepth(x.X, depth)
+		typ := swizzleType(v.typ, x.Name)
+		if !ok || typ == "" {
+			return constValue{}, false
+		}
+		result := constValue{typ: typ}
+		for _, c := range x.Name {
+			for _, set := range []string{"xyzw", "rgba", "stpq"} {
+				if i := strings.IndexRune(set, c); i >= 0 {
+					result.components = append(result.components, v.components[i])
+				}
+			}
+		}
+		return result, true
+	}
+
+	return constValue{}, false
+}
+
+func evalLiteral(x *ast.Literal) (constValue, bool) {
+	switch x.Kind {
+	case ast.LiteralBool:
+		if x.Value == "true" {
+			return constValue{typ: "bool", components: []float64{1}}, true
+		}
+		return constValue{typ: "bool", components: []float64{0}}, true
+	case ast.LiteralFloat:
+		f, err := strconv.ParseFloat(strings.TrimSuffix(x.Value, "f"), 64)
+		return constValue{typ: "float", components: []float64{f}}, err == nil
+	default:
+		typ := "int"
+		if x.Kind == ast.LiteralUint {
+			typ = "uint"
+		}
+		i, err := strconv.ParseInt(strings.TrimSuffix(x.Value, "u"), 0, 64)
+		return constValue{typ: typ, components: []float64{float64(i)}}, err == nil
+	}
+}
+
+func (e *typeEnv) evalConstBinary(fn func(a, b float64) float64, left, right ast.Expr, depth int) (constValue, bool) {
+	a, ok := e.evalConstDepth(left, depth)
+	if !ok {
+		return constValue{}, false
+	}
+	b, ok := e.evalConstDepth(right, depth)
+	if !ok || isMatrix(a.typ) || isMatrix(b.typ) {
+		return constValue{}, false
+	}
+
+	result := a
+	if len(b.components) > len(a.components) {
+		result.typ = b.typ
+	}
+	size := max(len(a.components), len(b.components))
+	if len(a.components) != len(b.components) && len(a.components) != 1 && len(b.components) != 1 {
+		return constValue{}, false
+	}
+
+	result.components = make([]float64, size)
+	for i := range size {
+		result.components[i] = fn(a.components[min(i, len(a.components)-1)], b.components[min(i, len(b.components)-1)])
+	}
+	if scalarType(result.typ) == "int" || scalarType(result.typ) == "uint" {
+		result = result.mapComponents(math.Trunc)
+	}
+	return result, true
+}
+
+func (e *typeEnv) evalConstCall(x *ast.CallExpr, depth int) (constValue, bool) {
+	callee := x.Callee()
+	if callee == nil {
+		return constValue{}, false
+	}
+	name := callee.Name
+
+	if fn, ok := constMathFunctions[name]; ok && len(x.Args) == 1 {
+		v, ok := e.evalConstDepth(x.Args[0], depth)
+		return v.mapComponents(fn), ok
+	}
+
+	if fn, ok := constBinaryFunctions[name]; ok && len(x.Args) == 2 {
+		return e.evalConstBinary(fn, x.Args[0], x.Args[1], depth)
+	}
+
+	size := componentCount(name)
+	if size == 0 || isMatrix(name) {
+		return constValue{}, false
+	}
+
+	// Type constructor, such as vec3(1.0) or vec4(v.xy, 0.0, 1.0).
+	var components []float64
+	for _, arg := range x.Args {
+		v, ok := e.evalConstDepth(arg, depth)
+		if !ok {
+			return constValue{}, false
+		}
+		components = append(components, v.components...)
+	}
+	switch {
+	case len(components) == 1:
+		for len(components) < size {
+			components = append(components, components[0])
+		}
+	case len(components) < size:
+		return constValue{}, false
+	}
+
+	result := constValue{typ: name, components: components[:size]}
+	switch scalarType(name) {
+	case "int", "uint":
+		result = result.mapComponents(math.Trunc)
+	case "bool":
+		result = result.mapComponents(func(f float64) float64 {
+			if f != 0 {
+				return 1
+			}
+			return 0
+		})
+	}
+	return result, true
+}
+
+func (v constValue) mapComponents(fn func(float64) float64) constValue {
+	result := constValue{typ: v.typ, components: make([]float64, len(v.components))}
+	for i, c := range v.components {
+		result.components[i] = fn(c)
+	}
+	return result
+}
+
+// String formats the value as shader source code.
+func (v constValue) String() string {
+	scalar := scalarType(v.typ)
+	parts := make([]string, len(v.components))
+	for i, c := range v.components {
+		parts[i] = formatScalar(scalar, c)
+	}
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return v.typ + "(" + strings.Join(parts, ", ") + ")"
+}
+
+func formatScalar(scalar string, f float64) string {
+	switch scalar {
+	case "bool":
+		return strconv.FormatBool(f != 0)
+	case "int":
+		return strconv.FormatInt(int64(f), 10)
+	case "uint":
+		return strconv.FormatInt(int64(f), 10) + "u"
+	}
+	s := strconv.FormatFloat(f, 'g', 7, 32)
+	if !strings.ContainsAny(s, ".eEnN") {
+		s += ".0"
+	}
+	return s
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package app
+
+import (
+	"context"
+
+	"github.com/armsnyder/gdshader-language-server/internal/ast"
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+)
+
+// symbolScope is a document and the files that it includes, in which
+// symbols are looked up.
+type symbolScope struct {
+	doc      *lsp.Snapshot
+	file     *ast.File
+	included []*shaderFile
+}
+
+func (h *Handler) symbolScope(ctx context.Context, uri string) (*symbolScope, error) {
+	doc, file, err := h.parseDocument(uri)
+	if err != nil {
+		return nil, err
+	}
+	included := h.followIncludes(ctx, uri, doc, h.resolveIncludes(uri, file, h.project(ctx, uri)))
+	return &symbolScope{doc: doc, file: file, included: included}, nil
+}
+
+func (s *symbolScope) env(offset int) *typeEnv {
+	return newTypeEnv(s.file, offset, includedFiles(s.included)...)
+}
+
+// symbolAt returns the name and declaration of the symbol that is used or
+// declared at an offset.
+func (s *symbolScope) symbolAt(offset int) (string, symbol, bool) {
+	var name string
+	var sym symbol
+	var found bool
+	ast.Inspect(s.file, func(node ast.Node) bool {
+		if !node.Extent().Contains(offset) {
+			return false
+		}
+		if ident, ok := node.(*ast.Ident); ok {
+			name = ident.Name
+			sym, found = s.env(ident.Start).symbols[ident.Name]
+		} else if n, span, ok := declaredName(node); ok && span.Contains(offset) {
+			name, sym, found = n, symbol{s.file.Filename, span}, true
+		}
+		return true
+	})
+	return name, sym, found
+}
+
+// declaredName returns the name that a node declares.
+func declaredName(node ast.Node) (string, ast.Span, bool) {
+	switch n := node.(type) {
+	case *ast.UniformDecl:
+		return n.Name, n.NameSpan, true
+	case *ast.VaryingDecl:
+		return n.Name, n.NameSpan, true
+	case *ast.StructDecl:
+		return n.Name, n.NameSpan, true
+	case *ast.FunctionDecl:
+		return n.Name, n.NameSpan, true
+	case *ast.Param:
+		return n.Name, n.NameSpan, true
+	case *ast.Declarator:
+		return n.Name, n.NameSpan, true
+	}
+	return "", ast.Span{}, false
+}
+
+// location returns the location of a symbol, which is in the document or
+// in one of its included files.
+func (s *symbolScope) location(sym symbol) lsp.Location {
+	doc := s.doc
+	for _, inc := range s.included {
+		if inc.file.Filename == sym.filename {
+			doc = inc.doc
+		}
+	}
+	return lsp.Location{URI: sym.filename, Range: spanToRange(doc, sym.span)}
+}
+
+// isUniform reports whether a symbol is declared as a uniform.
+func (s *symbolScope) isUniform(sym symbol) bool {
+	files := append([]*ast.File{s.file}, includedFiles(s.included)...)
+	for _, file := range files {
+		if file.Filename != sym.filename {
+			continue
+		}
+		for _, decl := range file.Declarations {
+			if u := decl.UniformDecl; u != nil && u.NameSpan == sym.span {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func includedFiles(included []*shaderFile) []*ast.File {
+	files := make([]*ast.File, len(included))
+	for i, inc := range included {
+		files[i] = inc.file
+	}
+	return files
+}
+
+// Definition implements lsp.DefinitionHandler. Symbols that are declared in
+// included files are found in those files.
+func (h *Handler) Definition(ctx context.Context, params lsp.DefinitionParams) ([]lsp.Location, error) {
+	s, err := h.symbolScope(ctx, params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	offset, err := s.doc.PositionToOffset(params.Position)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, sym, ok := s.symbolAt(offset); ok {
+		return []lsp.Location{s.location(sym)}, nil
+	}
+	return []lsp.Location{}, nil
+}
+
+// References implements lsp.ReferencesHandler. The uniforms of a shader are
+// also referenced by the materials that set them.
+func (h *Handler) References(ctx context.Context, params lsp.ReferenceParams) ([]lsp.Location, error) {
+	uri := params.TextDocument.URI
+	s, err := h.symbolScope(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	offset, err := s.doc.PositionToOffset(params.Position)
+	if err != nil {
+		return nil, err
+	}
+
+	locations := []lsp.Location{}
+	name, sym, ok := s.symbolAt(offset)
+	if !ok {
+		return locations, nil
+	}
+
+	if params.Context.IncludeDeclaration {
+		locations = append(locations, s.location(sym))
+	}
+	ast.Inspect(s.file, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok && ident.Name == name && s.env(ident.Start).symbols[name] == sym {
+			locations = append(locations, lsp.Location{URI: uri, Range: spanToRange(s.doc, ident.Span)})
+		}
+		return true
+	})
+
+	if path, err := lsp.URIToPath(uri); err == nil && s.isUniform(sym) {
+		locations = append(locations, h.materialReferences(ctx, path, name)...)
+	}
+	return locations, nil
+}
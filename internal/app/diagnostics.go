@@ -0,0 +1,329 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/armsnyder/gdshader-language-server/internal/ast"
+	"github.com/armsnyder/gdshader-language-server/internal/godot"
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+)
+
+// diagnostic is a problem in a document, along with the fixes that the
+// user can apply to resolve it.
+type diagnostic struct {
+	ast.Span
+	severity lsp.DiagnosticSeverity
+	code     string
+	message  string
+	fixes    []fix
+}
+
+// fix is a set of edits that resolves a diagnostic. Each fix is offered to
+// the user as a quick fix code action.
+type fix struct {
+	title     string
+	edits     []ast.TextEdit
+	preferred bool
+}
+
+// analysis is the input to each checker.
+type analysis struct {
+	doc         *lsp.Snapshot
+	text        []byte
+	file        *ast.File
+	parseErrors ast.ErrorList
+	// include is set for shader include files, which are inserted into
+	// other shaders and have no shader_type of their own.
+	include bool
+	// project is the Godot project of the document, or nil if it isn't in
+	// one.
+	project  *godot.Project
+	includes []include
+	// included are the files that the document includes, directly or
+	// through other includes.
+	included []*shaderFile
+}
+
+// typeEnv creates a typeEnv at an offset of the document, which sees the
+// symbols of the included files.
+func (a *analysis) typeEnv(offset int) *typeEnv {
+	return newTypeEnv(a.file, offset, includedFiles(a.included)...)
+}
+
+// checker reports diagnostics for a document.
+type checker func(a *analysis) []diagnostic
+
+// checkers are run in order on every document.
+var checkers = []checker{
+	checkSyntax,
+	checkShaderType,
+	checkIntLiterals,
+	checkUnknownNames,
+	checkGodot3,
+	checkGlobalUniforms,
+	checkIncludes,
+}
+
+// analyze parses a document and runs every checker on it. It gives up early
+// if the request is cancelled.
+func (h *Handler) analyze(ctx context.Context, uri string) (*analysis, []diagnostic, error) {
+	doc, err := h.Snapshot(uri)
+	if err != nil {
+		return nil, nil, err
+	}
+	return h.analyzeSnapshot(ctx, uri, doc)
+}
+
+// analyzeSnapshot is like analyze, for content that may not be open in the
+// client.
+func (h *Handler) analyzeSnapshot(ctx context.Context, uri string, doc *lsp.Snapshot) (*analysis, []diagnostic, error) {
+	var err error
+	a := &analysis{
+		doc:     doc,
+		text:    doc.Bytes(),
+		include: isShaderInclude(uri, doc.LanguageID()),
+		project: h.project(ctx, uri),
+	}
+	a.file, err = ast.Parse(uri, bytes.NewReader(a.text))
+	if a.file == nil {
+		return nil, nil, fmt.Errorf("parse document: %w", err)
+	}
+	errors.As(err, &a.parseErrors)
+	a.includes = h.resolveIncludes(uri, a.file, a.project)
+	a.included = h.followIncludes(ctx, uri, a.doc, a.includes)
+
+	var diagnostics []diagnostic
+	for _, check := range checkers {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		diagnostics = append(diagnostics, check(a)...)
+	}
+	slices.SortStableFunc(diagnostics, func(x, y diagnostic) int { return x.Start - y.Start })
+
+	return a, diagnostics, nil
+}
+
+// Diagnostic implements lsp.DiagnosticHandler.
+func (h *Handler) Diagnostic(ctx context.Context, params lsp.DocumentDiagnosticParams) (*lsp.FullDocumentDiagnosticReport, error) {
+	a, diagnostics, err := h.analyze(ctx, params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &lsp.FullDocumentDiagnosticReport{Kind: "full", Items: []lsp.Diagnostic{}}
+	for _, d := range diagnostics {
+		report.Items = append(report.Items, d.toLSP(a.doc))
+	}
+	return report, nil
+}
+
+// DidOpenTextDocument implements lsp.Handler.
+func (h *Handler) DidOpenTextDocument(ctx context.Context, params lsp.DidOpenTextDocumentParams) error {
+	if err := h.Filesystem.DidOpenTextDocument(ctx, params); err != nil {
+		return err
+	}
+	// Load the project early, so that problems with it are reported when
+	// the user starts editing.
+	h.project(ctx, params.TextDocument.URI)
+	h.publishInBackground(ctx, params.TextDocument.URI)
+	return nil
+}
+
+// DidChangeTextDocument implements lsp.Handler. The user is told whenever a
+// change is dropped because the document is out of sync, since editors send
+// the whole content of a document again when it is opened again.
+func (h *Handler) DidChangeTextDocument(ctx context.Context, params lsp.DidChangeTextDocumentParams) error {
+	if err := h.Filesystem.DidChangeTextDocument(ctx, params); err != nil {
+		if errors.Is(err, lsp.ErrChangeFailed) || errors.Is(err, lsp.ErrOutOfOrder) || errors.Is(err, lsp.ErrStale) {
+			h.showMessage(ctx, lsp.MessageError, fmt.Sprintf("Lost track of the changes to %s. Close and reopen it to continue.", params.TextDocument.URI))
+		}
+		return err
+	}
+	h.publishInBackground(ctx, params.TextDocument.URI)
+	return nil
+}
+
+// DidCloseTextDocument implements lsp.Handler. The diagnostics of a closed
+// document are cleared, unless it is a shader file of the workspace, whose
+// diagnostics are computed from disk again. So are those of the shaders
+// that include it, since unsaved changes are dropped.
+func (h *Handler) DidCloseTextDocument(ctx context.Context, params lsp.DidCloseTextDocumentParams) error {
+	if err := h.Filesystem.DidCloseTextDocument(ctx, params); err != nil {
+		return err
+	}
+	h.publishing.stop(params.TextDocument.URI)
+	h.publishDependents(ctx, params.TextDocument.URI)
+	if h.Client == nil || h.supports((*lsp.ClientCapabilities).PullDiagnostics) {
+		return nil
+	}
+	if h.workspace.contains(params.TextDocument.URI) {
+		if _, err := h.publishFileDiagnostics(ctx, params.TextDocument.URI); err == nil {
+			return nil
+		}
+	}
+	return h.Client.Notify(ctx, "textDocument/publishDiagnostics", lsp.PublishDiagnosticsParams{
+		URI:         params.TextDocument.URI,
+		Diagnostics: []lsp.Diagnostic{},
+	})
+}
+
+// publishing tracks the diagnostics that are published in the background
+// for each document, so that publishing them for an old version of the
+// document can be cancelled.
+type publishing struct {
+	mu sync.Mutex
+	// runs are the latest publishing runs, by URI.
+	runs map[string]*publishRun
+}
+
+// publishRun publishes the diagnostics of a version of a document.
+type publishRun struct {
+	cancel context.CancelFunc
+	// previous is the run for the version before, which is cancelled and
+	// must finish first, so that diagnostics are published in order.
+	previous *publishRun
+	done     chan struct{}
+}
+
+// start starts a run for a document, and cancels the one before it.
+func (p *publishing) start(ctx context.Context, uri string) (context.Context, *publishRun) {
+	ctx, cancel := context.WithCancel(ctx)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.runs == nil {
+		p.runs = make(map[string]*publishRun)
+	}
+	run := &publishRun{cancel: cancel, previous: p.runs[uri], done: make(chan struct{})}
+	if run.previous != nil {
+		run.previous.cancel()
+	}
+	p.runs[uri] = run
+	return ctx, run
+}
+
+// finish marks a run as finished. It is forgotten unless a newer one
+// started.
+func (p *publishing) finish(uri string, run *publishRun) {
+	run.cancel()
+	p.mu.Lock()
+	if p.runs[uri] == run {
+		delete(p.runs, uri)
+	}
+	p.mu.Unlock()
+	close(run.done)
+}
+
+// stop cancels the runs for a document, and waits for them to finish.
+func (p *publishing) stop(uri string) {
+	p.mu.Lock()
+	run := p.runs[uri]
+	delete(p.runs, uri)
+	p.mu.Unlock()
+	if run != nil {
+		run.cancel()
+		<-run.done
+	}
+}
+
+// stopAll cancels the runs for every document, and waits for them to
+// finish.
+func (p *publishing) stopAll() {
+	p.mu.Lock()
+	runs := p.runs
+	p.runs = nil
+	p.mu.Unlock()
+	for _, run := range runs {
+		run.cancel()
+	}
+	// Each run waits for the one before it, so the latest runs are the last
+	// to finish.
+	for _, run := range runs {
+		<-run.done
+	}
+}
+
+// publishInBackground publishes the diagnostics of a document that was
+// opened or changed, and of the files that depend on it, without holding
+// up the notifications after it. A newer version of the document cancels
+// it.
+func (h *Handler) publishInBackground(ctx context.Context, uri string) {
+	if h.Client == nil || h.supports((*lsp.ClientCapabilities).PullDiagnostics) {
+		return
+	}
+
+	ctx, run := h.publishing.start(ctx, uri)
+	go func() {
+		defer h.publishing.finish(uri, run)
+		if run.previous != nil {
+			<-run.previous.done
+			run.previous = nil
+		}
+
+		if err := h.publishDiagnostics(ctx, uri); err != nil {
+			if ctx.Err() == nil {
+				h.logMessage(ctx, lsp.MessageWarning, fmt.Sprintf("Failed to check %s: %v", uri, err))
+			}
+			return
+		}
+		h.publishDependents(ctx, uri)
+	}()
+}
+
+// publishDiagnostics sends the diagnostics of a document to clients that
+// don't pull them. They carry the version of the document that they were
+// computed from, so that the client can drop them if it has moved on.
+func (h *Handler) publishDiagnostics(ctx context.Context, uri string) error {
+	if h.Client == nil || h.supports((*lsp.ClientCapabilities).PullDiagnostics) {
+		return nil
+	}
+
+	a, diagnostics, err := h.analyze(ctx, uri)
+	if err != nil {
+		return err
+	}
+
+	version := a.doc.Version()
+	params := lsp.PublishDiagnosticsParams{URI: uri, Version: &version, Diagnostics: []lsp.Diagnostic{}}
+	for _, d := range diagnostics {
+		params.Diagnostics = append(params.Diagnostics, d.toLSP(a.doc))
+	}
+	return h.Client.Notify(ctx, "textDocument/publishDiagnostics", params)
+}
+
+// isShaderInclude reports whether a document is a shader include file. The
+// file extension is used for clients that don't tell the languages apart.
+func isShaderInclude(uri, languageID string) bool {
+	return languageID == "gdshaderinc" || strings.HasSuffix(uri, ".gdshaderinc")
+}
+
+func (d diagnostic) toLSP(doc *lsp.Snapshot) lsp.Diagnostic {
+	return lsp.Diagnostic{
+		Range:    spanToRange(doc, d.Span),
+		Severity: d.severity,
+		Code:     d.code,
+		Source:   "gdshader",
+		Message:  d.message,
+	}
+}
+
+func spanToRange(doc *lsp.Snapshot, span ast.Span) lsp.Range {
+	return lsp.Range{Start: doc.OffsetToPosition(span.Start), End: doc.OffsetToPosition(span.End)}
+}
+
+func toLSPEdits(doc *lsp.Snapshot, edits []ast.TextEdit) []lsp.TextEdit {
+	result := make([]lsp.TextEdit, len(edits))
+	for i, edit := range edits {
+		result[i] = lsp.TextEdit{Range: spanToRange(doc, edit.Span), NewText: edit.NewText}
+	}
+	return result
+}
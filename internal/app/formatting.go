@@ -0,0 +1,140 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/armsnyder/gdshader-language-server/internal/ast"
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+)
+
+// Formatting implements lsp.FormattingHandler.
+func (h *Handler) Formatting(_ context.Context, params lsp.DocumentFormattingParams) ([]lsp.TextEdit, error) {
+	doc, err := h.Snapshot(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	return formatRange(doc, params.Options, 0, doc.Len()), nil
+}
+
+// RangeFormatting implements lsp.RangeFormattingHandler.
+func (h *Handler) RangeFormatting(_ context.Context, params lsp.DocumentRangeFormattingParams) ([]lsp.TextEdit, error) {
+	doc, err := h.Snapshot(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := doc.RangeToOffsets(params.Range)
+	if err != nil {
+		return nil, err
+	}
+
+	// Trailing newlines are only touched when formatting the whole file.
+	options := params.Options
+	if end < doc.Len() {
+		options.InsertFinalNewline = false
+		options.TrimFinalNewlines = false
+	}
+
+	return formatRange(doc, options, start, end), nil
+}
+
+// OnTypeFormattingOptions implements lsp.OnTypeFormattingHandler.
+func (h *Handler) OnTypeFormattingOptions() lsp.DocumentOnTypeFormattingOptions {
+	return lsp.DocumentOnTypeFormattingOptions{
+		FirstTriggerCharacter: "}",
+		MoreTriggerCharacter:  []string{";"},
+	}
+}
+
+// OnTypeFormatting implements lsp.OnTypeFormattingHandler. Typing ";"
+// formats the current line, and typing "}" formats the whole block that it
+// closes.
+func (h *Handler) OnTypeFormatting(_ context.Context, params lsp.DocumentOnTypeFormattingParams) ([]lsp.TextEdit, error) {
+	doc, err := h.Snapshot(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	end, err := doc.PositionToOffset(params.Position)
+	if err != nil {
+		return nil, fmt.Errorf("position: %w", err)
+	}
+
+	text := doc.Bytes()
+	start := end
+	switch params.Ch {
+	case ";":
+	case "}":
+		start = matchingOpenBrace(text, end-1)
+	default:
+		return nil, nil
+	}
+	start = bytes.LastIndexByte(text[:max(start, 0)], '\n') + 1
+
+	options := params.Options
+	options.InsertFinalNewline = false
+	options.TrimFinalNewlines = false
+
+	return formatRange(doc, options, start, end), nil
+}
+
+// matchingOpenBrace returns the offset of the "{" that is closed by the "}"
+// at the given offset, or the offset itself if there is none.
+func matchingOpenBrace(text []byte, offset int) int {
+	var open []int
+	for _, tok := range ast.Tokenize(text) {
+		if tok.Kind != ast.TokenPunct || tok.Start > offset {
+			continue
+		}
+		switch tok.Text {
+		case "{":
+			open = append(open, tok.Start)
+		case "}":
+			if len(open) == 0 {
+				continue
+			}
+			if tok.Start == offset {
+				return open[len(open)-1]
+			}
+			open = open[:len(open)-1]
+		}
+	}
+	return offset
+}
+
+// formatRange formats a document and returns the edits that touch the byte
+// range from start to end.
+func formatRange(doc *lsp.Snapshot, options lsp.FormattingOptions, start, end int) []lsp.TextEdit {
+	edits := ast.Format(doc.Bytes(), ast.FormatOptions{
+		TabSize:                options.TabSize,
+		InsertSpaces:           options.InsertSpaces,
+		TrimTrailingWhitespace: options.TrimTrailingWhitespace,
+		InsertFinalNewline:     options.InsertFinalNewline,
+		TrimFinalNewlines:      options.TrimFinalNewlines,
+	})
+
+	result := []lsp.TextEdit{}
+	for _, edit := range edits {
+		// An edit that ends at the start of the range re-indents its first
+		// line.
+		overlaps := edit.Start < end && edit.End >= start
+		inserts := edit.Start == edit.End && edit.Start >= start && edit.Start <= end
+		if !overlaps && !inserts {
+			continue
+		}
+		result = append(result, lsp.TextEdit{
+			Range: lsp.Range{
+				Start: doc.OffsetToPosition(edit.Start),
+				End:   doc.OffsetToPosition(edit.End),
+			},
+			NewText: edit.NewText,
+		})
+	}
+	return result
+}
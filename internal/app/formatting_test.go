@@ -0,0 +1,110 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package app_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/armsnyder/gdshader-language-server/internal/app"
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+)
+
+func TestHandler_Formatting(t *testing.T) {
+	g := NewWithT(t)
+	h := openDocument(t, "void f(){\nfloat x=1.0;\n}")
+
+	edits, err := h.Formatting(t.Context(), lsp.DocumentFormattingParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: testURI},
+		Options:      lsp.FormattingOptions{TabSize: 4, InsertSpaces: true, InsertFinalNewline: true},
+	})
+	g.Expect(err).ToNot(HaveOccurred(), "Formatting error")
+	g.Expect(edits).To(Equal([]lsp.TextEdit{
+		{Range: lsp.Range{Start: lsp.Position{Line: 0, Character: 8}, End: lsp.Position{Line: 0, Character: 8}}, NewText: " "},
+		{Range: lsp.Range{Start: lsp.Position{Line: 0, Character: 9}, End: lsp.Position{Line: 1, Character: 0}}, NewText: "\n    "},
+		{Range: lsp.Range{Start: lsp.Position{Line: 1, Character: 7}, End: lsp.Position{Line: 1, Character: 7}}, NewText: " "},
+		{Range: lsp.Range{Start: lsp.Position{Line: 1, Character: 8}, End: lsp.Position{Line: 1, Character: 8}}, NewText: " "},
+		{Range: lsp.Range{Start: lsp.Position{Line: 2, Character: 1}, End: lsp.Position{Line: 2, Character: 1}}, NewText: "\n"},
+	}))
+}
+
+func TestHandler_RangeFormatting(t *testing.T) {
+	g := NewWithT(t)
+	h := openDocument(t, "void f() {\n\tx=1;\n\ty=2;\n}\n")
+
+	edits, err := h.RangeFormatting(t.Context(), lsp.DocumentRangeFormattingParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: testURI},
+		Range:        lsp.Range{Start: lsp.Position{Line: 2}, End: lsp.Position{Line: 2, Character: 5}},
+	})
+	g.Expect(err).ToNot(HaveOccurred(), "RangeFormatting error")
+	g.Expect(edits).To(Equal([]lsp.TextEdit{
+		{Range: lsp.Range{Start: lsp.Position{Line: 2, Character: 2}, End: lsp.Position{Line: 2, Character: 2}}, NewText: " "},
+		{Range: lsp.Range{Start: lsp.Position{Line: 2, Character: 3}, End: lsp.Position{Line: 2, Character: 3}}, NewText: " "},
+	}))
+}
+
+func TestHandler_OnTypeFormatting(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		position lsp.Position
+		ch       string
+		want     []lsp.TextEdit
+	}{
+		{
+			name:     "Semicolon",
+			document: "void f() {\nx=1;\ny=2;\n}\n",
+			position: lsp.Position{Line: 1, Character: 4},
+			ch:       ";",
+			want: []lsp.TextEdit{
+				{Range: lsp.Range{Start: lsp.Position{Line: 0, Character: 10}, End: lsp.Position{Line: 1, Character: 0}}, NewText: "\n\t"},
+				{Range: lsp.Range{Start: lsp.Position{Line: 1, Character: 1}, End: lsp.Position{Line: 1, Character: 1}}, NewText: " "},
+				{Range: lsp.Range{Start: lsp.Position{Line: 1, Character: 2}, End: lsp.Position{Line: 1, Character: 2}}, NewText: " "},
+			},
+		},
+		{
+			name:     "ClosingBrace",
+			document: "float x=1.0;\nvoid f() {\nif(true){\nx=2.0;\n}\n}\n",
+			position: lsp.Position{Line: 4, Character: 1},
+			ch:       "}",
+			want: []lsp.TextEdit{
+				{Range: lsp.Range{Start: lsp.Position{Line: 1, Character: 10}, End: lsp.Position{Line: 2, Character: 0}}, NewText: "\n\t"},
+				{Range: lsp.Range{Start: lsp.Position{Line: 2, Character: 2}, End: lsp.Position{Line: 2, Character: 2}}, NewText: " "},
+				{Range: lsp.Range{Start: lsp.Position{Line: 2, Character: 8}, End: lsp.Position{Line: 2, Character: 8}}, NewText: " "},
+				{Range: lsp.Range{Start: lsp.Position{Line: 2, Character: 9}, End: lsp.Position{Line: 3, Character: 0}}, NewText: "\n\t\t"},
+				{Range: lsp.Range{Start: lsp.Position{Line: 3, Character: 1}, End: lsp.Position{Line: 3, Character: 1}}, NewText: " "},
+				{Range: lsp.Range{Start: lsp.Position{Line: 3, Character: 2}, End: lsp.Position{Line: 3, Character: 2}}, NewText: " "},
+				{Range: lsp.Range{Start: lsp.Position{Line: 3, Character: 6}, End: lsp.Position{Line: 4, Character: 0}}, NewText: "\n\t"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			h := openDocument(t, tt.document)
+
+			edits, err := h.OnTypeFormatting(t.Context(), lsp.DocumentOnTypeFormattingParams{
+				TextDocument: lsp.TextDocumentIdentifier{URI: testURI},
+				Position:     tt.position,
+				Ch:           tt.ch,
+			})
+			g.Expect(err).ToNot(HaveOccurred(), "OnTypeFormatting error")
+			g.Expect(edits).To(Equal(tt.want))
+		})
+	}
+}
+
+const testURI = "file:///test.gdshader"
+
+func openDocument(t *testing.T, text string) *app.Handler {
+	t.Helper()
+	h := &app.Handler{}
+	err := h.DidOpenTextDocument(t.Context(), lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{URI: testURI, Text: text},
+	})
+	NewWithT(t).Expect(err).ToNot(HaveOccurred(), "DidOpenTextDocument error")
+	return h
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/armsnyder/gdshader-language-server/internal/ast"
+	"github.com/armsnyder/gdshader-language-server/internal/godot"
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+)
+
+// globalUniformTypes are the shader types of the global uniform types in
+// project.godot that are not named after a shader type.
+var globalUniformTypes = map[string]string{
+	"color":           "vec4",
+	"rect2":           "vec4",
+	"rect2i":          "ivec4",
+	"transform_2d":    "mat3",
+	"transform":       "mat4",
+	"samplerExternal": "samplerExternalOES",
+}
+
+// globalUniformType returns the shader type that a global uniform must be
+// declared with.
+func globalUniformType(global godot.ShaderGlobal) string {
+	if typ, ok := globalUniformTypes[global.Type]; ok {
+		return typ
+	}
+	return global.Type
+}
+
+// checkGlobalUniforms reports global uniforms that the project doesn't
+// define, or that are declared with a different type than in the project.
+// Shaders outside of a project are not checked, since their globals are
+// unknown.
+func checkGlobalUniforms(a *analysis) []diagnostic {
+	if a.project == nil {
+		return nil
+	}
+
+	var diagnostics []diagnostic
+	for _, decl := range a.file.Declarations {
+		u := decl.UniformDecl
+		if u == nil || u.Scope != "global" || u.Name == "" {
+			continue
+		}
+
+		global, ok := a.project.ShaderGlobal(u.Name)
+		if !ok {
+			diagnostics = append(diagnostics, diagnostic{
+				Span:     u.NameSpan,
+				severity: lsp.SeverityError,
+				code:     "undefined-global-uniform",
+				message:  fmt.Sprintf("Global uniform '%s' is not defined in the shader globals of project.godot.", u.Name),
+			})
+			continue
+		}
+
+		if want := globalUniformType(global); u.Type != "" && u.Type != want {
+			diagnostics = append(diagnostics, diagnostic{
+				Span:     u.TypeSpan,
+				severity: lsp.SeverityError,
+				code:     "global-uniform-type",
+				message:  fmt.Sprintf("Global uniform '%s' is a %s in project.godot, so it must be declared as %s.", u.Name, global.Type, want),
+				fixes: []fix{{
+					title:     fmt.Sprintf("Change type to '%s'", want),
+					edits:     []ast.TextEdit{{Span: u.TypeSpan, NewText: want}},
+					preferred: true,
+				}},
+			})
+		}
+	}
+	return diagnostics
+}
+
+// globalUniformCompletions offers the global uniforms of the project after
+// "global uniform <type>", for the globals of that type.
+func globalUniformCompletions(project *godot.Project, c *completionContext, currentWord string) []lsp.CompletionItem {
+	tokens := c.lineTokens
+	if project == nil || len(tokens) < 3 || tokens[0] != "global" || tokens[1] != "uniform" {
+		return nil
+	}
+	// A precision qualifier can come before the type.
+	if len(tokens) > 4 || (len(tokens) == 4 && !slices.Contains([]string{"lowp", "mediump", "highp"}, tokens[2])) {
+		return nil
+	}
+	typ := c.lastToken()
+
+	var items []lsp.CompletionItem
+	for _, global := range project.ShaderGlobals {
+		if globalUniformType(global) != typ || !strings.HasPrefix(global.Name, currentWord) {
+			continue
+		}
+		documentation := globalUniformDocumentation(global)
+		items = append(items, lsp.CompletionItem{
+			Label:         global.Name,
+			Kind:          lsp.CompletionVariable,
+			Detail:        global.Type,
+			Documentation: &documentation,
+		})
+	}
+	return items
+}
+
+// globalUniformDocumentation describes a global uniform as it is
+// configured in the project.
+func globalUniformDocumentation(global godot.ShaderGlobal) lsp.MarkupContent {
+	value := fmt.Sprintf("`global uniform %s %s`\n\nGlobal shader uniform of type `%s` in project.godot.", globalUniformType(global), global.Name, global.Type)
+	if global.Value.Text != "" {
+		value += fmt.Sprintf("\n\nDefault: `%s`", global.Value.Text)
+	}
+	return lsp.MarkupContent{Kind: lsp.MarkupMarkdown, Value: value}
+}
+
+// declaresGlobalUniform reports whether a file declares a global uniform.
+func declaresGlobalUniform(file *ast.File, name string) bool {
+	for _, decl := range file.Declarations {
+		if u := decl.UniformDecl; u != nil && u.Scope == "global" && u.Name == name {
+			return true
+		}
+	}
+	return false
+}
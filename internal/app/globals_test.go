@@ -0,0 +1,95 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package app_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+	"github.com/armsnyder/gdshader-language-server/internal/lsptest"
+)
+
+const globalsProject = `config_version=5
+
+[shader_globals]
+
+wind_strength={
+"type": "float",
+"value": 0.5
+}
+sky_tint={
+"type": "color",
+"value": Color(0.2, 0.4, 1, 1)
+}
+sky_gradient={
+"type": "color",
+"value": Color(0, 0, 0, 1)
+}
+`
+
+// startProject starts a session for a shader in a project with global
+// uniforms, and returns the URI of the shader.
+func startProject(t *testing.T) (*lsptest.Client, string) {
+	dir := writeFiles(t, map[string]string{"project.godot": globalsProject})
+	return startSession(t, lsptest.Options{}), lsp.PathToURI(filepath.Join(dir, "water.gdshader"))
+}
+
+func TestHandler_GlobalUniforms_Diagnostics(t *testing.T) {
+	g := NewWithT(t)
+	c, uri := startProject(t)
+
+	f := c.Open(uri, `shader_type spatial;
+global uniform float wind_strength;
+global uniform /*^type*/vec3 sky_tint;
+global uniform float /*^name*/gust;
+uniform float local;
+`)
+	diagnostics := c.WaitDiagnostics(uri)
+	g.Expect(diagnostics).To(HaveLen(2))
+	g.Expect(diagnostics[0].Code).To(Equal("global-uniform-type"))
+	g.Expect(diagnostics[0].Range.Start).To(Equal(f.At("type")))
+	g.Expect(diagnostics[0].Message).To(Equal("Global uniform 'sky_tint' is a color in project.godot, so it must be declared as vec4."))
+	g.Expect(diagnostics[1].Code).To(Equal("undefined-global-uniform"))
+	g.Expect(diagnostics[1].Range.Start).To(Equal(f.At("name")))
+}
+
+func TestHandler_GlobalUniforms_OutsideProject(t *testing.T) {
+	g := NewWithT(t)
+	c := startSession(t, lsptest.Options{})
+	uri := lsp.PathToURI(filepath.Join(t.TempDir(), "water.gdshader"))
+
+	c.Open(uri, "shader_type spatial;\nglobal uniform float gust;\n")
+	g.Expect(c.WaitDiagnostics(uri)).To(BeEmpty())
+}
+
+func TestHandler_GlobalUniforms_Completion(t *testing.T) {
+	g := NewWithT(t)
+	c, uri := startProject(t)
+
+	f := c.Open(uri, "shader_type spatial;\nglobal uniform vec4 sky/*^vec4*/\nglobal uniform highp float wi/*^float*/\nuniform vec4 sk/*^local*/\n")
+
+	items := c.Complete(uri, f.At("vec4")).Items
+	g.Expect(items).To(ConsistOf(
+		And(HaveField("Label", "sky_tint"), HaveField("Detail", "color")),
+		HaveField("Label", "sky_gradient"),
+	))
+	g.Expect(c.Complete(uri, f.At("float")).Items).To(ContainElement(HaveField("Label", "wind_strength")))
+	g.Expect(c.Complete(uri, f.At("local")).Items).ToNot(ContainElement(HaveField("Label", "sky_tint")))
+}
+
+func TestHandler_GlobalUniforms_Hover(t *testing.T) {
+	g := NewWithT(t)
+	c, uri := startProject(t)
+
+	f := c.Open(uri, "shader_type spatial;\nglobal uniform vec4 sky_tint;\nvoid fragment() {\n\tALBEDO = /*^use*/sky_tint.rgb;\n}\n")
+
+	hover := c.Hover(uri, f.At("use"))
+	g.Expect(hover).ToNot(BeNil())
+	g.Expect(hover.Contents.Value).To(ContainSubstring("global uniform vec4 sky_tint"))
+	g.Expect(hover.Contents.Value).To(ContainSubstring("type color"))
+	g.Expect(hover.Contents.Value).To(ContainSubstring("Color(0.2, 0.4, 1, 1)"))
+}
@@ -8,11 +8,14 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"regexp"
 	"slices"
 	"strings"
+	"sync/atomic"
 	"unicode"
 	"unicode/utf8"
 
+	"github.com/armsnyder/gdshader-language-server/internal/ast"
 	"github.com/armsnyder/gdshader-language-server/internal/lsp"
 	"github.com/samber/lo"
 )
@@ -20,77 +23,142 @@ import (
 // Handler encapsulates the logic of the Godot shader language server.
 type Handler struct {
 	lsp.Filesystem
+
+	// Client receives the diagnostics that are published to clients that
+	// don't pull them.
+	Client lsp.Client
+
+	settings atomic.Pointer[Settings]
+	// capabilities are those of the client, or nil before initialization.
+	capabilities atomic.Pointer[lsp.ClientCapabilities]
+	workspace    workspace
+	projects     projects
+	includeGraph includeGraph
+	materials    materials
+	publishing   publishing
+	indexing     indexing
+}
+
+// Initialize implements lsp.InitializeHandler.
+func (h *Handler) Initialize(_ context.Context, params lsp.InitializeParams, serverCapabilities *lsp.ServerCapabilities) error {
+	h.capabilities.Store(&params.Capabilities)
+	h.PositionEncoding = lsp.ChoosePositionEncoding(params.Capabilities.PositionEncodings())
+	h.workspace.setRoots(params)
+
+	serverCapabilities.PositionEncoding = h.PositionEncoding
+	serverCapabilities.CodeActionProvider = &lsp.CodeActionOptions{
+		CodeActionKinds: []lsp.CodeActionKind{lsp.CodeActionQuickFix, lsp.CodeActionRefactorExtract, codeActionMigrate},
+	}
+	return nil
+}
+
+// Shutdown implements lsp.ShutdownHandler. The workspace indexing and the
+// diagnostics that are published in the background are stopped, since they
+// can't be sent once the connection closes.
+func (h *Handler) Shutdown(context.Context) error {
+	h.indexing.stop()
+	h.publishing.stopAll()
+	return nil
+}
+
+// supports reports whether the client has a capability. Before
+// initialization, every capability is assumed, so that the handler can be
+// used without a client.
+func (h *Handler) supports(capability func(c *lsp.ClientCapabilities) bool) bool {
+	c := h.capabilities.Load()
+	return c == nil || capability(c)
 }
 
-// Initialize implements lsp.Handler.
-func (h *Handler) Initialize(context.Context, lsp.ClientCapabilities) (*lsp.ServerCapabilities, error) {
-	return &lsp.ServerCapabilities{
-		TextDocumentSync: &lsp.TextDocumentSyncOptions{
-			OpenClose: true,
-			Change:    lsp.SyncIncremental,
-		},
-		CompletionProvider: &lsp.CompletionOptions{},
-		HoverProvider:      true,
-	}, nil
+// parseDocument parses the current content of a document. Syntax errors are
+// tolerated, since the parser returns everything it could make sense of.
+func (h *Handler) parseDocument(uri string) (*lsp.Snapshot, *ast.File, error) {
+	doc, err := h.Snapshot(uri)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := ast.Parse(uri, bytes.NewReader(doc.Bytes()))
+	if file == nil {
+		return nil, nil, fmt.Errorf("parse document: %w", err)
+	}
+
+	return doc, file, nil
 }
 
-// Hover implements lsp.Handler.
-func (h *Handler) Hover(_ context.Context, params lsp.HoverParams) (*lsp.Hover, error) {
-	word, err := h.getWordAtPosition(params.TextDocumentPositionParams)
+// readRange reads the content in a range of a snapshot.
+func readRange(doc *lsp.Snapshot, r lsp.Range) ([]byte, error) {
+	reader, err := doc.Slice(r)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(reader)
+}
+
+// lineRange is the range of a whole line, including its line ending.
+func lineRange(line int) lsp.Range {
+	return lsp.Range{Start: lsp.Position{Line: line}, End: lsp.Position{Line: line + 1}}
+}
+
+// Hover implements lsp.HoverHandler.
+func (h *Handler) Hover(ctx context.Context, params lsp.HoverParams) (*lsp.Hover, error) {
+	doc, file, err := h.parseDocument(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	word, _, err := doc.TokenAt(params.Position)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get word: %w", err)
 	}
 
-	if word == "" {
+	if r, _ := utf8.DecodeRuneInString(word); !unicode.IsLetter(r) && r != '_' {
 		return nil, nil
 	}
 
+	if project := h.project(ctx, params.TextDocument.URI); project != nil && declaresGlobalUniform(file, word) {
+		if global, ok := project.ShaderGlobal(word); ok {
+			return h.hover(globalUniformDocumentation(global)), nil
+		}
+	}
+
 	for _, item := range completionItems {
 		if item.item.Label == word && item.item.Documentation != nil {
-			return &lsp.Hover{
-				Contents: *item.item.Documentation,
-			}, nil
+			return h.hover(*item.item.Documentation), nil
 		}
 	}
 
 	return nil, nil
 }
 
-func (h *Handler) getWordAtPosition(params lsp.TextDocumentPositionParams) (string, error) {
-	doc, ok := h.Documents[params.TextDocument.URI]
-	if !ok {
-		return "", fmt.Errorf("document not found: %s", params.TextDocument.URI)
+// hover adapts hover contents to the client.
+func (h *Handler) hover(contents lsp.MarkupContent) *lsp.Hover {
+	if !h.supports((*lsp.ClientCapabilities).HoverMarkdown) {
+		contents = plainText(contents)
 	}
+	return &lsp.Hover{Contents: contents}
+}
 
-	line, err := h.readLine(doc, params.Position.Line)
-	if err != nil {
-		return "", fmt.Errorf("reading line: %w", err)
+var markdownLink = regexp.MustCompile(`\[([^\]]*)\]\(([^)]*)\)`)
+
+// plainText converts markdown content for clients that can't display it.
+// Only the markdown that the documentation uses is handled.
+func plainText(content lsp.MarkupContent) lsp.MarkupContent {
+	if content.Kind != lsp.MarkupMarkdown {
+		return content
 	}
+	value := markdownLink.ReplaceAllString(content.Value, "$1 ($2)")
+	value = strings.ReplaceAll(value, "`", "")
+	return lsp.MarkupContent{Kind: lsp.MarkupPlainText, Value: value}
+}
 
-	tokens := tokenize(line)
-	offset := 0
-	for _, token := range tokens {
-		tokenStart := strings.Index(string(line[offset:]), token)
-		if tokenStart < 0 {
-			continue
-		}
-		tokenStart += offset
-		tokenEnd := tokenStart + len(token)
-		if params.Position.Character >= tokenStart && params.Position.Character < tokenEnd {
-			r, _ := utf8.DecodeRuneInString(token)
-			if unicode.IsLetter(r) || r == '_' {
-				return token, nil
-			}
-			return "", nil
+// Completion implements lsp.CompletionHandler.
+func (h *Handler) Completion(ctx context.Context, params lsp.CompletionParams) (*lsp.CompletionList, error) {
+	if doc, err := h.Snapshot(params.TextDocument.URI); err == nil {
+		if items, ok := h.includeCompletions(ctx, doc, params.TextDocument.URI, params.Position); ok {
+			return &lsp.CompletionList{Items: items}, nil
 		}
-		offset = tokenEnd
 	}
 
-	return "", nil
-}
-
-// Completion implements lsp.Handler.
-func (h *Handler) Completion(_ context.Context, params lsp.CompletionParams) (*lsp.CompletionList, error) {
 	currentWord, c, err := h.getCompletionContext(params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get context: %w", err)
@@ -104,28 +172,48 @@ func (h *Handler) Completion(_ context.Context, params lsp.CompletionParams) (*l
 	// - Struct fields
 	// - Built-in functions https://docs.godotengine.org/en/stable/tutorials/shaders/shader_reference/shader_functions.html#
 
-	return &lsp.CompletionList{
-		Items: lo.FilterMap(completionItems, func(item completionItemPredicate, _ int) (lsp.CompletionItem, bool) {
-			return item.item, strings.HasPrefix(item.item.Label, currentWord) && item.predicate(*c)
-		}),
-	}, nil
+	items := lo.FilterMap(completionItems, func(item completionItemPredicate, _ int) (lsp.CompletionItem, bool) {
+		return h.adaptCompletionItem(item.item), strings.HasPrefix(item.item.Label, currentWord) && item.predicate(*c)
+	})
+	for _, item := range globalUniformCompletions(h.project(ctx, params.TextDocument.URI), c, currentWord) {
+		items = append(items, h.adaptCompletionItem(item))
+	}
+
+	return &lsp.CompletionList{Items: items}, nil
+}
+
+// adaptCompletionItem leaves out the parts of a completion item that the
+// client doesn't support.
+func (h *Handler) adaptCompletionItem(item lsp.CompletionItem) lsp.CompletionItem {
+	if item.Documentation != nil && !h.supports((*lsp.ClientCapabilities).CompletionMarkdown) {
+		documentation := plainText(*item.Documentation)
+		item.Documentation = &documentation
+	}
+	if !h.supports(func(c *lsp.ClientCapabilities) bool { return c.CompletionKind(item.Kind) }) {
+		item.Kind = 0
+	}
+	if item.InsertTextFormat == lsp.InsertTextSnippet && !h.supports((*lsp.ClientCapabilities).CompletionSnippets) {
+		item.InsertText = ""
+		item.InsertTextFormat = 0
+	}
+	return item
 }
 
 func (h *Handler) getCompletionContext(params lsp.CompletionParams) (currentWord string, c *completionContext, err error) {
-	doc, ok := h.Documents[params.TextDocument.URI]
-	if !ok {
-		return "", nil, fmt.Errorf("document not found: %s", params.TextDocument.URI)
+	doc, err := h.Snapshot(params.TextDocument.URI)
+	if err != nil {
+		return "", nil, err
 	}
 
 	lineStartPos := params.Position
 	lineStartPos.Character = 0
 
-	line, err := h.readBetweenPositions(doc, lineStartPos, params.Position)
+	line, err := readRange(doc, lsp.Range{Start: lineStartPos, End: params.Position})
 	if err != nil {
 		return "", nil, fmt.Errorf("reading current line: %w", err)
 	}
 
-	firstLine, err := h.readLine(doc, 0)
+	firstLine, err := readRange(doc, lineRange(0))
 	if err != nil {
 		return "", nil, fmt.Errorf("reading first line: %w", err)
 	}
@@ -151,40 +239,9 @@ func (h *Handler) getCompletionContext(params lsp.CompletionParams) (currentWord
 	return tokens[len(tokens)-1], c, nil
 }
 
-func (h *Handler) readBetweenPositions(doc *lsp.Document, startPos, endPos lsp.Position) ([]byte, error) {
-	startOffset, err := doc.PositionToOffset(startPos)
-	if err != nil {
-		return nil, fmt.Errorf("start position to offset: %w", err)
-	}
-
-	var endOffset int
-	if endPos.Line >= doc.Lines()-1 {
-		endOffset = doc.Len()
-	} else {
-		endOffset, err = doc.PositionToOffset(endPos)
-		if err != nil {
-			return nil, fmt.Errorf("end position to offset: %w", err)
-		}
-	}
-
-	return io.ReadAll(io.NewSectionReader(doc, int64(startOffset), int64(endOffset-startOffset)))
-}
-
-func (h *Handler) readLine(doc *lsp.Document, lineNumber int) ([]byte, error) {
-	startPos := lsp.Position{Line: lineNumber, Character: 0}
-	endPos := lsp.Position{Line: lineNumber + 1, Character: 0}
-
-	line, err := h.readBetweenPositions(doc, startPos, endPos)
-	if err != nil {
-		return nil, fmt.Errorf("reading line %d: %w", lineNumber, err)
-	}
-
-	return line, nil
-}
-
-func (h *Handler) getCurrentFunction(doc *lsp.Document, pos lsp.Position) (string, error) {
+func (h *Handler) getCurrentFunction(doc *lsp.Snapshot, pos lsp.Position) (string, error) {
 	for lineNumber := pos.Line; lineNumber >= 0; lineNumber-- {
-		line, err := h.readLine(doc, lineNumber)
+		line, err := readRange(doc, lineRange(lineNumber))
 		if err != nil {
 			return "", fmt.Errorf("reading line %d: %w", lineNumber, err)
 		}
@@ -231,4 +288,21 @@ func tokenize(line []byte) []string {
 	}
 }
 
-var _ lsp.Handler = &Handler{}
+var (
+	_ lsp.Handler                       = &Handler{}
+	_ lsp.InitializeHandler             = &Handler{}
+	_ lsp.InitializedHandler            = &Handler{}
+	_ lsp.DidChangeConfigurationHandler = &Handler{}
+	_ lsp.CompletionHandler             = &Handler{}
+	_ lsp.HoverHandler                  = &Handler{}
+	_ lsp.DefinitionHandler             = &Handler{}
+	_ lsp.ReferencesHandler             = &Handler{}
+	_ lsp.InlayHintHandler              = &Handler{}
+	_ lsp.FormattingHandler             = &Handler{}
+	_ lsp.RangeFormattingHandler        = &Handler{}
+	_ lsp.OnTypeFormattingHandler       = &Handler{}
+	_ lsp.CodeActionHandler             = &Handler{}
+	_ lsp.DiagnosticHandler             = &Handler{}
+	_ lsp.DocumentLinkHandler           = &Handler{}
+	_ lsp.ExecuteCommandHandler         = &Handler{}
+)
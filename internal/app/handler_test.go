@@ -4,6 +4,7 @@
 package app_test
 
 import (
+	"sync"
 	"testing"
 
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -11,6 +12,8 @@ import (
 
 	"github.com/armsnyder/gdshader-language-server/internal/app"
 	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+	"github.com/armsnyder/gdshader-language-server/internal/lsptest"
+	"github.com/armsnyder/gdshader-language-server/internal/testutil"
 )
 
 func TestHandler(t *testing.T) {
@@ -27,7 +30,7 @@ func TestHandler(t *testing.T) {
 
 		// Type the first character.
 		err = h.DidChangeTextDocument(t.Context(), lsp.DidChangeTextDocumentParams{
-			TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+			TextDocument: lsp.VersionedTextDocumentIdentifier{URI: uri, Version: 1},
 			ContentChanges: []lsp.TextDocumentContentChangeEvent{{
 				Range: &lsp.Range{},
 				Text:  "s",
@@ -49,71 +52,249 @@ func TestHandler(t *testing.T) {
 	})
 }
 
+// startSession serves a handler to an in-memory client.
+func startSession(t *testing.T, options lsptest.Options) *lsptest.Client {
+	t.Helper()
+	testutil.SetupLogger(t)
+	handler := &app.Handler{}
+	server := &lsp.Server{Handler: handler}
+	handler.Client = server
+	return lsptest.Start(t, server, options)
+}
+
 func TestHandler_Hover(t *testing.T) {
 	tests := []struct {
 		name         string
 		document     string
-		position     lsp.Position
 		wantNil      bool
 		wantContains string
 	}{
 		{
 			name:         "Keyword",
-			document:     "shader_type spatial;\n",
-			position:     lsp.Position{Line: 0, Character: 3},
+			document:     "sha/*^at*/der_type spatial;\n",
 			wantContains: "shader",
 		},
 		{
 			name:         "DataType",
-			document:     "uniform vec3 color;\n",
-			position:     lsp.Position{Line: 0, Character: 9},
+			document:     "uniform v/*^at*/ec3 color;\n",
 			wantContains: "vector",
 		},
 		{
 			name:     "UnknownWord",
-			document: "uniform vec3 my_color;\n",
-			position: lsp.Position{Line: 0, Character: 15},
+			document: "uniform vec3 my_/*^at*/color;\n",
 			wantNil:  true,
 		},
 		{
 			name:     "Whitespace",
-			document: "uniform   vec3 color;\n",
-			position: lsp.Position{Line: 0, Character: 8},
+			document: "uniform /*^at*/  vec3 color;\n",
 			wantNil:  true,
 		},
 		{
 			name:         "BuiltInConstant",
-			document:     "shader_type spatial;\nvoid vertex() {\nVERTEX = vec3(0.0);\n}\n",
-			position:     lsp.Position{Line: 2, Character: 2},
+			document:     "shader_type spatial;\nvoid vertex() {\nVE/*^at*/RTEX = vec3(0.0);\n}\n",
 			wantContains: "vertex",
 		},
 	}
 
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			c := startSession(t, lsptest.Options{})
+
+			f := c.Open(testURI, tt.document)
+			result := c.Hover(testURI, f.At("at"))
+
+			if tt.wantNil {
+				g.Expect(result).To(BeNil())
+			} else {
+				g.Expect(result).ToNot(BeNil())
+				g.Expect(result.Contents.Value).To(ContainSubstring(tt.wantContains))
+			}
+		})
+	}
+}
+
+func TestHandler_Session(t *testing.T) {
+	g := NewWithT(t)
+	c := startSession(t, lsptest.Options{})
+
+	f := c.Open(testURI, "shader_type spatial;\nvoid fragment() {\n\tALBEDO = /*^call*/texure(TEXTURE, UV).rgb;\n}\n")
+	diagnostics := c.WaitDiagnostics(testURI)
+	g.Expect(diagnostics).To(HaveLen(1))
+	g.Expect(diagnostics[0].Range.Start).To(Equal(f.At("call")))
+
+	f = c.Change(testURI, "shader_type spatial;\nvoid fragment() {\n\tALB/*^call*/\n}\n")
+	g.Expect(c.Complete(testURI, f.At("call")).Items).To(ContainElement(HaveField("Label", "ALBEDO")))
+}
+
+// TestHandler_Concurrent is meant to be run with the race detector, as the
+// server handles requests concurrently with notifications.
+func TestHandler_Concurrent(t *testing.T) {
+	h := openDocument(t, "shader_type spatial;\n\nvoid fragment() {\n\tALBEDO = vec3(0.5);\n}\n")
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				if _, err := h.Diagnostic(t.Context(), lsp.DocumentDiagnosticParams{TextDocument: lsp.TextDocumentIdentifier{URI: testURI}}); err != nil {
+					t.Error(err)
+					return
+				}
+				if _, err := h.InlayHint(t.Context(), lsp.InlayHintParams{TextDocument: lsp.TextDocumentIdentifier{URI: testURI}, Range: lsp.Range{End: lsp.Position{Line: 10}}}); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+
+	for i := range 50 {
+		pos := lsp.Position{Line: 3, Character: 19 + i}
+		if err := h.DidChangeTextDocument(t.Context(), lsp.DidChangeTextDocumentParams{
+			TextDocument:   lsp.VersionedTextDocumentIdentifier{URI: testURI, Version: i + 1},
+			ContentChanges: []lsp.TextDocumentContentChangeEvent{{Range: &lsp.Range{Start: pos, End: pos}, Text: " "}},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.DidChangeConfiguration(t.Context(), lsp.DidChangeConfigurationParams{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wg.Wait()
+}
+
+func TestHandler_ClientCapabilities(t *testing.T) {
+	const uri = "file:///test.gdshader"
+	const document = "uniform float x : hint_"
+
+	tests := []struct {
+		name              string
+		capabilities      lsp.ClientCapabilities
+		wantHoverKind     lsp.MarkupKind
+		wantKind          lsp.CompletionItemKind
+		wantInsertText    string
+		wantDocumentation lsp.MarkupKind
+	}{
+		{
+			name:              "None",
+			wantHoverKind:     lsp.MarkupPlainText,
+			wantKind:          lsp.CompletionFunction,
+			wantDocumentation: lsp.MarkupPlainText,
+		},
+		{
+			name: "All",
+			capabilities: lsp.ClientCapabilities{TextDocument: &lsp.TextDocumentClientCapabilities{
+				Hover: &lsp.HoverClientCapabilities{ContentFormat: []lsp.MarkupKind{lsp.MarkupMarkdown, lsp.MarkupPlainText}},
+				Completion: &lsp.CompletionClientCapabilities{
+					CompletionItem: &lsp.CompletionItemClientCapabilities{
+						SnippetSupport:      true,
+						DocumentationFormat: []lsp.MarkupKind{lsp.MarkupMarkdown},
+					},
+				},
+			}},
+			wantHoverKind:     lsp.MarkupMarkdown,
+			wantKind:          lsp.CompletionFunction,
+			wantInsertText:    "hint_range(${1:0.0}, ${2:1.0})",
+			wantDocumentation: lsp.MarkupMarkdown,
+		},
+		{
+			name: "UnknownKind",
+			capabilities: lsp.ClientCapabilities{TextDocument: &lsp.TextDocumentClientCapabilities{
+				Completion: &lsp.CompletionClientCapabilities{
+					CompletionItemKind: &lsp.CompletionItemKindClientCapabilities{ValueSet: []lsp.CompletionItemKind{lsp.CompletionText}},
+				},
+			}},
+			wantHoverKind:     lsp.MarkupPlainText,
+			wantDocumentation: lsp.MarkupPlainText,
+		},
+	}
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			g := NewWithT(t)
 			var h app.Handler
-			const uri = "file:///test.gdshader"
+			err := h.Initialize(t.Context(), lsp.InitializeParams{Capabilities: tt.capabilities}, &lsp.ServerCapabilities{})
+			g.Expect(err).ToNot(HaveOccurred(), "Initialize error")
 
-			err := h.DidOpenTextDocument(t.Context(), lsp.DidOpenTextDocumentParams{
-				TextDocument: lsp.TextDocumentItem{URI: uri, Text: tt.document},
+			err = h.DidOpenTextDocument(t.Context(), lsp.DidOpenTextDocumentParams{
+				TextDocument: lsp.TextDocumentItem{URI: uri, Text: document},
 			})
 			g.Expect(err).ToNot(HaveOccurred(), "DidOpenTextDocument error")
 
-			result, err := h.Hover(t.Context(), lsp.HoverParams{
+			hover, err := h.Hover(t.Context(), lsp.HoverParams{
 				TextDocumentPositionParams: lsp.TextDocumentPositionParams{
 					TextDocument: lsp.TextDocumentIdentifier{URI: uri},
-					Position:     tt.position,
+					Position:     lsp.Position{Character: 2},
 				},
 			})
 			g.Expect(err).ToNot(HaveOccurred(), "Hover error")
+			g.Expect(hover.Contents.Kind).To(Equal(tt.wantHoverKind))
+			if tt.wantHoverKind == lsp.MarkupPlainText {
+				g.Expect(hover.Contents.Value).ToNot(ContainSubstring("`"))
+			}
 
-			if tt.wantNil {
-				g.Expect(result).To(BeNil())
-			} else {
-				g.Expect(result).ToNot(BeNil())
-				g.Expect(result.Contents.Value).To(ContainSubstring(tt.wantContains))
+			list, err := h.Completion(t.Context(), lsp.CompletionParams{
+				TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+					TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+					Position:     lsp.Position{Character: len(document)},
+				},
+			})
+			g.Expect(err).ToNot(HaveOccurred(), "Completion error")
+
+			var hintRange *lsp.CompletionItem
+			for _, item := range list.Items {
+				if item.Label == "hint_range" {
+					hintRange = &item
+				}
 			}
+			g.Expect(hintRange).ToNot(BeNil(), "Missing hint_range completion")
+			g.Expect(hintRange.Kind).To(Equal(tt.wantKind))
+			g.Expect(hintRange.InsertText).To(Equal(tt.wantInsertText))
+			g.Expect(hintRange.Documentation.Kind).To(Equal(tt.wantDocumentation))
+		})
+	}
+}
+
+func TestHandler_PositionEncoding(t *testing.T) {
+	const document = "shader_type spatial;\nvoid fragment() {\n\t/* 😀 */ ALBEDO = vec3(ALPHAA);\n}\n"
+
+	tests := []struct {
+		name      string
+		supported []lsp.PositionEncodingKind
+		want      lsp.PositionEncodingKind
+		wantStart int
+	}{
+		{name: "Default", want: lsp.PositionEncodingUTF16, wantStart: 24},
+		{name: "UTF8", supported: []lsp.PositionEncodingKind{lsp.PositionEncodingUTF16, lsp.PositionEncodingUTF8}, want: lsp.PositionEncodingUTF8, wantStart: 26},
+		{name: "UTF32", supported: []lsp.PositionEncodingKind{lsp.PositionEncodingUTF32}, want: lsp.PositionEncodingUTF32, wantStart: 23},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			var h app.Handler
+			var capabilities lsp.ServerCapabilities
+			err := h.Initialize(t.Context(), lsp.InitializeParams{Capabilities: lsp.ClientCapabilities{
+				General: &lsp.GeneralClientCapabilities{PositionEncodings: tt.supported},
+			}}, &capabilities)
+			g.Expect(err).ToNot(HaveOccurred(), "Initialize error")
+			g.Expect(capabilities.PositionEncoding).To(Equal(tt.want))
+
+			err = h.DidOpenTextDocument(t.Context(), lsp.DidOpenTextDocumentParams{
+				TextDocument: lsp.TextDocumentItem{URI: testURI, Text: document},
+			})
+			g.Expect(err).ToNot(HaveOccurred(), "DidOpenTextDocument error")
+
+			report, err := h.Diagnostic(t.Context(), lsp.DocumentDiagnosticParams{TextDocument: lsp.TextDocumentIdentifier{URI: testURI}})
+			g.Expect(err).ToNot(HaveOccurred(), "Diagnostic error")
+			g.Expect(report.Items).To(HaveLen(1))
+			g.Expect(report.Items[0].Range).To(Equal(lsp.Range{
+				Start: lsp.Position{Line: 2, Character: tt.wantStart},
+				End:   lsp.Position{Line: 2, Character: tt.wantStart + len("ALPHAA")},
+			}))
 		})
 	}
 }
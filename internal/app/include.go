@@ -0,0 +1,235 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/armsnyder/gdshader-language-server/internal/ast"
+	"github.com/armsnyder/gdshader-language-server/internal/godot"
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+)
+
+// include is an #include directive of a shader.
+type include struct {
+	directive *ast.Directive
+	// path is the path between the quotes, as it is written.
+	path string
+	// pathSpan is the span of the path, without the quotes.
+	pathSpan ast.Span
+	// resolved is the path of the included file on disk, or empty if it
+	// couldn't be resolved.
+	resolved string
+	// err explains why the path couldn't be resolved.
+	err *includeError
+	// cycle is the chain of paths through which the included file
+	// includes itself or a file that includes it.
+	cycle []string
+}
+
+type includeError struct {
+	code    string
+	message string
+}
+
+// resolveIncludes finds the included files of a shader. Paths are either
+// res:// paths in the project, or relative to the shader.
+func (h *Handler) resolveIncludes(uri string, file *ast.File, project *godot.Project) []include {
+	var includes []include
+	for _, decl := range file.Declarations {
+		d := decl.Directive
+		if d == nil || d.Name != "include" {
+			continue
+		}
+		inc := include{directive: d, pathSpan: d.ValueSpan}
+
+		path, ok := strings.CutPrefix(d.Value, `"`)
+		path, closed := strings.CutSuffix(path, `"`)
+		if !ok || !closed || len(d.Value) < 2 {
+			inc.err = &includeError{"include-syntax", "Expected a quoted path after #include."}
+			includes = append(includes, inc)
+			continue
+		}
+		inc.path = path
+		inc.pathSpan = ast.Span{Start: d.ValueSpan.Start + 1, End: d.ValueSpan.End - 1}
+		inc.resolved, inc.err = h.resolveInclude(uri, path, project)
+		includes = append(includes, inc)
+	}
+	return includes
+}
+
+func (h *Handler) resolveInclude(uri, path string, project *godot.Project) (string, *includeError) {
+	if path == "" {
+		return "", &includeError{"unresolved-include", "Empty include path."}
+	}
+
+	var resolved string
+	if strings.HasPrefix(path, "res://") {
+		if project == nil {
+			return "", &includeError{"unresolved-include", "Can't resolve res:// paths outside of a Godot project, since there is no project.godot."}
+		}
+		var ok bool
+		if resolved, ok = project.ResolvePath(path); !ok {
+			return "", &includeError{"unresolved-include", fmt.Sprintf("'%s' is outside of the project.", path)}
+		}
+	} else {
+		docPath, err := lsp.URIToPath(uri)
+		if err != nil {
+			// Documents that are not on disk have no directory to
+			// resolve relative paths in.
+			return "", nil
+		}
+		resolved = filepath.Join(filepath.Dir(docPath), filepath.FromSlash(path))
+	}
+
+	if filepath.Ext(resolved) != ".gdshaderinc" {
+		return "", &includeError{"include-extension", "Only .gdshaderinc files can be included."}
+	}
+	if _, err := h.Snapshot(lsp.PathToURI(resolved)); err == nil {
+		// The file is open, and may not be saved yet.
+		return resolved, nil
+	}
+	if info, err := os.Stat(resolved); err != nil || info.IsDir() {
+		return "", &includeError{"unresolved-include", fmt.Sprintf("Included file '%s' not found.", path)}
+	}
+	return resolved, nil
+}
+
+// checkIncludes reports #include directives whose path can't be resolved,
+// or that lead to a file including itself.
+func checkIncludes(a *analysis) []diagnostic {
+	var diagnostics []diagnostic
+	for _, inc := range a.includes {
+		switch {
+		case inc.err != nil:
+			diagnostics = append(diagnostics, diagnostic{
+				Span:     inc.pathSpan,
+				severity: lsp.SeverityError,
+				code:     inc.err.code,
+				message:  inc.err.message,
+			})
+		case inc.cycle != nil:
+			names := make([]string, len(inc.cycle))
+			for i, path := range inc.cycle {
+				names[i] = filepath.Base(path)
+			}
+			diagnostics = append(diagnostics, diagnostic{
+				Span:     inc.pathSpan,
+				severity: lsp.SeverityError,
+				code:     "include-cycle",
+				message:  fmt.Sprintf("Include cycle: %s.", strings.Join(names, " → ")),
+			})
+		}
+	}
+	return diagnostics
+}
+
+// DocumentLink implements lsp.DocumentLinkHandler. The paths of includes
+// link to the included files.
+func (h *Handler) DocumentLink(ctx context.Context, params lsp.DocumentLinkParams) ([]lsp.DocumentLink, error) {
+	uri := params.TextDocument.URI
+	doc, file, err := h.parseDocument(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	links := []lsp.DocumentLink{}
+	for _, inc := range h.resolveIncludes(uri, file, h.project(ctx, uri)) {
+		if inc.resolved == "" {
+			continue
+		}
+		links = append(links, lsp.DocumentLink{
+			Range:   spanToRange(doc, inc.pathSpan),
+			Target:  lsp.PathToURI(inc.resolved),
+			Tooltip: inc.resolved,
+		})
+	}
+	return links, nil
+}
+
+// includePathPrefix matches a line up to a position in the path of an
+// #include.
+var includePathPrefix = regexp.MustCompile(`^\s*#include\s+"([^"]*)$`)
+
+// includeCompletions offers the .gdshaderinc files of the project when the
+// position is in the path of an #include. Files are offered as res://
+// paths, unless the path starts with a dot or the shader isn't in a
+// project, in which case they are relative to the shader. It reports false
+// if the position is not in an include path.
+func (h *Handler) includeCompletions(ctx context.Context, doc *lsp.Snapshot, uri string, pos lsp.Position) ([]lsp.CompletionItem, bool) {
+	line, err := readRange(doc, lsp.Range{Start: lsp.Position{Line: pos.Line}, End: pos})
+	if err != nil {
+		return nil, false
+	}
+	m := includePathPrefix.FindSubmatch(line)
+	if m == nil {
+		return nil, false
+	}
+	typed := string(m[1])
+
+	docPath, err := lsp.URIToPath(uri)
+	if err != nil {
+		return []lsp.CompletionItem{}, true
+	}
+	// Without a project, the files are searched in the workspace folder of
+	// the shader, or else only next to it.
+	project := h.project(ctx, uri)
+	root, recursive := h.workspace.rootOf(docPath)
+	if project != nil {
+		root, recursive = project.Root, true
+	} else if !recursive {
+		root = filepath.Dir(docPath)
+	}
+	relative := project == nil || strings.HasPrefix(typed, ".")
+
+	offset, err := doc.PositionToOffset(pos)
+	if err != nil {
+		return nil, false
+	}
+	replace := lsp.Range{Start: doc.OffsetToPosition(offset - len(typed)), End: pos}
+
+	items := []lsp.CompletionItem{}
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			// Skip what can't be read.
+			return nil
+		}
+		if d.IsDir() && path != root && (!recursive || strings.HasPrefix(d.Name(), ".")) {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || filepath.Ext(path) != ".gdshaderinc" || path == docPath {
+			return nil
+		}
+
+		var label string
+		var ok bool
+		if relative {
+			rel, err := filepath.Rel(filepath.Dir(docPath), path)
+			label, ok = filepath.ToSlash(rel), err == nil
+			if strings.HasPrefix(typed, "./") && !strings.HasPrefix(label, "../") {
+				label = "./" + label
+			}
+		} else {
+			label, ok = project.ResPath(path)
+		}
+		if ok && strings.HasPrefix(label, typed) {
+			items = append(items, lsp.CompletionItem{
+				Label:    label,
+				Kind:     lsp.CompletionFile,
+				TextEdit: &lsp.TextEdit{Range: replace, NewText: label},
+			})
+		}
+		return nil
+	})
+	return items, true
+}
@@ -0,0 +1,261 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package app
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/armsnyder/gdshader-language-server/internal/ast"
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+)
+
+// includeGraph is which files include which, for the shaders that were
+// indexed or analyzed. It finds the shaders that depend on an include file,
+// whose diagnostics change along with it.
+type includeGraph struct {
+	mu sync.Mutex
+	// edges are the resolved #include directives of each file, by path.
+	edges map[string][]includeEdge
+}
+
+// includeEdge is an #include directive of a file.
+type includeEdge struct {
+	// path is the path of the included file.
+	path string
+	// location is the location of the include path in the including file.
+	location lsp.Location
+}
+
+func (g *includeGraph) set(path string, edges []includeEdge) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.edges == nil {
+		g.edges = make(map[string][]includeEdge)
+	}
+	g.edges[path] = edges
+}
+
+// dependents returns the shaders that include a file, directly or through
+// other includes. Each is located at the #include directive that leads to
+// the file.
+func (g *includeGraph) dependents(path string) []lsp.Location {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	files := slices.Sorted(maps.Keys(g.edges))
+	found := map[string]bool{path: true}
+	locations := []lsp.Location{}
+	for targets := []string{path}; len(targets) > 0; {
+		var next []string
+		for _, file := range files {
+			if found[file] {
+				continue
+			}
+			for _, edge := range g.edges[file] {
+				if slices.Contains(targets, edge.path) {
+					found[file] = true
+					locations = append(locations, edge.location)
+					next = append(next, file)
+					break
+				}
+			}
+		}
+		targets = next
+	}
+
+	slices.SortFunc(locations, func(a, b lsp.Location) int {
+		return cmp.Or(cmp.Compare(a.URI, b.URI), cmp.Compare(a.Range.Start.Line, b.Range.Start.Line))
+	})
+	return locations
+}
+
+// shaderFile is a parsed shader file, which is open in the client or read
+// from disk.
+type shaderFile struct {
+	doc      *lsp.Snapshot
+	file     *ast.File
+	includes []include
+}
+
+// loadShaderFile parses a shader file and resolves its includes. The content
+// that is open in the client is used over the file on disk.
+func (h *Handler) loadShaderFile(ctx context.Context, path string) (*shaderFile, error) {
+	uri := lsp.PathToURI(path)
+	doc, err := h.Snapshot(uri)
+	if err != nil {
+		if doc, err = h.readSnapshot(path); err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := ast.Parse(uri, bytes.NewReader(doc.Bytes()))
+	if file == nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return &shaderFile{doc: doc, file: file, includes: h.resolveIncludes(uri, file, h.project(ctx, uri))}, nil
+}
+
+// readSnapshot reads a file on disk, which is not open in the client.
+func (h *Handler) readSnapshot(path string) (*lsp.Snapshot, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc := lsp.NewDocument(text, nil)
+	doc.SetPositionEncoding(h.PositionEncoding)
+	return doc.Snapshot(), nil
+}
+
+// followIncludes loads the files that a document includes, directly or
+// through other includes, and records every file on the way in the include
+// graph. Includes that lead to a cycle are marked with it.
+func (h *Handler) followIncludes(ctx context.Context, uri string, doc *lsp.Snapshot, includes []include) []*shaderFile {
+	// Documents that are not on disk have an empty path, which no include
+	// resolves to.
+	path, _ := lsp.URIToPath(uri)
+	h.recordIncludes(path, uri, doc, includes)
+
+	var included []*shaderFile
+	loaded := map[string]bool{path: true}
+
+	// follow returns the first cycle that is found in the includes of a
+	// file, given the files that include it.
+	var follow func(path string, stack []string) []string
+	follow = func(path string, stack []string) []string {
+		if i := slices.Index(stack, path); i >= 0 {
+			return append(slices.Clone(stack[i:]), path)
+		}
+		if loaded[path] || ctx.Err() != nil {
+			return nil
+		}
+		loaded[path] = true
+
+		f, err := h.loadShaderFile(ctx, path)
+		if err != nil {
+			// Unresolved includes are reported by checkIncludes.
+			return nil
+		}
+		included = append(included, f)
+		h.recordIncludes(path, f.file.Filename, f.doc, f.includes)
+
+		stack = append(stack, path)
+		var cycle []string
+		for _, inc := range f.includes {
+			if inc.resolved == "" {
+				continue
+			}
+			if c := follow(inc.resolved, stack); cycle == nil {
+				cycle = c
+			}
+		}
+		return cycle
+	}
+
+	for i, inc := range includes {
+		if inc.resolved != "" {
+			includes[i].cycle = follow(inc.resolved, []string{path})
+		}
+	}
+	return included
+}
+
+// recordIncludes sets the edges of a file in the include graph.
+func (h *Handler) recordIncludes(path, uri string, doc *lsp.Snapshot, includes []include) {
+	if path == "" {
+		return
+	}
+	var edges []includeEdge
+	for _, inc := range includes {
+		if inc.resolved != "" {
+			edges = append(edges, includeEdge{
+				path:     inc.resolved,
+				location: lsp.Location{URI: uri, Range: spanToRange(doc, inc.pathSpan)},
+			})
+		}
+	}
+	h.includeGraph.set(path, edges)
+}
+
+// indexIncludes records the includes of a shader file in the include graph.
+func (h *Handler) indexIncludes(ctx context.Context, uri string) error {
+	path, err := lsp.URIToPath(uri)
+	if err != nil {
+		return err
+	}
+	f, err := h.loadShaderFile(ctx, path)
+	if err != nil {
+		return err
+	}
+	h.recordIncludes(path, uri, f.doc, f.includes)
+	return nil
+}
+
+// publishDependents publishes the diagnostics of the shaders that include
+// a document again, since they depend on its content, and of the materials
+// that use the document or those shaders. Clients that pull diagnostics ask
+// for the ones of shaders again on their own.
+func (h *Handler) publishDependents(ctx context.Context, uri string) {
+	if h.Client == nil || h.supports((*lsp.ClientCapabilities).PullDiagnostics) {
+		return
+	}
+	path, err := lsp.URIToPath(uri)
+	if err != nil {
+		return
+	}
+	h.publishMaterials(ctx, path)
+
+	for _, dependent := range h.includeGraph.dependents(path) {
+		if ctx.Err() != nil {
+			return
+		}
+		if dependentPath, err := lsp.URIToPath(dependent.URI); err == nil {
+			h.publishMaterials(ctx, dependentPath)
+		}
+		if _, err = h.Snapshot(dependent.URI); err == nil {
+			err = h.publishDiagnostics(ctx, dependent.URI)
+		} else if h.workspace.contains(dependent.URI) {
+			_, err = h.publishFileDiagnostics(ctx, dependent.URI)
+		} else {
+			continue
+		}
+		if err != nil && ctx.Err() == nil {
+			h.logMessage(ctx, lsp.MessageWarning, fmt.Sprintf("Failed to check %s: %v", dependent.URI, err))
+		}
+	}
+}
+
+// commandFindIncludingShaders finds the shaders that include a file,
+// directly or through other includes. Its argument is the URI of the file,
+// and it returns the locations of the #include directives that lead to it.
+const commandFindIncludingShaders = "gdshader.findIncludingShaders"
+
+// ExecuteCommands implements lsp.ExecuteCommandHandler.
+func (h *Handler) ExecuteCommands() []string {
+	return []string{commandFindIncludingShaders}
+}
+
+// ExecuteCommand implements lsp.ExecuteCommandHandler.
+func (h *Handler) ExecuteCommand(_ context.Context, params lsp.ExecuteCommandParams) (any, error) {
+	switch params.Command {
+	case commandFindIncludingShaders:
+		var uri string
+		if len(params.Arguments) != 1 || json.Unmarshal(params.Arguments[0], &uri) != nil {
+			return nil, &lsp.ResponseError{Code: lsp.CodeInvalidParams, Message: "Expected the URI of a file as the only argument"}
+		}
+		path, err := lsp.URIToPath(uri)
+		if err != nil {
+			return nil, &lsp.ResponseError{Code: lsp.CodeInvalidParams, Message: err.Error(), InternalError: err}
+		}
+		return h.includeGraph.dependents(path), nil
+	}
+	return nil, &lsp.ResponseError{Code: lsp.CodeInvalidParams, Message: fmt.Sprintf("Unknown command %q", params.Command)}
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package app_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+	"github.com/armsnyder/gdshader-language-server/internal/lsptest"
+	"github.com/samber/lo"
+)
+
+func TestHandler_IncludeGraph_Cycle(t *testing.T) {
+	g := NewWithT(t)
+	dir := writeFiles(t, map[string]string{
+		"a.gdshaderinc":    "#include \"b.gdshaderinc\"\n",
+		"b.gdshaderinc":    "#include \"a.gdshaderinc\"\n",
+		"self.gdshaderinc": "",
+	})
+	c := startSession(t, lsptest.Options{})
+
+	uri := lsp.PathToURI(filepath.Join(dir, "water.gdshader"))
+	f := c.Open(uri, "shader_type spatial;\n#include \"/*^cycle*/a.gdshaderinc\"\n")
+	diagnostics := c.WaitDiagnostics(uri)
+	g.Expect(diagnostics).To(HaveLen(1))
+	g.Expect(diagnostics[0].Code).To(Equal("include-cycle"))
+	g.Expect(diagnostics[0].Range.Start).To(Equal(f.At("cycle")))
+	g.Expect(diagnostics[0].Message).To(Equal("Include cycle: a.gdshaderinc → b.gdshaderinc → a.gdshaderinc."))
+
+	selfURI := lsp.PathToURI(filepath.Join(dir, "self.gdshaderinc"))
+	c.Open(selfURI, "#include \"self.gdshaderinc\"\n")
+	diagnostics = c.WaitDiagnostics(selfURI)
+	g.Expect(diagnostics).To(HaveLen(1))
+	g.Expect(diagnostics[0].Message).To(Equal("Include cycle: self.gdshaderinc → self.gdshaderinc."))
+}
+
+// startIncludeWorkspace starts a session for a workspace with a shader that
+// includes an include file through another one, and waits for it to be
+// indexed. It returns the workspace directory.
+func startIncludeWorkspace(t *testing.T) (*lsptest.Client, string) {
+	dir := writeFiles(t, map[string]string{
+		"project.godot":          "config_version=5\n",
+		"lib/noise.gdshaderinc":  "float noise(vec2 p) {\n\treturn 0.0;\n}\n",
+		"lib/common.gdshaderinc": "#include \"noise.gdshaderinc\"\n",
+		"water.gdshader":         "shader_type spatial;\n#include \"res://lib/common.gdshaderinc\"\nvoid fragment() {\n\tALBEDO = vec3(noise2(UV));\n}\n",
+		"sky.gdshader":           "shader_type sky;\n",
+	})
+	c := startSession(t, lsptest.Options{
+		WorkspaceFolders: []lsp.WorkspaceFolder{{URI: lsp.PathToURI(dir), Name: "project"}},
+	})
+	c.WaitDiagnostics(lsp.PathToURI(filepath.Join(dir, "water.gdshader")))
+	return c, dir
+}
+
+func TestHandler_IncludeGraph_PublishDependents(t *testing.T) {
+	g := NewWithT(t)
+	c, dir := startIncludeWorkspace(t)
+	waterURI := lsp.PathToURI(filepath.Join(dir, "water.gdshader"))
+	noiseURI := lsp.PathToURI(filepath.Join(dir, "lib", "noise.gdshaderinc"))
+
+	diagnostics := c.WaitDiagnostics(waterURI)
+	g.Expect(diagnostics).To(HaveLen(1))
+	g.Expect(diagnostics[0].Message).To(Equal("Unknown function 'noise2'."))
+
+	// Defining the function in the include fixes the shader, which isn't
+	// open.
+	c.Open(noiseURI, "float noise(vec2 p) {\n\treturn 0.0;\n}\n")
+	c.Change(noiseURI, "float noise2(vec2 p) {\n\treturn 0.0;\n}\n")
+	g.Eventually(func() []lsp.Diagnostic { return c.Diagnostics(waterURI) }).Should(BeEmpty())
+
+	// The unsaved change is dropped when the include is closed.
+	c.Close(noiseURI)
+	g.Eventually(func() []lsp.Diagnostic { return c.Diagnostics(waterURI) }).Should(HaveLen(1))
+}
+
+func TestHandler_IncludeGraph_FindIncludingShaders(t *testing.T) {
+	g := NewWithT(t)
+	c, dir := startIncludeWorkspace(t)
+	commonPath := filepath.Join(dir, "lib", "common.gdshaderinc")
+
+	var locations []lsp.Location
+	lo.Must0(c.Call("workspace/executeCommand", lsp.ExecuteCommandParams{
+		Command:   "gdshader.findIncludingShaders",
+		Arguments: []json.RawMessage{lo.Must(json.Marshal(lsp.PathToURI(filepath.Join(dir, "lib", "noise.gdshaderinc"))))},
+	}, &locations))
+	g.Expect(locations).To(Equal([]lsp.Location{
+		{
+			URI:   lsp.PathToURI(commonPath),
+			Range: lsp.Range{Start: lsp.Position{Line: 0, Character: 10}, End: lsp.Position{Line: 0, Character: 27}},
+		},
+		{
+			URI:   lsp.PathToURI(filepath.Join(dir, "water.gdshader")),
+			Range: lsp.Range{Start: lsp.Position{Line: 1, Character: 10}, End: lsp.Position{Line: 1, Character: 38}},
+		},
+	}))
+
+	err := c.Call("workspace/executeCommand", lsp.ExecuteCommandParams{Command: "gdshader.findIncludingShaders"}, &locations)
+	g.Expect(err).To(MatchError(ContainSubstring("Expected the URI of a file")))
+}
+
+func TestHandler_Definition(t *testing.T) {
+	g := NewWithT(t)
+	c, dir := startIncludeWorkspace(t)
+	uri := lsp.PathToURI(filepath.Join(dir, "water.gdshader"))
+
+	f := c.Open(uri, `shader_type spatial;
+#include "res://lib/common.gdshaderinc"
+uniform float /*^strength*/strength;
+void fragment() {
+	float /*^local*/x = /*^use*/strength;
+	ALBEDO = vec3(no/*^call*/ise(UV) + /*^useLocal*/x);
+}
+`)
+	g.Expect(c.Definition(uri, f.At("use"))).To(Equal([]lsp.Location{
+		{URI: uri, Range: lsp.Range{Start: f.At("strength"), End: lsp.Position{Line: 2, Character: f.At("strength").Character + len("strength")}}},
+	}))
+	g.Expect(c.Definition(uri, f.At("useLocal"))).To(Equal([]lsp.Location{
+		{URI: uri, Range: lsp.Range{Start: f.At("local"), End: lsp.Position{Line: 4, Character: f.At("local").Character + 1}}},
+	}))
+
+	// The function is declared in a file that is included by an include.
+	g.Expect(c.Definition(uri, f.At("call"))).To(Equal([]lsp.Location{
+		{
+			URI:   lsp.PathToURI(filepath.Join(dir, "lib", "noise.gdshaderinc")),
+			Range: lsp.Range{Start: lsp.Position{Line: 0, Character: 6}, End: lsp.Position{Line: 0, Character: 11}},
+		},
+	}))
+
+	g.Expect(c.Definition(uri, lsp.Position{Line: 0, Character: 2})).To(BeEmpty())
+}
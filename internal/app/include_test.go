@@ -0,0 +1,131 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package app_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+	"github.com/armsnyder/gdshader-language-server/internal/lsptest"
+)
+
+// startIncludeProject starts a session for a shader in a project with
+// include files, and returns the project directory and the URI of the
+// shader.
+func startIncludeProject(t *testing.T) (*lsptest.Client, string, string) {
+	dir := writeFiles(t, map[string]string{
+		"project.godot":                "config_version=5\n",
+		"lib/noise.gdshaderinc":        "float noise(vec2 p) {\n\treturn 0.0;\n}\n",
+		"lib/light.gdshaderinc":        "",
+		"lib/sky.gdshader":             "shader_type sky;\n",
+		"shaders/local.gdshaderinc":    "",
+		".godot/cache/x.gdshaderinc":   "",
+		"shaders/water.gdshader":       "",
+		"addons/tool/tool.gdshaderinc": "",
+	})
+	return startSession(t, lsptest.Options{}), dir, lsp.PathToURI(filepath.Join(dir, "shaders", "water.gdshader"))
+}
+
+func TestHandler_Include_Diagnostics(t *testing.T) {
+	g := NewWithT(t)
+	c, _, uri := startIncludeProject(t)
+
+	f := c.Open(uri, `shader_type spatial;
+#include "res://lib/noise.gdshaderinc"
+#include "local.gdshaderinc"
+#include "../lib/light.gdshaderinc"
+#include "/*^missing*/res://lib/missing.gdshaderinc"
+#include "/*^extension*/res://lib/sky.gdshader"
+#include "/*^outside*/res://../secret.gdshaderinc"
+#include /*^syntax*/noise
+`)
+	diagnostics := c.WaitDiagnostics(uri)
+	g.Expect(diagnostics).To(HaveLen(4))
+	g.Expect(diagnostics[0].Code).To(Equal("unresolved-include"))
+	g.Expect(diagnostics[0].Range.Start).To(Equal(f.At("missing")))
+	g.Expect(diagnostics[0].Message).To(Equal("Included file 'res://lib/missing.gdshaderinc' not found."))
+	g.Expect(diagnostics[1].Code).To(Equal("include-extension"))
+	g.Expect(diagnostics[1].Range.Start).To(Equal(f.At("extension")))
+	g.Expect(diagnostics[2].Code).To(Equal("unresolved-include"))
+	g.Expect(diagnostics[2].Range.Start).To(Equal(f.At("outside")))
+	g.Expect(diagnostics[3].Code).To(Equal("include-syntax"))
+	g.Expect(diagnostics[3].Range.Start).To(Equal(f.At("syntax")))
+}
+
+func TestHandler_Include_OutsideProject(t *testing.T) {
+	g := NewWithT(t)
+	dir := writeFiles(t, map[string]string{"noise.gdshaderinc": ""})
+	uri := lsp.PathToURI(filepath.Join(dir, "water.gdshader"))
+	c := startSession(t, lsptest.Options{})
+
+	c.Open(uri, "shader_type spatial;\n#include \"noise.gdshaderinc\"\n#include \"res://noise.gdshaderinc\"\n")
+	diagnostics := c.WaitDiagnostics(uri)
+	g.Expect(diagnostics).To(HaveLen(1))
+	g.Expect(diagnostics[0].Message).To(ContainSubstring("no project.godot"))
+}
+
+func TestHandler_Include_OpenFile(t *testing.T) {
+	g := NewWithT(t)
+	c, dir, uri := startIncludeProject(t)
+
+	// An include file that is open but not saved yet is found.
+	includeURI := lsp.PathToURI(filepath.Join(dir, "lib", "new.gdshaderinc"))
+	c.Open(includeURI, "")
+	c.Open(uri, "shader_type spatial;\n#include \"res://lib/new.gdshaderinc\"\n")
+	g.Expect(c.WaitDiagnostics(uri)).To(BeEmpty())
+}
+
+func TestHandler_Include_DocumentLinks(t *testing.T) {
+	g := NewWithT(t)
+	c, dir, uri := startIncludeProject(t)
+
+	f := c.Open(uri, "shader_type spatial;\n#include \"/*^res*/res://lib/noise.gdshaderinc/*^resEnd*/\"\n#include \"/*^rel*/local.gdshaderinc\"\n#include \"res://lib/missing.gdshaderinc\"\n")
+
+	g.Expect(c.DocumentLinks(uri)).To(Equal([]lsp.DocumentLink{
+		{
+			Range:   lsp.Range{Start: f.At("res"), End: f.At("resEnd")},
+			Target:  lsp.PathToURI(filepath.Join(dir, "lib", "noise.gdshaderinc")),
+			Tooltip: filepath.Join(dir, "lib", "noise.gdshaderinc"),
+		},
+		{
+			Range:   lsp.Range{Start: f.At("rel"), End: lsp.Position{Line: 2, Character: f.At("rel").Character + len("local.gdshaderinc")}},
+			Target:  lsp.PathToURI(filepath.Join(dir, "shaders", "local.gdshaderinc")),
+			Tooltip: filepath.Join(dir, "shaders", "local.gdshaderinc"),
+		},
+	}))
+}
+
+func TestHandler_Include_Completion(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want []string
+	}{
+		{name: "Empty", line: `#include "/*^start*//*^at*/`, want: []string{"res://lib/noise.gdshaderinc", "res://lib/light.gdshaderinc", "res://shaders/local.gdshaderinc", "res://addons/tool/tool.gdshaderinc"}},
+		{name: "ResPath", line: `#include "/*^start*/res://li/*^at*/`, want: []string{"res://lib/noise.gdshaderinc", "res://lib/light.gdshaderinc"}},
+		{name: "Current", line: `#include "/*^start*/./lo/*^at*/`, want: []string{"./local.gdshaderinc"}},
+		{name: "Parent", line: `#include "/*^start*/../l/*^at*/"`, want: []string{"../lib/noise.gdshaderinc", "../lib/light.gdshaderinc"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			c, _, uri := startIncludeProject(t)
+
+			f := c.Open(uri, "shader_type spatial;\n"+tt.line+"\n")
+			items := c.Complete(uri, f.At("at")).Items
+
+			var labels []string
+			for _, item := range items {
+				labels = append(labels, item.Label)
+				g.Expect(item.Kind).To(Equal(lsp.CompletionFile))
+				g.Expect(item.TextEdit).To(Equal(&lsp.TextEdit{Range: lsp.Range{Start: f.At("start"), End: f.At("at")}, NewText: item.Label}))
+			}
+			g.Expect(labels).To(ConsistOf(tt.want))
+		})
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package app
+
+import (
+	"context"
+	"strings"
+
+	"github.com/armsnyder/gdshader-language-server/internal/ast"
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+)
+
+// InlayHint implements lsp.InlayHintHandler.
+func (h *Handler) InlayHint(_ context.Context, params lsp.InlayHintParams) ([]lsp.InlayHint, error) {
+	doc, file, err := h.parseDocument(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := doc.RangeToOffsets(params.Range)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := h.getSettings().InlayHints
+	hints := []lsp.InlayHint{}
+
+	addHint := func(offset int, hint lsp.InlayHint) {
+		if offset >= start && offset <= end {
+			hint.Position = doc.OffsetToPosition(offset)
+			hints = append(hints, hint)
+		}
+	}
+
+	for _, decl := range file.Declarations {
+		if decl.End < start || decl.Start > end {
+			continue
+		}
+
+		// constant is set while the declarators of a const declaration are
+		// visited.
+		var constant bool
+		inspectWithEnv(newTypeEnv(file, decl.Start), decl, func(node ast.Node, env *typeEnv) bool {
+			switch n := node.(type) {
+			case *ast.VarDecl:
+				constant = n.Const
+			case *ast.Declarator:
+				if constant && settings.ConstantValues {
+					if label, ok := constValueHint(env, n.Init); ok {
+						addHint(n.Init.Extent().End, lsp.InlayHint{Label: label, PaddingLeft: true})
+					}
+				}
+			case *ast.CallExpr:
+				if settings.ParameterNames {
+					for i, label := range parameterNameHints(env, n) {
+						if label != "" {
+							addHint(n.Args[i].Extent().Start, lsp.InlayHint{Label: label, Kind: lsp.InlayHintParameter, PaddingRight: true})
+						}
+					}
+				}
+			case *ast.AssignExpr:
+				if settings.SwizzleTypes {
+					if label, ok := swizzleTypeHint(env, n); ok {
+						addHint(n.End, lsp.InlayHint{Label: label, Kind: lsp.InlayHintType})
+					}
+				}
+			}
+			return true
+		})
+	}
+
+	return hints, nil
+}
+
+// constValueHint shows the value of a const initializer, unless it is
+// already a plain literal.
+func constValueHint(env *typeEnv, init ast.Expr) (string, bool) {
+	switch x := init.(type) {
+	case nil, *ast.Literal:
+		return "", false
+	case *ast.UnaryExpr:
+		if _, ok := x.X.(*ast.Literal); ok {
+			return "", false
+		}
+	}
+
+	value, ok := env.evalConst(init)
+	if !ok {
+		return "", false
+	}
+	return "= " + value.String(), true
+}
+
+// parameterNameHints returns a label for each argument of a call to a
+// multi-parameter built-in function. Arguments whose name already matches
+// the parameter get an empty label.
+func parameterNameHints(env *typeEnv, call *ast.CallExpr) []string {
+	callee := call.Callee()
+	if callee == nil || len(call.Args) < 2 {
+		return nil
+	}
+
+	if _, ok := env.functions[callee.Name]; ok || env.isType(callee.Name) {
+		return nil
+	}
+
+	argTypes := make([]string, len(call.Args))
+	for i, arg := range call.Args {
+		argTypes[i] = env.typeOf(arg)
+	}
+	fn, ok := builtinOverload(callee.Name, argTypes)
+	if !ok {
+		return nil
+	}
+
+	labels := make([]string, len(call.Args))
+	for i, param := range fn.params {
+		if !argMatchesName(call.Args[i], param.name) {
+			labels[i] = param.name + ":"
+		}
+	}
+	return labels
+}
+
+func argMatchesName(arg ast.Expr, name string) bool {
+	switch x := arg.(type) {
+	case *ast.Ident:
+		return strings.EqualFold(x.Name, name)
+	case *ast.MemberExpr:
+		return strings.EqualFold(x.Name, name)
+	}
+	return false
+}
+
+// swizzleTypeHint shows the type of a complex expression that is assigned
+// to a swizzle, such as "COLOR.rgb = mix(a, b, t) * k".
+func swizzleTypeHint(env *typeEnv, assign *ast.AssignExpr) (string, bool) {
+	member, ok := assign.Left.(*ast.MemberExpr)
+	if !ok {
+		return "", false
+	}
+
+	switch x := assign.Right.(type) {
+	case *ast.Literal, *ast.Ident, *ast.MemberExpr, *ast.BadExpr:
+		return "", false
+	case *ast.CallExpr:
+		if callee := x.Callee(); callee != nil && componentCount(callee.Name) > 0 {
+			// The type of a constructor is obvious.
+			return "", false
+		}
+	}
+
+	if swizzleType(env.typeOf(member.X), member.Name) == "" {
+		return "", false
+	}
+
+	typ := env.typeOf(assign.Right)
+	if typ == "" {
+		return "", false
+	}
+	return ": " + typ, true
+}
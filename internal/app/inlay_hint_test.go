@@ -0,0 +1,93 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package app_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/armsnyder/gdshader-language-server/internal/app"
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+)
+
+func TestHandler_InlayHint(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		settings string
+		want     []lsp.InlayHint
+	}{
+		{
+			name:     "ParameterNames",
+			document: "void f() {\n\tfloat x = mix(0.0, 1.0, 0.5);\n}\n",
+			want: []lsp.InlayHint{
+				{Position: lsp.Position{Line: 1, Character: 15}, Label: "a:", Kind: lsp.InlayHintParameter, PaddingRight: true},
+				{Position: lsp.Position{Line: 1, Character: 20}, Label: "b:", Kind: lsp.InlayHintParameter, PaddingRight: true},
+				{Position: lsp.Position{Line: 1, Character: 25}, Label: "c:", Kind: lsp.InlayHintParameter, PaddingRight: true},
+			},
+		},
+		{
+			name:     "ParameterNameMatchesArgument",
+			document: "void f(float edge0, float edge1, float x) {\n\tfloat y = smoothstep(edge0, edge1, x * 2.0);\n}\n",
+			want: []lsp.InlayHint{
+				{Position: lsp.Position{Line: 1, Character: 36}, Label: "x:", Kind: lsp.InlayHintParameter, PaddingRight: true},
+			},
+		},
+		{
+			name:     "SingleArgumentAndUserFunctionsAreSkipped",
+			document: "float mix(float a, float b) { return a; }\nvoid f() {\n\tfloat y = sin(mix(1.0, 2.0));\n}\n",
+			want:     []lsp.InlayHint{},
+		},
+		{
+			name:     "ConstantValues",
+			document: "const float HALF = 0.5;\nconst float TAU2 = TAU * HALF;\nconst vec3 UP = vec3(0.0, HALF * 2.0, 0.0);\nconst int N = 7 / 2;\n",
+			want: []lsp.InlayHint{
+				{Position: lsp.Position{Line: 1, Character: 29}, Label: "= 3.141593", PaddingLeft: true},
+				{Position: lsp.Position{Line: 2, Character: 42}, Label: "= vec3(0.0, 1.0, 0.0)", PaddingLeft: true},
+				{Position: lsp.Position{Line: 3, Character: 19}, Label: "= 3", PaddingLeft: true},
+			},
+		},
+		{
+			name:     "SwizzleTypes",
+			document: "shader_type spatial;\nuniform vec3 tint;\nvoid fragment() {\n\tALBEDO.rg = tint.rb * 0.5;\n\tALBEDO.b = 1.0;\n}\n",
+			want: []lsp.InlayHint{
+				{Position: lsp.Position{Line: 3, Character: 26}, Label: ": vec2", Kind: lsp.InlayHintType},
+			},
+		},
+		{
+			name:     "DisabledBySettings",
+			document: "const float A = 1.0 + 1.0;\nvoid f() {\n\tfloat x = mix(0.0, 1.0, 0.5);\n}\n",
+			settings: `{"gdshader":{"inlayHints":{"parameterNames":false}}}`,
+			want: []lsp.InlayHint{
+				{Position: lsp.Position{Line: 0, Character: 25}, Label: "= 2.0", PaddingLeft: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			var h app.Handler
+			const uri = "file:///test.gdshader"
+
+			if tt.settings != "" {
+				err := h.DidChangeConfiguration(t.Context(), lsp.DidChangeConfigurationParams{Settings: []byte(tt.settings)})
+				g.Expect(err).ToNot(HaveOccurred(), "DidChangeConfiguration error")
+			}
+
+			err := h.DidOpenTextDocument(t.Context(), lsp.DidOpenTextDocumentParams{
+				TextDocument: lsp.TextDocumentItem{URI: uri, Text: tt.document},
+			})
+			g.Expect(err).ToNot(HaveOccurred(), "DidOpenTextDocument error")
+
+			hints, err := h.InlayHint(t.Context(), lsp.InlayHintParams{
+				TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+				Range:        lsp.Range{End: lsp.Position{Line: 100}},
+			})
+			g.Expect(err).ToNot(HaveOccurred(), "InlayHint error")
+			g.Expect(hints).To(Equal(tt.want))
+		})
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/armsnyder/gdshader-language-server/internal/ast"
+	"github.com/armsnyder/gdshader-language-server/internal/godot"
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+)
+
+// materials are the resource files of the workspace that have shader
+// materials, by the shaders that the materials use. They are found when the
+// workspace is indexed, and read again when they are needed, since Godot
+// changes them on its own.
+type materials struct {
+	mu sync.Mutex
+	// shaders are the paths of the shaders that the materials of each
+	// resource file use, by the path of the resource file.
+	shaders map[string][]string
+}
+
+func (m *materials) set(resource string, shaders []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.shaders == nil {
+		m.shaders = make(map[string][]string)
+	}
+	m.shaders[resource] = shaders
+}
+
+// resourcesOf returns the paths of the resource files with materials that
+// use a shader.
+func (m *materials) resourcesOf(shader string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var resources []string
+	for resource, shaders := range m.shaders {
+		if slices.Contains(shaders, shader) {
+			resources = append(resources, resource)
+		}
+	}
+	slices.Sort(resources)
+	return resources
+}
+
+// resourceFile is a text resource or scene with shader materials.
+type resourceFile struct {
+	doc      *lsp.Snapshot
+	resource *godot.Resource
+	// shaders are the paths of the shader files of the materials, or empty
+	// for the materials whose shader is not a file of the project.
+	shaders []string
+}
+
+// loadResource reads a resource file, and records the shaders that its
+// materials use. Syntax errors are tolerated, since Godot writes these
+// files.
+func (h *Handler) loadResource(ctx context.Context, path string) (*resourceFile, error) {
+	doc, err := h.readSnapshot(path)
+	if err != nil {
+		h.materials.set(path, nil)
+		return nil, err
+	}
+	resource, err := godot.ParseResource(bytes.NewReader(doc.Bytes()))
+	if resource == nil {
+		h.materials.set(path, nil)
+		return nil, err
+	}
+
+	r := &resourceFile{doc: doc, resource: resource}
+	project := h.project(ctx, lsp.PathToURI(path))
+	used := make(map[string]bool)
+	for _, m := range resource.Materials {
+		var shader string
+		if project != nil && m.ShaderPath != "" {
+			shader, _ = project.ResolvePath(m.ShaderPath)
+		}
+		r.shaders = append(r.shaders, shader)
+		if shader != "" {
+			used[shader] = true
+		}
+	}
+	h.materials.set(path, slices.Sorted(maps.Keys(used)))
+	return r, nil
+}
+
+// checkMaterials reports the parameters of the materials in a resource file
+// that are not uniforms of their shader, which Godot ignores. These are
+// usually left behind when a uniform is renamed or removed.
+func (h *Handler) checkMaterials(ctx context.Context, r *resourceFile) []diagnostic {
+	var diagnostics []diagnostic
+	uniforms := make(map[string]map[string]bool)
+	for i, m := range r.resource.Materials {
+		shader := r.shaders[i]
+		if shader == "" {
+			continue
+		}
+		declared, ok := uniforms[shader]
+		if !ok {
+			declared = h.shaderUniforms(ctx, shader)
+			uniforms[shader] = declared
+		}
+		if declared == nil {
+			// The shader can't be read.
+			continue
+		}
+
+		for _, p := range m.Parameters {
+			if declared[p.Name] {
+				continue
+			}
+			diagnostics = append(diagnostics, diagnostic{
+				Span:     parameterSpan(p),
+				severity: lsp.SeverityWarning,
+				code:     "unknown-shader-parameter",
+				message:  fmt.Sprintf("Shader '%s' has no uniform '%s'.", m.ShaderPath, p.Name),
+			})
+		}
+	}
+	return diagnostics
+}
+
+// shaderUniforms returns the names of the uniforms of a shader file and the
+// files that it includes, or nil if it can't be read.
+func (h *Handler) shaderUniforms(ctx context.Context, path string) map[string]bool {
+	f, err := h.loadShaderFile(ctx, path)
+	if err != nil {
+		return nil
+	}
+	files := append([]*ast.File{f.file}, includedFiles(h.followIncludes(ctx, f.file.Filename, f.doc, f.includes))...)
+
+	uniforms := make(map[string]bool)
+	for _, file := range files {
+		for _, decl := range file.Declarations {
+			if decl.UniformDecl != nil {
+				uniforms[decl.UniformDecl.Name] = true
+			}
+		}
+	}
+	return uniforms
+}
+
+// parameterSpan is the span of the key of a shader parameter.
+func parameterSpan(p godot.ShaderParameter) ast.Span {
+	return ast.Span{Start: p.Start, End: p.Start + len(godot.ShaderParameterPrefix) + len(p.Name)}
+}
+
+// publishMaterials publishes the diagnostics of the resource files with
+// materials that use a shader.
+func (h *Handler) publishMaterials(ctx context.Context, shader string) {
+	for _, resource := range h.materials.resourcesOf(shader) {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := h.publishResourceDiagnostics(ctx, resource); err != nil && ctx.Err() == nil {
+			h.logMessage(ctx, lsp.MessageWarning, fmt.Sprintf("Failed to check %s: %v", resource, err))
+		}
+	}
+}
+
+// publishResourceDiagnostics publishes the diagnostics of a resource file.
+// They are cleared if it can't be read anymore.
+func (h *Handler) publishResourceDiagnostics(ctx context.Context, path string) error {
+	params := lsp.PublishDiagnosticsParams{URI: lsp.PathToURI(path), Diagnostics: []lsp.Diagnostic{}}
+	r, err := h.loadResource(ctx, path)
+	if err == nil {
+		for _, d := range h.checkMaterials(ctx, r) {
+			params.Diagnostics = append(params.Diagnostics, d.toLSP(r.doc))
+		}
+	}
+	if notifyErr := h.Client.Notify(ctx, "textDocument/publishDiagnostics", params); notifyErr != nil {
+		return notifyErr
+	}
+	return err
+}
+
+// materialReferences returns the parameters of the materials that set a
+// uniform of a shader.
+func (h *Handler) materialReferences(ctx context.Context, shader, name string) []lsp.Location {
+	var locations []lsp.Location
+	for _, path := range h.materials.resourcesOf(shader) {
+		r, err := h.loadResource(ctx, path)
+		if err != nil {
+			continue
+		}
+		for i, m := range r.resource.Materials {
+			if r.shaders[i] != shader {
+				continue
+			}
+			for _, p := range m.Parameters {
+				if p.Name == name {
+					locations = append(locations, lsp.Location{URI: lsp.PathToURI(path), Range: spanToRange(r.doc, parameterSpan(p))})
+				}
+			}
+		}
+	}
+	return locations
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package app_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+	"github.com/armsnyder/gdshader-language-server/internal/lsptest"
+)
+
+const waterShader = `shader_type spatial;
+#include "res://lib/foam.gdshaderinc"
+uniform float strength;
+uniform vec4 tint;
+void fragment() {
+	ALBEDO = tint.rgb * strength;
+}
+`
+
+// startMaterialProject starts a session for a project with materials that
+// use a shader, and waits for it to be indexed. It returns the project
+// directory.
+func startMaterialProject(t *testing.T) (*lsptest.Client, string) {
+	dir := writeFiles(t, map[string]string{
+		"project.godot":         "config_version=5\n",
+		"water.gdshader":        waterShader,
+		"lib/foam.gdshaderinc":  "uniform float foam_amount;\n",
+		"materials/water.tres":  "[gd_resource type=\"ShaderMaterial\" load_steps=2 format=3]\n\n[ext_resource type=\"Shader\" path=\"res://water.gdshader\" id=\"1_w\"]\n\n[resource]\nshader = ExtResource(\"1_w\")\nshader_parameter/strength = 0.5\nshader_parameter/speed = 2.0\nshader_parameter/foam_amount = 1.0\n",
+		"main.tscn":             "[gd_scene load_steps=3 format=3]\n\n[ext_resource type=\"Shader\" path=\"res://water.gdshader\" id=\"1\"]\n\n[sub_resource type=\"ShaderMaterial\" id=\"ShaderMaterial_w\"]\nshader = ExtResource(\"1\")\nshader_parameter/strength = 2.0\n\n[node name=\"Water\" type=\"MeshInstance3D\"]\nmaterial_override = SubResource(\"ShaderMaterial_w\")\n",
+		"materials/plain.tres":  "[gd_resource type=\"StandardMaterial3D\" format=3]\n\n[resource]\nalbedo_color = Color(1, 0, 0, 1)\n",
+		"materials/broken.tres": "[gd_resource type=\"ShaderMaterial\" format=3]\n\n[resource]\nshader = ExtResource(\"missing\")\nshader_parameter/anything = 1\n",
+	})
+	c := startSession(t, lsptest.Options{
+		WorkspaceFolders: []lsp.WorkspaceFolder{{URI: lsp.PathToURI(dir), Name: "project"}},
+	})
+	c.WaitDiagnostics(lsp.PathToURI(filepath.Join(dir, "materials", "water.tres")))
+	return c, dir
+}
+
+func TestHandler_Material_UnknownParameter(t *testing.T) {
+	g := NewWithT(t)
+	c, dir := startMaterialProject(t)
+	materialURI := lsp.PathToURI(filepath.Join(dir, "materials", "water.tres"))
+	sceneURI := lsp.PathToURI(filepath.Join(dir, "main.tscn"))
+	shaderURI := lsp.PathToURI(filepath.Join(dir, "water.gdshader"))
+
+	// Uniforms of included files are parameters of the shader too.
+	g.Expect(c.WaitDiagnostics(materialURI)).To(Equal([]lsp.Diagnostic{{
+		Range:    lsp.Range{Start: lsp.Position{Line: 7, Character: 0}, End: lsp.Position{Line: 7, Character: 22}},
+		Severity: lsp.SeverityWarning,
+		Code:     "unknown-shader-parameter",
+		Source:   "gdshader",
+		Message:  "Shader 'res://water.gdshader' has no uniform 'speed'.",
+	}}))
+	g.Expect(c.WaitDiagnostics(sceneURI)).To(BeEmpty())
+
+	// Renaming the uniform leaves the parameters of the materials behind.
+	c.Open(shaderURI, waterShader)
+	c.Change(shaderURI, "shader_type spatial;\nuniform float power;\n")
+	g.Eventually(func() []lsp.Diagnostic { return c.Diagnostics(materialURI) }).Should(HaveLen(3))
+	g.Eventually(func() []lsp.Diagnostic { return c.Diagnostics(sceneURI) }).Should(HaveLen(1))
+	g.Expect(c.Diagnostics(sceneURI)[0].Message).To(Equal("Shader 'res://water.gdshader' has no uniform 'strength'."))
+}
+
+func TestHandler_Material_References(t *testing.T) {
+	g := NewWithT(t)
+	c, dir := startMaterialProject(t)
+	shaderURI := lsp.PathToURI(filepath.Join(dir, "water.gdshader"))
+
+	f := c.Open(shaderURI, `shader_type spatial;
+#include "res://lib/foam.gdshaderinc"
+uniform float /*^decl*/strength;
+uniform vec4 tint;
+void fragment() {
+	ALBEDO = tint.rgb * /*^use*/strength * foam_amount;
+}
+`)
+	declaration := lsp.Range{Start: f.At("decl"), End: lsp.Position{Line: 2, Character: f.At("decl").Character + len("strength")}}
+	use := lsp.Range{Start: f.At("use"), End: lsp.Position{Line: 5, Character: f.At("use").Character + len("strength")}}
+	g.Expect(c.References(shaderURI, f.At("use"))).To(Equal([]lsp.Location{
+		{URI: shaderURI, Range: declaration},
+		{URI: shaderURI, Range: use},
+		{
+			URI:   lsp.PathToURI(filepath.Join(dir, "main.tscn")),
+			Range: lsp.Range{Start: lsp.Position{Line: 6, Character: 0}, End: lsp.Position{Line: 6, Character: 25}},
+		},
+		{
+			URI:   lsp.PathToURI(filepath.Join(dir, "materials", "water.tres")),
+			Range: lsp.Range{Start: lsp.Position{Line: 6, Character: 0}, End: lsp.Position{Line: 6, Character: 25}},
+		},
+	}))
+
+	// The declaration finds the same references.
+	g.Expect(c.References(shaderURI, f.At("decl"))).To(HaveLen(4))
+
+	// Uniforms that no material sets are only referenced in the shader.
+	g.Expect(c.References(shaderURI, lsp.Position{Line: 3, Character: 14})).To(HaveLen(2))
+}
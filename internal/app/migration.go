@@ -0,0 +1,239 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/armsnyder/gdshader-language-server/internal/ast"
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+)
+
+// codeActionMigrate is the kind of the source action that migrates a whole
+// file to Godot 4.
+const codeActionMigrate lsp.CodeActionKind = "source.migrate"
+
+const godot3Code = "godot3"
+
+// godot3Builtins maps renamed built-in variables to their Godot 4 name.
+// https://docs.godotengine.org/en/stable/tutorials/migrating/upgrading_to_godot_4.html
+var godot3Builtins = map[string]string{
+	"WORLD_MATRIX":      "MODEL_MATRIX",
+	"CAMERA_MATRIX":     "INV_VIEW_MATRIX",
+	"INV_CAMERA_MATRIX": "VIEW_MATRIX",
+	"NORMALMAP":         "NORMAL_MAP",
+	"NORMALMAP_DEPTH":   "NORMAL_MAP_DEPTH",
+	"TRANSMISSION":      "BACKLIGHT",
+	"ALPHA_SCISSOR":     "ALPHA_SCISSOR_THRESHOLD",
+}
+
+// godot3Textures are the built-in textures that became uniforms with a
+// hint. The uniform name and declaration are what the Godot 4 project
+// converter generates.
+var godot3Textures = map[string]struct{ name, hints string }{
+	"SCREEN_TEXTURE":           {"screen_texture", "hint_screen_texture, filter_linear_mipmap"},
+	"DEPTH_TEXTURE":            {"depth_texture", "hint_depth_texture, filter_linear_mipmap"},
+	"NORMAL_ROUGHNESS_TEXTURE": {"normal_roughness_texture", "hint_normal_roughness_texture, filter_linear_mipmap"},
+}
+
+var godot3Hints = map[string]string{
+	"hint_albedo":       "source_color",
+	"hint_color":        "source_color",
+	"hint_black":        "hint_default_black",
+	"hint_black_albedo": "hint_default_black",
+	"hint_white":        "hint_default_white",
+	"hint_aniso":        "hint_anisotropy",
+}
+
+var godot3RenderModes = map[string]string{
+	"depth_draw_alpha_prepass": "depth_prepass_alpha",
+	// Removed without a replacement.
+	"specular_blinn": "",
+	"specular_phong": "",
+	"async_visible":  "",
+	"async_hidden":   "",
+}
+
+// checkGodot3 reports constructs from Godot 3 that no longer exist in
+// Godot 4, with a fix that migrates each of them.
+func checkGodot3(a *analysis) []diagnostic {
+	var diagnostics []diagnostic
+	add := func(span ast.Span, message, title string, edits ...ast.TextEdit) {
+		diagnostics = append(diagnostics, diagnostic{
+			Span:     span,
+			severity: lsp.SeverityError,
+			code:     godot3Code,
+			message:  message,
+			fixes:    []fix{{title: title, edits: edits, preferred: true}},
+		})
+	}
+
+	for _, decl := range a.file.Declarations {
+		switch {
+		case decl.RenderMode != nil:
+			for i, mode := range decl.RenderMode.Modes {
+				newName, ok := godot3RenderModes[mode.Name]
+				switch {
+				case !ok:
+				case newName != "":
+					add(mode.Span, fmt.Sprintf("Render mode '%s' was renamed to '%s' in Godot 4.", mode.Name, newName), fmt.Sprintf("Rename to '%s'", newName),
+						ast.TextEdit{Span: mode.Span, NewText: newName})
+				default:
+					add(mode.Span, fmt.Sprintf("Render mode '%s' was removed in Godot 4.", mode.Name), fmt.Sprintf("Remove '%s'", mode.Name),
+						removeRenderMode(a.text, decl, i))
+				}
+			}
+
+		case decl.UniformDecl != nil:
+			for _, hint := range decl.UniformDecl.Hints {
+				if newName, ok := godot3Hints[hint.Name]; ok {
+					span := ast.Span{Start: hint.Start, End: hint.Start + len(hint.Name)}
+					add(span, fmt.Sprintf("Uniform hint '%s' was renamed to '%s' in Godot 4.", hint.Name, newName), fmt.Sprintf("Rename to '%s'", newName),
+						ast.TextEdit{Span: span, NewText: newName})
+				}
+			}
+
+		case decl.FunctionDecl != nil && decl.FunctionDecl.Body != nil:
+			ast.Inspect(decl.FunctionDecl.Body, func(node ast.Node) bool {
+				ident, ok := node.(*ast.Ident)
+				if !ok {
+					return true
+				}
+				if newName, ok := godot3Builtins[ident.Name]; ok {
+					add(ident.Span, fmt.Sprintf("'%s' was renamed to '%s' in Godot 4.", ident.Name, newName), fmt.Sprintf("Rename to '%s'", newName),
+						ast.TextEdit{Span: ident.Span, NewText: newName})
+				}
+				if texture, ok := godot3Textures[ident.Name]; ok {
+					edits := []ast.TextEdit{{Span: ident.Span, NewText: texture.name}}
+					if insert, ok := textureUniformEdit(a, texture.name, texture.hints); ok {
+						edits = append([]ast.TextEdit{insert}, edits...)
+					}
+					add(ident.Span, fmt.Sprintf("'%s' was replaced by a uniform with a hint in Godot 4.", ident.Name), fmt.Sprintf("Replace with uniform '%s'", texture.name), edits...)
+				}
+				return true
+			})
+		}
+	}
+
+	return diagnostics
+}
+
+func isGodot3Builtin(name string) bool {
+	_, renamed := godot3Builtins[name]
+	_, texture := godot3Textures[name]
+	return renamed || texture
+}
+
+// removeRenderMode returns the edit that removes a render mode along with
+// its separating comma. The whole declaration is removed if it has no other
+// modes.
+func removeRenderMode(text []byte, decl *ast.Declaration, i int) ast.TextEdit {
+	modes := decl.RenderMode.Modes
+	switch {
+	case len(modes) == 1:
+		return ast.TextEdit{Span: lineExtent(text, decl.Span)}
+	case i == 0:
+		return ast.TextEdit{Span: ast.Span{Start: modes[0].Start, End: modes[1].Start}}
+	default:
+		return ast.TextEdit{Span: ast.Span{Start: modes[i-1].End, End: modes[i].End}}
+	}
+}
+
+// textureUniformEdit declares the uniform that replaces a Godot 3 built-in
+// texture, after the shader_type and render_mode declarations. It returns
+// false if the uniform already exists.
+func textureUniformEdit(a *analysis, name, hints string) (ast.TextEdit, bool) {
+	offset := 0
+	for _, decl := range a.file.Declarations {
+		switch {
+		case decl.UniformDecl != nil && decl.UniformDecl.Name == name:
+			return ast.TextEdit{}, false
+		case decl.ShaderType != nil || decl.RenderMode != nil:
+			offset = lineExtent(a.text, decl.Span).End
+		}
+	}
+
+	newText := "uniform sampler2D " + name + " : " + hints + ";\n"
+	if offset > 0 && !bytes.HasSuffix(a.text[:offset], []byte("\n")) {
+		newText = "\n" + newText
+	}
+	return ast.TextEdit{Span: ast.Span{Start: offset, End: offset}, NewText: newText}, true
+}
+
+// migrateAction is a source action that applies the fix of every Godot 3
+// diagnostic in the file at once.
+func migrateAction(uri string, a *analysis, diagnostics []diagnostic) (lsp.CodeAction, bool) {
+	// The render modes of a declaration are migrated together, since the
+	// fixes of adjacent modes that are removed overlap. These edits come
+	// first, so that they take the place of the fixes of single modes.
+	edits := migrateRenderModes(a)
+	for _, d := range diagnostics {
+		if d.code == godot3Code {
+			edits = append(edits, d.fixes[0].edits...)
+		}
+	}
+	if len(edits) == 0 {
+		return lsp.CodeAction{}, false
+	}
+
+	// Fixes of different diagnostics may make the same edit, such as
+	// declaring the screen_texture uniform. Overlapping edits are dropped,
+	// since the client would reject them, and the first one is kept.
+	slices.SortStableFunc(edits, func(x, y ast.TextEdit) int { return x.Start - y.Start })
+	var merged []ast.TextEdit
+	seen := make(map[ast.TextEdit]bool)
+	for _, edit := range edits {
+		if seen[edit] || (len(merged) > 0 && edit.Start < merged[len(merged)-1].End) {
+			continue
+		}
+		seen[edit] = true
+		merged = append(merged, edit)
+	}
+
+	return lsp.CodeAction{
+		Title: "Migrate shader to Godot 4",
+		Kind:  codeActionMigrate,
+		Edit:  &lsp.WorkspaceEdit{Changes: map[string][]lsp.TextEdit{uri: toLSPEdits(a.doc, merged)}},
+	}, true
+}
+
+// migrateRenderModes returns an edit for each render_mode declaration with
+// Godot 3 modes, which renames and removes all of them. The whole
+// declaration is removed if it has no modes left.
+func migrateRenderModes(a *analysis) []ast.TextEdit {
+	var edits []ast.TextEdit
+	for _, decl := range a.file.Declarations {
+		if decl.RenderMode == nil {
+			continue
+		}
+		modes := decl.RenderMode.Modes
+		var names []string
+		changed := false
+		for _, mode := range modes {
+			newName, ok := godot3RenderModes[mode.Name]
+			switch {
+			case !ok:
+				names = append(names, mode.Name)
+			case newName != "":
+				names = append(names, newName)
+				changed = true
+			default:
+				changed = true
+			}
+		}
+
+		switch {
+		case !changed:
+		case len(names) == 0:
+			edits = append(edits, ast.TextEdit{Span: lineExtent(a.text, decl.Span)})
+		default:
+			span := ast.Span{Start: modes[0].Start, End: modes[len(modes)-1].End}
+			edits = append(edits, ast.TextEdit{Span: span, NewText: strings.Join(names, ", ")})
+		}
+	}
+	return edits
+}
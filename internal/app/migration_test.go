@@ -0,0 +1,188 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package app_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+)
+
+func TestHandler_CodeAction_Godot3(t *testing.T) {
+	const document = `shader_type spatial;
+render_mode depth_draw_alpha_prepass, specular_blinn, unshaded;
+
+uniform vec4 tint : hint_color = vec4(1.0);
+
+void vertex() {
+	VERTEX = (WORLD_MATRIX * vec4(VERTEX, 1.0)).xyz;
+}
+
+void fragment() {
+	vec3 screen = texture(SCREEN_TEXTURE, SCREEN_UV).rgb;
+	float depth = texture(DEPTH_TEXTURE, SCREEN_UV).r;
+	ALBEDO = screen * tint.rgb + textureLod(SCREEN_TEXTURE, SCREEN_UV, 2.0).rgb * depth;
+	TRANSMISSION = vec3(0.1);
+}
+`
+
+	t.Run("QuickFixes", func(t *testing.T) {
+		tests := []struct {
+			line int
+			want map[string]string
+		}{
+			{
+				line: 1,
+				want: map[string]string{
+					"Rename to 'depth_prepass_alpha'": "render_mode depth_prepass_alpha, specular_blinn, unshaded;",
+					"Remove 'specular_blinn'":         "render_mode depth_draw_alpha_prepass, unshaded;",
+				},
+			},
+			{
+				line: 3,
+				want: map[string]string{
+					"Rename to 'source_color'": "uniform vec4 tint : source_color = vec4(1.0);",
+				},
+			},
+			{
+				line: 6,
+				want: map[string]string{
+					"Rename to 'MODEL_MATRIX'": "\tVERTEX = (MODEL_MATRIX * vec4(VERTEX, 1.0)).xyz;",
+				},
+			},
+			{
+				line: 13,
+				want: map[string]string{
+					"Rename to 'BACKLIGHT'": "\tBACKLIGHT = vec3(0.1);",
+				},
+			},
+		}
+
+		for _, tt := range tests {
+			g := NewWithT(t)
+			h := openDocument(t, document)
+
+			actions, err := h.CodeAction(t.Context(), lsp.CodeActionParams{
+				TextDocument: lsp.TextDocumentIdentifier{URI: testURI},
+				Range:        lsp.Range{Start: lsp.Position{Line: tt.line}, End: lsp.Position{Line: tt.line + 1}},
+				Context:      lsp.CodeActionContext{Only: []lsp.CodeActionKind{lsp.CodeActionQuickFix}},
+			})
+			g.Expect(err).ToNot(HaveOccurred(), "CodeAction error")
+
+			got := make(map[string]string)
+			for _, action := range actions {
+				got[action.Title] = lineOf(applyTextEdits(document, action.Edit.Changes[testURI]), tt.line)
+			}
+			g.Expect(got).To(Equal(tt.want), "line %d", tt.line)
+		}
+	})
+
+	t.Run("ScreenTexture", func(t *testing.T) {
+		g := NewWithT(t)
+		h := openDocument(t, document)
+
+		actions, err := h.CodeAction(t.Context(), lsp.CodeActionParams{
+			TextDocument: lsp.TextDocumentIdentifier{URI: testURI},
+			Range:        lsp.Range{Start: lsp.Position{Line: 10, Character: 24}, End: lsp.Position{Line: 10, Character: 24}},
+			Context:      lsp.CodeActionContext{Only: []lsp.CodeActionKind{lsp.CodeActionQuickFix}},
+		})
+		g.Expect(err).ToNot(HaveOccurred(), "CodeAction error")
+		g.Expect(actions).To(HaveLen(1))
+		g.Expect(actions[0].Title).To(Equal("Replace with uniform 'screen_texture'"))
+		g.Expect(applyTextEdits(document, actions[0].Edit.Changes[testURI])).To(Equal(`shader_type spatial;
+render_mode depth_draw_alpha_prepass, specular_blinn, unshaded;
+uniform sampler2D screen_texture : hint_screen_texture, filter_linear_mipmap;
+
+uniform vec4 tint : hint_color = vec4(1.0);
+
+void vertex() {
+	VERTEX = (WORLD_MATRIX * vec4(VERTEX, 1.0)).xyz;
+}
+
+void fragment() {
+	vec3 screen = texture(screen_texture, SCREEN_UV).rgb;
+	float depth = texture(DEPTH_TEXTURE, SCREEN_UV).r;
+	ALBEDO = screen * tint.rgb + textureLod(SCREEN_TEXTURE, SCREEN_UV, 2.0).rgb * depth;
+	TRANSMISSION = vec3(0.1);
+}
+`))
+	})
+
+	t.Run("MigrateWholeFile", func(t *testing.T) {
+		g := NewWithT(t)
+		h := openDocument(t, document)
+
+		actions, err := h.CodeAction(t.Context(), lsp.CodeActionParams{
+			TextDocument: lsp.TextDocumentIdentifier{URI: testURI},
+			Context:      lsp.CodeActionContext{Only: []lsp.CodeActionKind{lsp.CodeActionSource}},
+		})
+		g.Expect(err).ToNot(HaveOccurred(), "CodeAction error")
+		g.Expect(actions).To(HaveLen(1))
+		g.Expect(actions[0].Title).To(Equal("Migrate shader to Godot 4"))
+		g.Expect(applyTextEdits(document, actions[0].Edit.Changes[testURI])).To(Equal(`shader_type spatial;
+render_mode depth_prepass_alpha, unshaded;
+uniform sampler2D screen_texture : hint_screen_texture, filter_linear_mipmap;
+uniform sampler2D depth_texture : hint_depth_texture, filter_linear_mipmap;
+
+uniform vec4 tint : source_color = vec4(1.0);
+
+void vertex() {
+	VERTEX = (MODEL_MATRIX * vec4(VERTEX, 1.0)).xyz;
+}
+
+void fragment() {
+	vec3 screen = texture(screen_texture, SCREEN_UV).rgb;
+	float depth = texture(depth_texture, SCREEN_UV).r;
+	ALBEDO = screen * tint.rgb + textureLod(screen_texture, SCREEN_UV, 2.0).rgb * depth;
+	BACKLIGHT = vec3(0.1);
+}
+`))
+	})
+}
+
+func TestHandler_CodeAction_Godot3_RenderModes(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		want     string
+	}{
+		{
+			name:     "AdjacentRemovals",
+			document: "shader_type spatial;\nrender_mode unshaded, async_visible, specular_phong;\n",
+			want:     "shader_type spatial;\nrender_mode unshaded;\n",
+		},
+		{
+			name:     "RemoveEveryMode",
+			document: "shader_type spatial;\nrender_mode async_visible, specular_phong;\n",
+			want:     "shader_type spatial;\n",
+		},
+		{
+			name:     "RenameAndRemove",
+			document: "shader_type spatial;\nrender_mode specular_blinn, depth_draw_alpha_prepass, async_hidden;\n",
+			want:     "shader_type spatial;\nrender_mode depth_prepass_alpha;\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			h := openDocument(t, tt.document)
+
+			actions, err := h.CodeAction(t.Context(), lsp.CodeActionParams{
+				TextDocument: lsp.TextDocumentIdentifier{URI: testURI},
+				Context:      lsp.CodeActionContext{Only: []lsp.CodeActionKind{lsp.CodeActionSource}},
+			})
+			g.Expect(err).ToNot(HaveOccurred(), "CodeAction error")
+			g.Expect(actions).To(HaveLen(1))
+			g.Expect(applyTextEdits(tt.document, actions[0].Edit.Changes[testURI])).To(Equal(tt.want))
+		})
+	}
+}
+
+func lineOf(document string, line int) string {
+	return strings.Split(document, "\n")[line]
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/armsnyder/gdshader-language-server/internal/godot"
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+)
+
+// projects caches the Godot projects of documents by their root. A project
+// is loaded again when its project.godot changes, and so is one that failed
+// to load.
+type projects struct {
+	mu     sync.Mutex
+	byRoot map[string]*loadedProject
+	// roots are the project roots of the directories of documents, or empty
+	// for the directories that aren't in a project. A project.godot that is
+	// added later is only found for directories that weren't looked up yet.
+	roots map[string]string
+}
+
+// rootOf returns the project root of a directory, which is looked up once.
+func (p *projects) rootOf(dir string) (string, bool) {
+	p.mu.Lock()
+	root, ok := p.roots[dir]
+	p.mu.Unlock()
+	if ok {
+		return root, root != ""
+	}
+
+	root, _ = godot.FindProject(dir)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.roots == nil {
+		p.roots = make(map[string]string)
+	}
+	p.roots[dir] = root
+	return root, root != ""
+}
+
+type loadedProject struct {
+	// project is nil if project.godot can't be read.
+	project *godot.Project
+	modTime time.Time
+}
+
+// project returns the Godot project of a document, which is the nearest
+// project.godot above it, or nil if it isn't in a project. Problems with
+// project.godot are shown to the user once each time it changes.
+func (h *Handler) project(ctx context.Context, uri string) *godot.Project {
+	path, err := lsp.URIToPath(uri)
+	if err != nil {
+		return nil
+	}
+	root, ok := h.projects.rootOf(filepath.Dir(path))
+	if !ok {
+		return nil
+	}
+	return h.loadProject(ctx, root)
+}
+
+// loadProject returns the project in a root directory, from the cache if
+// its project.godot hasn't changed since it was loaded. Failures to read it
+// are cached too, so that they are reported once for each change.
+func (h *Handler) loadProject(ctx context.Context, root string) *godot.Project {
+	info, err := os.Stat(filepath.Join(root, godot.ProjectFile))
+	if err != nil {
+		return nil
+	}
+
+	// The client is told about the project once the lock is released, since
+	// this is deferred before it.
+	var notify []func()
+	defer func() {
+		for _, f := range notify {
+			f()
+		}
+	}()
+	h.projects.mu.Lock()
+	defer h.projects.mu.Unlock()
+
+	if loaded, ok := h.projects.byRoot[root]; ok && loaded.modTime.Equal(info.ModTime()) {
+		return loaded.project
+	}
+
+	if h.projects.byRoot == nil {
+		h.projects.byRoot = make(map[string]*loadedProject)
+	}
+	project, err := godot.LoadProject(root)
+	h.projects.byRoot[root] = &loadedProject{project: project, modTime: info.ModTime()}
+	if project == nil {
+		notify = append(notify, func() {
+			h.logMessage(ctx, lsp.MessageWarning, fmt.Sprintf("Failed to read %s: %v", filepath.Join(root, godot.ProjectFile), err))
+		})
+		return nil
+	}
+	if err != nil {
+		// The settings before the error are still used.
+		notify = append(notify, func() {
+			h.showMessage(ctx, lsp.MessageWarning, fmt.Sprintf("Malformed %s: %v", filepath.Join(root, godot.ProjectFile), err))
+		})
+	}
+
+	notify = append(notify, func() { h.logMessage(ctx, lsp.MessageInfo, describeProject(project)) })
+	return project
+}
+
+func describeProject(p *godot.Project) string {
+	description := fmt.Sprintf("Loaded Godot project %q at %s", p.Name, p.Root)
+	if version := p.EngineVersion(); version != "" {
+		description += " (Godot " + version + ")"
+	}
+	return description
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package app_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+	"github.com/armsnyder/gdshader-language-server/internal/lsptest"
+	"github.com/samber/lo"
+)
+
+func TestHandler_Project(t *testing.T) {
+	g := NewWithT(t)
+	dir := writeFiles(t, map[string]string{
+		"project.godot": "config_version=5\n\n[application]\n\nconfig/name=\"Water Demo\"\nconfig/features=PackedStringArray(\"4.3\", \"Forward Plus\")\n",
+	})
+	uri := lsp.PathToURI(filepath.Join(dir, "shaders", "water.gdshader"))
+
+	c := startSession(t, lsptest.Options{})
+	c.Open(uri, "shader_type spatial;\n")
+	c.WaitDiagnostics(uri)
+
+	var logMessage lsp.LogMessageParams
+	lo.Must0(json.Unmarshal(c.WaitNotifications("window/logMessage", 1)[0], &logMessage))
+	g.Expect(logMessage.Message).To(Equal(`Loaded Godot project "Water Demo" at ` + dir + " (Godot 4.3)"))
+
+	// The project is only loaded once.
+	c.Close(uri)
+	c.Open(uri, "shader_type spatial;\n")
+	c.WaitDiagnostics(uri)
+	g.Expect(c.WaitNotifications("window/logMessage", 1)).To(HaveLen(1))
+}
+
+func TestHandler_Project_Malformed(t *testing.T) {
+	g := NewWithT(t)
+	dir := writeFiles(t, map[string]string{
+		"project.godot": "config_version=5\n\n[application\n",
+	})
+	uri := lsp.PathToURI(filepath.Join(dir, "water.gdshader"))
+
+	c := startSession(t, lsptest.Options{})
+	c.Open(uri, "shader_type spatial;\n")
+
+	var showMessage lsp.ShowMessageParams
+	lo.Must0(json.Unmarshal(c.WaitNotifications("window/showMessage", 1)[0], &showMessage))
+	g.Expect(showMessage.Type).To(Equal(lsp.MessageWarning))
+	g.Expect(showMessage.Message).To(Equal("Malformed " + filepath.Join(dir, "project.godot") + ": line 3: unterminated tag"))
+}
+
+func TestHandler_Project_Unreadable(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can read any file")
+	}
+	g := NewWithT(t)
+	dir := writeFiles(t, map[string]string{
+		"project.godot": "config_version=5\n",
+	})
+	g.Expect(os.Chmod(filepath.Join(dir, "project.godot"), 0)).To(Succeed())
+	uri := lsp.PathToURI(filepath.Join(dir, "water.gdshader"))
+
+	c := startSession(t, lsptest.Options{})
+	c.Open(uri, "shader_type spatial;\n")
+	c.WaitDiagnostics(uri)
+	c.Hover(uri, lsp.Position{})
+	c.Change(uri, "shader_type canvas_item;\n")
+	c.WaitDiagnostics(uri)
+
+	// The failure is only reported once, until project.godot changes.
+	logMessages := c.WaitNotifications("window/logMessage", 1)
+	g.Expect(logMessages).To(HaveLen(1))
+	var logMessage lsp.LogMessageParams
+	lo.Must0(json.Unmarshal(logMessages[0], &logMessage))
+	g.Expect(logMessage.Message).To(HavePrefix("Failed to read " + filepath.Join(dir, "project.godot")))
+}
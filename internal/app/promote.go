@@ -0,0 +1,371 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package app
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/armsnyder/gdshader-language-server/internal/ast"
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+)
+
+// promotion describes a value that can be promoted to a uniform.
+type promotion struct {
+	name  string
+	typ   string
+	value constValue
+	// source is the default value of the uniform.
+	source string
+	// replace is the literal that is replaced by the uniform name, or the
+	// const declaration that is removed.
+	replace     ast.Span
+	replaceText string
+}
+
+// promoteToUniformActions returns a code action that promotes the literal or
+// const at the selection to a uniform at the top of the file, plus one for
+// each group_uniforms block that it can be placed in.
+func promoteToUniformActions(uri string, a *analysis, selection ast.Span) []lsp.CodeAction {
+	p, ok := findPromotion(a, selection)
+	if !ok {
+		return nil
+	}
+
+	declaration := "uniform " + p.typ + " " + p.name
+	if hint := uniformHint(p.value); hint != "" {
+		declaration += " : " + hint
+	}
+	declaration += " = " + p.source + ";\n"
+
+	replace := ast.TextEdit{Span: p.replace, NewText: p.replaceText}
+
+	actions := []lsp.CodeAction{refactorAction(uri, a.doc, "Promote to uniform", lsp.CodeActionRefactorExtract, []ast.TextEdit{
+		{Span: ast.Span{Start: topUniformOffset(a), End: topUniformOffset(a)}, NewText: declaration},
+		replace,
+	})}
+
+	for _, group := range uniformGroups(a) {
+		actions = append(actions, refactorAction(uri, a.doc, fmt.Sprintf("Promote to uniform in group '%s'", group.name), lsp.CodeActionRefactorExtract, []ast.TextEdit{
+			{Span: ast.Span{Start: group.insert, End: group.insert}, NewText: declaration},
+			replace,
+		}))
+	}
+
+	return actions
+}
+
+// findPromotion finds the value to promote. A selection that exactly
+// matches a literal is promoted as is. Otherwise, the vector constructor or
+// literal around the cursor is used, or the const whose name is under the
+// cursor.
+func findPromotion(a *analysis, selection ast.Span) (promotion, bool) {
+	if p, ok := findConstPromotion(a, selection); ok {
+		return p, true
+	}
+
+	fn := enclosingFunction(a.file, selection)
+	if fn == nil {
+		return promotion{}, false
+	}
+
+	var exact, outer ast.Expr
+	var target ast.Node
+	ast.Inspect(fn.Body, func(node ast.Node) bool {
+		extent := node.Extent()
+		if extent.Start > selection.Start || extent.End < selection.End {
+			return false
+		}
+		switch n := node.(type) {
+		case *ast.VarDecl:
+			// Constants can't be initialized with uniforms.
+			return !n.Const
+		case *ast.CaseStmt, *ast.Declarator:
+			if d, ok := n.(*ast.Declarator); ok && d.ArraySize == nil {
+				target = d
+				return true
+			}
+			return false
+		case *ast.AssignExpr:
+			target = n.Left
+		case *ast.CallExpr:
+			if !isPromotable(n) {
+				target = nil
+			}
+		}
+		if expr, ok := node.(ast.Expr); ok && isPromotable(expr) {
+			if extent == selection {
+				exact = expr
+			}
+			if outer == nil {
+				outer = expr
+			}
+		}
+		return true
+	})
+
+	expr := exact
+	if expr == nil {
+		expr = outer
+	}
+	if expr == nil {
+		return promotion{}, false
+	}
+
+	env := a.typeEnv(expr.Extent().Start)
+	value, ok := env.evalConst(expr)
+	if !ok {
+		return promotion{}, false
+	}
+
+	base := "value"
+	switch t := target.(type) {
+	case *ast.Declarator:
+		base = t.Name
+	case ast.Expr:
+		if ident := baseIdent(t); ident != nil {
+			base = strings.ToLower(ident.Name)
+		}
+	}
+
+	span := expr.Extent()
+	name := uniqueName(base, func(name string) bool { return nameIsDeclared(a.file, name) })
+	return promotion{
+		name:        name,
+		typ:         value.typ,
+		value:       value,
+		source:      string(a.text[span.Start:span.End]),
+		replace:     span,
+		replaceText: name,
+	}, true
+}
+
+// isPromotable reports whether an expression is a literal number, or a
+// vector constructor of literal numbers.
+func isPromotable(expr ast.Expr) bool {
+	switch x := expr.(type) {
+	case *ast.Literal:
+		return x.Kind != ast.LiteralBool
+	case *ast.UnaryExpr:
+		return x.Op == "-" && isPromotable(x.X)
+	case *ast.CallExpr:
+		callee := x.Callee()
+		if callee == nil || componentCount(callee.Name) < 2 || isMatrix(callee.Name) || len(x.Args) == 0 {
+			return false
+		}
+		for _, arg := range x.Args {
+			if _, ok := arg.(*ast.CallExpr); ok || !isPromotable(arg) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// findConstPromotion promotes a const with the name under the cursor. The
+// uniform keeps the name, so only the declaration has to be removed.
+func findConstPromotion(a *analysis, selection ast.Span) (promotion, bool) {
+	var decl *ast.VarDecl
+	var declSpan ast.Span
+	ast.Inspect(a.file, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.Declaration:
+			if n.ConstDecl != nil {
+				declSpan = n.Span
+			}
+		case *ast.Stmt:
+			if n.VarDecl != nil {
+				declSpan = n.Span
+			}
+		case *ast.VarDecl:
+			if n.Const && len(n.Vars) == 1 && n.Vars[0].NameSpan.Start <= selection.Start && selection.End <= n.Vars[0].NameSpan.End {
+				decl = n
+			}
+		}
+		return decl == nil
+	})
+	if decl == nil || decl.Vars[0].Init == nil || !isPromotable(decl.Vars[0].Init) {
+		return promotion{}, false
+	}
+
+	v := decl.Vars[0]
+	if constIsUsedInConstants(a.file, v.Name) {
+		return promotion{}, false
+	}
+
+	value, ok := a.typeEnv(decl.End).evalConst(v.Init)
+	if !ok {
+		return promotion{}, false
+	}
+	init := v.Init.Extent()
+
+	return promotion{
+		name:    v.Name,
+		typ:     decl.Type,
+		value:   value,
+		source:  string(a.text[init.Start:init.End]),
+		replace: lineExtent(a.text, declSpan),
+	}, true
+}
+
+// constIsUsedInConstants reports whether a name is used where only
+// constants are allowed, such as in another const or an array size.
+func constIsUsedInConstants(file *ast.File, name string) bool {
+	used := false
+	var search func(node ast.Node) bool
+	search = func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok && ident.Name == name {
+			used = true
+		}
+		return !used
+	}
+
+	ast.Inspect(file, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.VarDecl:
+			if n.Const {
+				for _, v := range n.Vars {
+					ast.Inspect(v.Init, search)
+				}
+			}
+		case *ast.Declarator:
+			ast.Inspect(n.ArraySize, search)
+		case *ast.UniformDecl:
+			ast.Inspect(n.ArraySize, search)
+			ast.Inspect(n.Default, search)
+		case *ast.CaseStmt:
+			ast.Inspect(n.Value, search)
+		}
+		return !used
+	})
+	return used
+}
+
+// nameIsDeclared reports whether any global or local declaration in the
+// file has the name.
+func nameIsDeclared(file *ast.File, name string) bool {
+	if _, ok := builtinFunctions[name]; ok {
+		return true
+	}
+	if _, ok := dataTypes[name]; ok {
+		return true
+	}
+	if slices.Contains(builtinVariableNames(newTypeEnv(file, 0).context), name) {
+		return true
+	}
+
+	declared := false
+	ast.Inspect(file, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.UniformDecl:
+			declared = n.Name == name
+		case *ast.VaryingDecl:
+			declared = n.Name == name
+		case *ast.StructDecl:
+			declared = n.Name == name
+		case *ast.FunctionDecl:
+			declared = n.Name == name
+		case *ast.Param:
+			declared = n.Name == name
+		case *ast.Declarator:
+			declared = n.Name == name
+		}
+		return !declared
+	})
+	return declared
+}
+
+// uniformHint suggests a hint for the default value of a uniform.
+func uniformHint(value constValue) string {
+	switch value.typ {
+	case "float":
+		v := value.components[0]
+		switch {
+		case v >= 0 && v <= 1:
+			return "hint_range(0.0, 1.0)"
+		case v > 1:
+			return "hint_range(0.0, " + formatScalar("float", niceCeil(2*v)) + ")"
+		default:
+			bound := niceCeil(-2 * v)
+			return "hint_range(" + formatScalar("float", -bound) + ", " + formatScalar("float", bound) + ")"
+		}
+	case "vec3", "vec4":
+		for _, c := range value.components {
+			if c < 0 || c > 1 {
+				return ""
+			}
+		}
+		return "source_color"
+	}
+	return ""
+}
+
+// niceCeil rounds up to the nearest 1, 2 or 5 times a power of ten.
+func niceCeil(v float64) float64 {
+	magnitude := math.Pow(10, math.Floor(math.Log10(v)))
+	for _, step := range []float64{1, 2, 5, 10} {
+		if step*magnitude >= v {
+			return step * magnitude
+		}
+	}
+	return 10 * magnitude
+}
+
+// topUniformOffset is where new uniforms are declared: after the leading
+// shader_type, render_mode and uniform declarations that are not in a
+// group.
+func topUniformOffset(a *analysis) int {
+	offset := 0
+	for _, decl := range a.file.Declarations {
+		if decl.ShaderType == nil && decl.RenderMode == nil && decl.UniformDecl == nil {
+			break
+		}
+		offset = lineExtent(a.text, decl.Span).End
+	}
+	return ensureLineStart(a.text, offset)
+}
+
+type uniformGroup struct {
+	name   string
+	insert int
+}
+
+// uniformGroups returns each group_uniforms block, with the offset after
+// its last uniform.
+func uniformGroups(a *analysis) []uniformGroup {
+	var groups []uniformGroup
+	for _, decl := range a.file.Declarations {
+		switch {
+		case decl.GroupUniforms != nil:
+			if decl.GroupUniforms.Name == "" {
+				groups = append(groups, uniformGroup{})
+			} else {
+				groups = append(groups, uniformGroup{name: decl.GroupUniforms.Name, insert: lineExtent(a.text, decl.Span).End})
+			}
+		case decl.UniformDecl != nil && len(groups) > 0:
+			groups[len(groups)-1].insert = lineExtent(a.text, decl.Span).End
+		}
+	}
+
+	result := groups[:0]
+	for _, group := range groups {
+		if group.name != "" {
+			group.insert = ensureLineStart(a.text, group.insert)
+			result = append(result, group)
+		}
+	}
+	return result
+}
+
+// ensureLineStart returns the offset if it is at the start of a line, or
+// the start of the next line otherwise.
+func ensureLineStart(text []byte, offset int) int {
+	if offset == 0 || offset >= len(text) || text[offset-1] == '\n' {
+		return offset
+	}
+	return lineExtent(text, ast.Span{Start: offset, End: offset}).End
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package app_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+)
+
+func TestHandler_CodeAction_PromoteToUniform(t *testing.T) {
+	const document = `shader_type spatial;
+uniform float speed;
+
+group_uniforms surface;
+uniform sampler2D albedo_texture;
+group_uniforms;
+
+const float SCALE = 3.0;
+
+void fragment() {
+	float wave = sin(TIME * 25.0) * -0.4;
+	ALBEDO = vec3(1.0, 0.5, 0.0) * SCALE;
+	ROUGHNESS = 0.7;
+}
+`
+
+	tests := []struct {
+		name  string
+		rng   lsp.Range
+		title string
+		want  string
+	}{
+		{
+			name:  "FloatInRange",
+			rng:   lsp.Range{Start: lsp.Position{Line: 12, Character: 13}, End: lsp.Position{Line: 12, Character: 16}},
+			title: "Promote to uniform",
+			want: `shader_type spatial;
+uniform float speed;
+uniform float roughness : hint_range(0.0, 1.0) = 0.7;
+
+group_uniforms surface;
+uniform sampler2D albedo_texture;
+group_uniforms;
+
+const float SCALE = 3.0;
+
+void fragment() {
+	float wave = sin(TIME * 25.0) * -0.4;
+	ALBEDO = vec3(1.0, 0.5, 0.0) * SCALE;
+	ROUGHNESS = roughness;
+}
+`,
+		},
+		{
+			name:  "FloatOutOfRange",
+			rng:   lsp.Range{Start: lsp.Position{Line: 10, Character: 25}, End: lsp.Position{Line: 10, Character: 29}},
+			title: "Promote to uniform",
+			want: `shader_type spatial;
+uniform float speed;
+uniform float value : hint_range(0.0, 50.0) = 25.0;
+
+group_uniforms surface;
+uniform sampler2D albedo_texture;
+group_uniforms;
+
+const float SCALE = 3.0;
+
+void fragment() {
+	float wave = sin(TIME * value) * -0.4;
+	ALBEDO = vec3(1.0, 0.5, 0.0) * SCALE;
+	ROUGHNESS = 0.7;
+}
+`,
+		},
+		{
+			name:  "NegativeFloatAtCursor",
+			rng:   lsp.Range{Start: lsp.Position{Line: 10, Character: 35}, End: lsp.Position{Line: 10, Character: 35}},
+			title: "Promote to uniform",
+			want: `shader_type spatial;
+uniform float speed;
+uniform float wave2 : hint_range(-1.0, 1.0) = -0.4;
+
+group_uniforms surface;
+uniform sampler2D albedo_texture;
+group_uniforms;
+
+const float SCALE = 3.0;
+
+void fragment() {
+	float wave = sin(TIME * 25.0) * wave2;
+	ALBEDO = vec3(1.0, 0.5, 0.0) * SCALE;
+	ROUGHNESS = 0.7;
+}
+`,
+		},
+		{
+			name:  "ColorInGroup",
+			rng:   lsp.Range{Start: lsp.Position{Line: 11, Character: 20}, End: lsp.Position{Line: 11, Character: 20}},
+			title: "Promote to uniform in group 'surface'",
+			want: `shader_type spatial;
+uniform float speed;
+
+group_uniforms surface;
+uniform sampler2D albedo_texture;
+uniform vec3 albedo : source_color = vec3(1.0, 0.5, 0.0);
+group_uniforms;
+
+const float SCALE = 3.0;
+
+void fragment() {
+	float wave = sin(TIME * 25.0) * -0.4;
+	ALBEDO = albedo * SCALE;
+	ROUGHNESS = 0.7;
+}
+`,
+		},
+		{
+			name:  "Const",
+			rng:   lsp.Range{Start: lsp.Position{Line: 7, Character: 13}, End: lsp.Position{Line: 7, Character: 13}},
+			title: "Promote to uniform",
+			want: `shader_type spatial;
+uniform float speed;
+uniform float SCALE : hint_range(0.0, 10.0) = 3.0;
+
+group_uniforms surface;
+uniform sampler2D albedo_texture;
+group_uniforms;
+
+
+void fragment() {
+	float wave = sin(TIME * 25.0) * -0.4;
+	ALBEDO = vec3(1.0, 0.5, 0.0) * SCALE;
+	ROUGHNESS = 0.7;
+}
+`,
+		},
+		{
+			name:  "NotLiteral",
+			rng:   lsp.Range{Start: lsp.Position{Line: 10, Character: 19}, End: lsp.Position{Line: 10, Character: 23}},
+			title: "Promote to uniform",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			h := openDocument(t, document)
+
+			actions, err := h.CodeAction(t.Context(), lsp.CodeActionParams{
+				TextDocument: lsp.TextDocumentIdentifier{URI: testURI},
+				Range:        tt.rng,
+				Context:      lsp.CodeActionContext{Only: []lsp.CodeActionKind{lsp.CodeActionRefactor}},
+			})
+			g.Expect(err).ToNot(HaveOccurred(), "CodeAction error")
+
+			got := make(map[string]string)
+			for _, action := range actions {
+				got[action.Title] = applyTextEdits(document, action.Edit.Changes[testURI])
+			}
+			if tt.want == "" {
+				g.Expect(got).ToNot(HaveKey(tt.title))
+			} else {
+				g.Expect(got).To(HaveKeyWithValue(tt.title, tt.want))
+			}
+		})
+	}
+}
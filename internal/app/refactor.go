@@ -0,0 +1,454 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package app
+
+import (
+	"bytes"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/armsnyder/gdshader-language-server/internal/ast"
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+)
+
+// refactorAction returns a refactor code action that applies the edits.
+func refactorAction(uri string, doc *lsp.Snapshot, title string, kind lsp.CodeActionKind, edits []ast.TextEdit) lsp.CodeAction {
+	return lsp.CodeAction{
+		Title: title,
+		Kind:  kind,
+		Edit:  &lsp.WorkspaceEdit{Changes: map[string][]lsp.TextEdit{uri: toLSPEdits(doc, edits)}},
+	}
+}
+
+// trimSelection shrinks a selection so that it does not start or end with
+// whitespace.
+func trimSelection(text []byte, start, end int) ast.Span {
+	for start < end && isSpaceByte(text[start]) {
+		start++
+	}
+	for end > start && isSpaceByte(text[end-1]) {
+		end--
+	}
+	return ast.Span{Start: start, End: end}
+}
+
+func isSpaceByte(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// enclosingFunction returns the function whose body contains the span.
+func enclosingFunction(file *ast.File, span ast.Span) *ast.FunctionDecl {
+	for _, decl := range file.Declarations {
+		fn := decl.FunctionDecl
+		if fn != nil && fn.Body != nil && fn.Body.Start < span.Start && span.End < fn.Body.End {
+			return fn
+		}
+	}
+	return nil
+}
+
+// extractVariableEdits replaces the selected expression with a new local
+// variable, which is declared before the statement that contains it.
+func extractVariableEdits(a *analysis, selection ast.Span) ([]ast.TextEdit, bool) {
+	fn := enclosingFunction(a.file, selection)
+	if fn == nil {
+		return nil, false
+	}
+
+	expr := selectedExpr(fn, selection)
+	if expr == nil {
+		return nil, false
+	}
+
+	stmt := enclosingStmt(fn.Body, selection)
+	if stmt == nil || stmt.For != nil || stmt.While != nil || stmt.DoWhile != nil || stmt.Case != nil {
+		// Hoisting an expression out of a loop would change how often it
+		// is evaluated.
+		return nil, false
+	}
+
+	env := a.typeEnv(stmt.Start)
+	typ := env.typeOf(expr)
+	if typ == "" || isArray(typ) || typ == "void" {
+		return nil, false
+	}
+
+	name := uniqueName("value", func(name string) bool { return nameIsUsed(env, fn, name) })
+	lineStart := bytes.LastIndexByte(a.text[:stmt.Start], '\n') + 1
+	indent := a.text[lineStart:stmt.Start]
+	decl := typ + " " + name + " = " + string(a.text[selection.Start:selection.End]) + ";"
+
+	var insert ast.TextEdit
+	if len(bytes.TrimSpace(indent)) == 0 {
+		insert = ast.TextEdit{Span: ast.Span{Start: lineStart, End: lineStart}, NewText: string(indent) + decl + "\n"}
+	} else {
+		insert = ast.TextEdit{Span: ast.Span{Start: stmt.Start, End: stmt.Start}, NewText: decl + " "}
+	}
+
+	return []ast.TextEdit{insert, {Span: selection, NewText: name}}, true
+}
+
+// selectedExpr returns the expression that exactly matches the selection,
+// unless it is a function name or is assigned to.
+func selectedExpr(fn *ast.FunctionDecl, selection ast.Span) ast.Expr {
+	excluded := make(map[ast.Expr]bool)
+	var result ast.Expr
+
+	ast.Inspect(fn.Body, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.CallExpr:
+			if n.Func != nil {
+				excluded[n.Func] = true
+			}
+		case *ast.AssignExpr:
+			excludeTarget(excluded, n.Left)
+		case *ast.UnaryExpr:
+			if n.Op == "++" || n.Op == "--" {
+				excludeTarget(excluded, n.X)
+			}
+		}
+
+		if expr, ok := node.(ast.Expr); ok && result == nil && expr.Extent() == selection && !excluded[expr] {
+			switch expr.(type) {
+			case *ast.BadExpr, *ast.AssignExpr, *ast.InitListExpr:
+			default:
+				result = expr
+			}
+		}
+		return node.Extent().Start <= selection.Start && selection.End <= node.Extent().End
+	})
+
+	return result
+}
+
+func excludeTarget(excluded map[ast.Expr]bool, expr ast.Expr) {
+	for expr != nil {
+		excluded[expr] = true
+		switch x := expr.(type) {
+		case *ast.MemberExpr:
+			expr = x.X
+		case *ast.IndexExpr:
+			expr = x.X
+		case *ast.ParenExpr:
+			expr = x.X
+		default:
+			return
+		}
+	}
+}
+
+// enclosingStmt returns the innermost statement that is directly in a block
+// and contains the span.
+func enclosingStmt(block *ast.BlockStmt, span ast.Span) *ast.Stmt {
+	var result *ast.Stmt
+	ast.Inspect(block, func(node ast.Node) bool {
+		if node.Extent().Start > span.Start || node.Extent().End < span.End {
+			return false
+		}
+		if b, ok := node.(*ast.BlockStmt); ok {
+			for _, stmt := range b.Stmts {
+				if stmt.Start <= span.Start && span.End <= stmt.End {
+					result = stmt
+				}
+			}
+		}
+		return true
+	})
+	return result
+}
+
+// nameIsUsed reports whether a new local variable or function with the name
+// would clash with an existing one.
+func nameIsUsed(env *typeEnv, fn *ast.FunctionDecl, name string) bool {
+	if env.lookup(name) != "" || env.isType(name) {
+		return true
+	}
+	if _, ok := env.functions[name]; ok {
+		return true
+	}
+	if _, ok := builtinFunctions[name]; ok {
+		return true
+	}
+	used := false
+	ast.Inspect(fn, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.Ident:
+			used = used || n.Name == name
+		case *ast.Declarator:
+			used = used || n.Name == name
+		case *ast.Param:
+			used = used || n.Name == name
+		}
+		return !used
+	})
+	return used
+}
+
+func uniqueName(base string, isUsed func(string) bool) string {
+	name := base
+	for i := 2; isUsed(name); i++ {
+		name = base + strconv.Itoa(i)
+	}
+	return name
+}
+
+// extractParam is a parameter of an extracted function.
+type extractParam struct {
+	name      string
+	arg       string
+	typ       string
+	qualifier string
+	read      bool
+	written   bool
+	// replaced is set when the argument is renamed inside the function,
+	// such as the built-in UV becoming the parameter uv.
+	replaced bool
+}
+
+// extractFunctionEdits moves the selected statements into a new function
+// that is declared before the current one, and calls it in their place.
+// Local variables and stage built-ins that the statements use become
+// parameters.
+func extractFunctionEdits(a *analysis, selection ast.Span) ([]ast.TextEdit, bool) {
+	fn := enclosingFunction(a.file, selection)
+	if fn == nil {
+		return nil, false
+	}
+
+	stmts := selectedStmts(fn.Body, selection)
+	if len(stmts) == 0 {
+		return nil, false
+	}
+
+	env := a.typeEnv(selection.Start)
+	globals := globalNames(a.file)
+
+	var params []*extractParam
+	byName := make(map[string]*extractParam)
+	declared := make(map[string]bool)
+	renames := make(map[int]*ast.Ident)
+	callees := make(map[*ast.Ident]bool)
+	writes := make(map[*ast.Ident]bool)
+	partialWrites := make(map[*ast.Ident]bool)
+	ok := true
+
+	for _, stmt := range stmts {
+		ast.Inspect(stmt, func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.Declarator:
+				declared[n.Name] = true
+			case *ast.ReturnStmt:
+				ok = false
+			case *ast.JumpStmt:
+				// Loops inside the selection are fine, but not jumps out of
+				// it.
+				ok = ok && n.Keyword != "discard" && jumpIsInsideLoop(stmts, n)
+			case *ast.CallExpr:
+				if callee := n.Callee(); callee != nil {
+					callees[callee] = true
+				}
+			case *ast.AssignExpr:
+				target := baseIdent(n.Left)
+				writes[target] = true
+				if _, whole := n.Left.(*ast.Ident); !whole || n.Op != "=" {
+					partialWrites[target] = true
+				}
+			case *ast.UnaryExpr:
+				if n.Op == "++" || n.Op == "--" {
+					writes[baseIdent(n.X)] = true
+					partialWrites[baseIdent(n.X)] = true
+				}
+			case *ast.Ident:
+				if callees[n] || declared[n.Name] || globals[n.Name] {
+					return true
+				}
+				p := byName[n.Name]
+				if p == nil {
+					p = newExtractParam(env, n.Name)
+					if p == nil {
+						// Unknown names can't be passed along.
+						ok = false
+						return false
+					}
+					byName[n.Name] = p
+					params = append(params, p)
+				}
+				if p.replaced {
+					renames[n.Start] = n
+				}
+				p.written = p.written || writes[n]
+				p.read = p.read || !writes[n] || partialWrites[n]
+			}
+			return ok
+		})
+	}
+	if !ok {
+		return nil, false
+	}
+
+	after := ast.Span{Start: selection.End, End: fn.Body.End}
+	usedAfter := func(name string) bool {
+		used := false
+		ast.Inspect(fn.Body, func(node ast.Node) bool {
+			if ident, ok := node.(*ast.Ident); ok && ident.Start >= after.Start && ident.Name == name {
+				used = true
+			}
+			return !used
+		})
+		return used
+	}
+
+	for name := range declared {
+		if usedAfter(name) {
+			// The declaration would move out of scope.
+			return nil, false
+		}
+	}
+
+	taken := func(name string) bool { return nameIsUsed(env, fn, name) || byName[name] != nil }
+	var paramDecls, args []string
+	for _, p := range params {
+		if p.typ == "" || isArray(p.typ) {
+			return nil, false
+		}
+		if p.replaced {
+			p.name = uniqueName(strings.ToLower(p.arg), taken)
+		}
+		switch {
+		case p.written && p.read && (usedAfter(p.arg) || p.replaced):
+			p.qualifier = "inout "
+		case p.written && (usedAfter(p.arg) || p.replaced):
+			p.qualifier = "out "
+		}
+		paramDecls = append(paramDecls, p.qualifier+p.typ+" "+p.name)
+		args = append(args, p.arg)
+	}
+
+	// Rename built-ins to their parameters.
+	stmtsSpan := ast.Span{Start: stmts[0].Start, End: stmts[len(stmts)-1].End}
+	lineStart := bytes.LastIndexByte(a.text[:stmtsSpan.Start], '\n') + 1
+	var body strings.Builder
+	last := lineStart
+	for _, offset := range slices.Sorted(maps.Keys(renames)) {
+		ident := renames[offset]
+		body.Write(a.text[last:ident.Start])
+		body.WriteString(byName[ident.Name].name)
+		last = ident.End
+	}
+	body.Write(a.text[last:stmtsSpan.End])
+
+	name := uniqueName("extracted", taken)
+	function := "void " + name + "(" + strings.Join(paramDecls, ", ") + ") {\n" + reindent(body.String(), "\t") + "\n}\n\n"
+	call := name + "(" + strings.Join(args, ", ") + ");"
+
+	fnLineStart := bytes.LastIndexByte(a.text[:fn.Start], '\n') + 1
+	return []ast.TextEdit{
+		{Span: ast.Span{Start: fnLineStart, End: fnLineStart}, NewText: function},
+		{Span: stmtsSpan, NewText: call},
+	}, true
+}
+
+// newExtractParam returns the parameter for a local variable or stage
+// built-in, or nil if the name is neither.
+func newExtractParam(env *typeEnv, name string) *extractParam {
+	if typ, ok := env.vars[name]; ok {
+		return &extractParam{name: name, arg: name, typ: typ}
+	}
+	if typ := builtinVariableType(env.context, name); typ != "" {
+		return &extractParam{arg: name, typ: typ, replaced: true}
+	}
+	return nil
+}
+
+// selectedStmts returns the statements of a block that the selection covers
+// exactly.
+func selectedStmts(body *ast.BlockStmt, selection ast.Span) []*ast.Stmt {
+	var result []*ast.Stmt
+	ast.Inspect(body, func(node ast.Node) bool {
+		if result != nil || node.Extent().Start > selection.Start || node.Extent().End < selection.End {
+			return false
+		}
+		block, ok := node.(*ast.BlockStmt)
+		if !ok {
+			return true
+		}
+		first := slices.IndexFunc(block.Stmts, func(s *ast.Stmt) bool { return s.Start == selection.Start })
+		last := slices.IndexFunc(block.Stmts, func(s *ast.Stmt) bool { return s.End == selection.End })
+		if first >= 0 && last >= first {
+			result = block.Stmts[first : last+1]
+		}
+		return true
+	})
+	if slices.ContainsFunc(result, func(s *ast.Stmt) bool { return s.Case != nil }) {
+		return nil
+	}
+	return result
+}
+
+// jumpIsInsideLoop reports whether a break or continue statement belongs to
+// a loop or switch within the statements.
+func jumpIsInsideLoop(stmts []*ast.Stmt, jump *ast.JumpStmt) bool {
+	inside := false
+	for _, stmt := range stmts {
+		ast.Inspect(stmt, func(node ast.Node) bool {
+			if s, ok := node.(*ast.Stmt); ok && (s.For != nil || s.While != nil || s.DoWhile != nil || (s.Switch != nil && jump.Keyword == "break")) {
+				inside = inside || (s.Start < jump.Start && jump.End <= s.End)
+			}
+			return !inside
+		})
+	}
+	return inside
+}
+
+// globalNames returns the names of uniforms, varyings and constants, which
+// every function can use.
+func globalNames(file *ast.File) map[string]bool {
+	names := make(map[string]bool)
+	for name := range builtinConstants {
+		names[name] = true
+	}
+	for _, decl := range file.Declarations {
+		switch {
+		case decl.UniformDecl != nil:
+			names[decl.UniformDecl.Name] = true
+		case decl.VaryingDecl != nil:
+			names[decl.VaryingDecl.Name] = true
+		case decl.ConstDecl != nil:
+			for _, v := range decl.ConstDecl.Vars {
+				names[v.Name] = true
+			}
+		}
+	}
+	return names
+}
+
+// reindent replaces the common indentation of the lines in text with
+// indent.
+func reindent(text, indent string) string {
+	lines := strings.Split(text, "\n")
+	var common *string
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lead := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if common == nil {
+			common = &lead
+			continue
+		}
+		n := 0
+		for n < len(lead) && n < len(*common) && lead[n] == (*common)[n] {
+			n++
+		}
+		*common = (*common)[:n]
+	}
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[i] = indent + strings.TrimPrefix(line, *common)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package app_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+)
+
+func TestHandler_CodeAction_Extract(t *testing.T) {
+	const document = `shader_type spatial;
+uniform vec3 tint;
+
+void fragment() {
+	float k = 2.0;
+	vec3 color = tint * k;
+	color.r += UV.x * 0.5;
+	ALBEDO = color * mix(0.2, 0.8, UV.y);
+	for (int i = 0; i < 2; i++) {
+		k *= 0.5;
+	}
+	ALPHA = k;
+}
+`
+
+	tests := []struct {
+		name  string
+		rng   lsp.Range
+		title string
+		want  string
+	}{
+		{
+			name:  "Variable",
+			rng:   lsp.Range{Start: lsp.Position{Line: 7, Character: 18}, End: lsp.Position{Line: 7, Character: 37}},
+			title: "Extract to local variable",
+			want: `shader_type spatial;
+uniform vec3 tint;
+
+void fragment() {
+	float k = 2.0;
+	vec3 color = tint * k;
+	color.r += UV.x * 0.5;
+	float value = mix(0.2, 0.8, UV.y);
+	ALBEDO = color * value;
+	for (int i = 0; i < 2; i++) {
+		k *= 0.5;
+	}
+	ALPHA = k;
+}
+`,
+		},
+		{
+			name:  "VariableSwizzle",
+			rng:   lsp.Range{Start: lsp.Position{Line: 6, Character: 12}, End: lsp.Position{Line: 6, Character: 16}},
+			title: "Extract to local variable",
+			want: `shader_type spatial;
+uniform vec3 tint;
+
+void fragment() {
+	float k = 2.0;
+	vec3 color = tint * k;
+	float value = UV.x;
+	color.r += value * 0.5;
+	ALBEDO = color * mix(0.2, 0.8, UV.y);
+	for (int i = 0; i < 2; i++) {
+		k *= 0.5;
+	}
+	ALPHA = k;
+}
+`,
+		},
+		{
+			name:  "Function",
+			rng:   lsp.Range{Start: lsp.Position{Line: 6}, End: lsp.Position{Line: 8}},
+			title: "Extract to function",
+			want: `shader_type spatial;
+uniform vec3 tint;
+
+void extracted(vec3 color, vec2 uv, out vec3 albedo) {
+	color.r += uv.x * 0.5;
+	albedo = color * mix(0.2, 0.8, uv.y);
+}
+
+void fragment() {
+	float k = 2.0;
+	vec3 color = tint * k;
+	extracted(color, UV, ALBEDO);
+	for (int i = 0; i < 2; i++) {
+		k *= 0.5;
+	}
+	ALPHA = k;
+}
+`,
+		},
+		{
+			name:  "FunctionDeclaresVariableUsedAfter",
+			rng:   lsp.Range{Start: lsp.Position{Line: 5, Character: 1}, End: lsp.Position{Line: 6, Character: 23}},
+			title: "Extract to function",
+		},
+		{
+			name:  "FunctionWithLoop",
+			rng:   lsp.Range{Start: lsp.Position{Line: 8}, End: lsp.Position{Line: 11}},
+			title: "Extract to function",
+			want: `shader_type spatial;
+uniform vec3 tint;
+
+void extracted(inout float k) {
+	for (int i = 0; i < 2; i++) {
+		k *= 0.5;
+	}
+}
+
+void fragment() {
+	float k = 2.0;
+	vec3 color = tint * k;
+	color.r += UV.x * 0.5;
+	ALBEDO = color * mix(0.2, 0.8, UV.y);
+	extracted(k);
+	ALPHA = k;
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			h := openDocument(t, document)
+
+			actions, err := h.CodeAction(t.Context(), lsp.CodeActionParams{
+				TextDocument: lsp.TextDocumentIdentifier{URI: testURI},
+				Range:        tt.rng,
+				Context:      lsp.CodeActionContext{Only: []lsp.CodeActionKind{lsp.CodeActionRefactor}},
+			})
+			g.Expect(err).ToNot(HaveOccurred(), "CodeAction error")
+
+			got := make(map[string]string)
+			for _, action := range actions {
+				g.Expect(action.Kind).To(Equal(lsp.CodeActionRefactorExtract))
+				got[action.Title] = applyTextEdits(document, action.Edit.Changes[testURI])
+			}
+			if tt.want == "" {
+				g.Expect(got).ToNot(HaveKey(tt.title))
+			} else {
+				g.Expect(got).To(HaveKeyWithValue(tt.title, tt.want))
+			}
+		})
+	}
+}
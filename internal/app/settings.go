@@ -0,0 +1,72 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+)
+
+// Settings are the user-configurable options of the language server. Clients
+// send them under the "gdshader" section of workspace/didChangeConfiguration.
+type Settings struct {
+	InlayHints InlayHintSettings `json:"inlayHints"`
+}
+
+// InlayHintSettings enables or disables each category of inlay hints.
+type InlayHintSettings struct {
+	// ParameterNames shows parameter names at call sites of built-in
+	// functions with more than one parameter.
+	ParameterNames bool `json:"parameterNames"`
+	// ConstantValues shows the evaluated value of const expressions.
+	ConstantValues bool `json:"constantValues"`
+	// SwizzleTypes shows the type of complex expressions assigned to
+	// swizzles.
+	SwizzleTypes bool `json:"swizzleTypes"`
+}
+
+// DefaultSettings returns the settings used until the client sends its own.
+func DefaultSettings() Settings {
+	return Settings{
+		InlayHints: InlayHintSettings{
+			ParameterNames: true,
+			ConstantValues: true,
+			SwizzleTypes:   true,
+		},
+	}
+}
+
+// DidChangeConfiguration implements lsp.DidChangeConfigurationHandler.
+func (h *Handler) DidChangeConfiguration(_ context.Context, params lsp.DidChangeConfigurationParams) error {
+	var wrapper struct {
+		GDShader json.RawMessage `json:"gdshader"`
+	}
+	if len(params.Settings) > 0 {
+		if err := json.Unmarshal(params.Settings, &wrapper); err != nil {
+			return fmt.Errorf("parse settings: %w", err)
+		}
+	}
+
+	// Missing fields keep their default values.
+	settings := DefaultSettings()
+	if len(wrapper.GDShader) > 0 {
+		if err := json.Unmarshal(wrapper.GDShader, &settings); err != nil {
+			return fmt.Errorf("parse gdshader settings: %w", err)
+		}
+	}
+
+	h.settings.Store(&settings)
+	return nil
+}
+
+func (h *Handler) getSettings() Settings {
+	settings := h.settings.Load()
+	if settings == nil {
+		return DefaultSettings()
+	}
+	return *settings
+}
@@ -0,0 +1,383 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package app
+
+import (
+	"maps"
+	"strconv"
+	"strings"
+
+	"github.com/armsnyder/gdshader-language-server/internal/ast"
+)
+
+// Types are represented by their name in the shader language, such as
+// "vec3". Arrays have a "[]" suffix. An empty string is an unknown type.
+
+func componentCount(typ string) int {
+	switch typ {
+	case "float", "int", "uint", "bool":
+		return 1
+	}
+	for _, prefix := range []string{"vec", "ivec", "uvec", "bvec", "mat"} {
+		if n, err := strconv.Atoi(strings.TrimPrefix(typ, prefix)); err == nil && strings.HasPrefix(typ, prefix) && n >= 2 && n <= 4 {
+			return n
+		}
+	}
+	return 0
+}
+
+func scalarType(typ string) string {
+	if componentCount(typ) == 0 {
+		return ""
+	}
+	switch typ[0] {
+	case 'v', 'm', 'f':
+		return "float"
+	case 'i':
+		return "int"
+	case 'u':
+		return "uint"
+	case 'b':
+		return "bool"
+	}
+	return ""
+}
+
+func isMatrix(typ string) bool {
+	return strings.HasPrefix(typ, "mat") && componentCount(typ) > 0
+}
+
+func isArray(typ string) bool {
+	return strings.HasSuffix(typ, "[]")
+}
+
+// vectorType returns the vector type with the given scalar type and number
+// of components. One component yields the scalar type itself.
+func vectorType(scalar string, size int) string {
+	if size <= 1 {
+		return scalar
+	}
+	prefix := map[string]string{"float": "vec", "int": "ivec", "uint": "uvec", "bool": "bvec"}[scalar]
+	if prefix == "" {
+		return ""
+	}
+	return prefix + strconv.Itoa(size)
+}
+
+// swizzleType returns the type produced by applying a swizzle to a vector,
+// or an empty string if the swizzle is invalid.
+func swizzleType(typ, swizzle string) string {
+	size := componentCount(typ)
+	if size < 2 || isMatrix(typ) || len(swizzle) > 4 {
+		return ""
+	}
+	for _, set := range []string{"xyzw", "rgba", "stpq"} {
+		valid := true
+		for _, c := range swizzle {
+			if i := strings.IndexRune(set, c); i < 0 || i >= size {
+				valid = false
+				break
+			}
+		}
+		if valid {
+			return vectorType(scalarType(typ), len(swizzle))
+		}
+	}
+	return ""
+}
+
+// typeEnv resolves the types of expressions at a location in a file.
+type typeEnv struct {
+	context   completionContext
+	vars      map[string]string
+	consts    map[string]ast.Expr
+	functions map[string]string
+	structs   map[string]map[string]string
+	// symbols are where the visible variables, functions and structs are
+	// declared.
+	symbols map[string]symbol
+	// filename is the file that the locals are declared in.
+	filename string
+}
+
+// symbol is the name of a declaration in a file.
+type symbol struct {
+	filename string
+	span     ast.Span
+}
+
+// newTypeEnv creates a typeEnv with every symbol that is visible at the
+// given offset. The symbols of included files are visible too.
+func newTypeEnv(file *ast.File, offset int, included ...*ast.File) *typeEnv {
+	env := &typeEnv{
+		vars:      make(map[string]string),
+		consts:    make(map[string]ast.Expr),
+		functions: make(map[string]string),
+		structs:   make(map[string]map[string]string),
+		symbols:   make(map[string]symbol),
+		filename:  file.Filename,
+	}
+
+	for _, f := range included {
+		env.addGlobals(f, f.End)
+	}
+	function := env.addGlobals(file, offset)
+
+	if function != nil {
+		env.context.functionName = function.Name
+		for _, param := range function.Params {
+			env.vars[param.Name] = arrayOf(param.Type, param.ArraySize)
+			env.symbols[param.Name] = symbol{file.Filename, param.NameSpan}
+		}
+		if function.Body != nil {
+			env.addLocals(function.Body, offset)
+		}
+	}
+
+	return env
+}
+
+// addGlobals adds the top-level declarations of a file, and returns the
+// function that contains the offset, if any.
+func (e *typeEnv) addGlobals(file *ast.File, offset int) *ast.FunctionDecl {
+	var function *ast.FunctionDecl
+
+	for _, decl := range file.Declarations {
+		switch {
+		case decl.ShaderType != nil:
+			e.context.shaderType = decl.ShaderType.Name
+		case decl.UniformDecl != nil:
+			e.vars[decl.UniformDecl.Name] = arrayOf(decl.UniformDecl.Type, decl.UniformDecl.ArraySize)
+			e.symbols[decl.UniformDecl.Name] = symbol{file.Filename, decl.UniformDecl.NameSpan}
+		case decl.VaryingDecl != nil:
+			e.vars[decl.VaryingDecl.Name] = arrayOf(decl.VaryingDecl.Type, decl.VaryingDecl.ArraySize)
+			e.symbols[decl.VaryingDecl.Name] = symbol{file.Filename, decl.VaryingDecl.NameSpan}
+		case decl.ConstDecl != nil:
+			e.addVarDecl(file.Filename, decl.ConstDecl, offset)
+		case decl.StructDecl != nil:
+			fields := make(map[string]string)
+			for _, field := range decl.StructDecl.Fields {
+				for _, v := range field.Vars {
+					fields[v.Name] = arrayOf(field.Type, v.ArraySize)
+				}
+			}
+			e.structs[decl.StructDecl.Name] = fields
+			e.symbols[decl.StructDecl.Name] = symbol{file.Filename, decl.StructDecl.NameSpan}
+		case decl.FunctionDecl != nil:
+			e.functions[decl.FunctionDecl.Name] = decl.FunctionDecl.ReturnType
+			e.symbols[decl.FunctionDecl.Name] = symbol{file.Filename, decl.FunctionDecl.NameSpan}
+			if decl.FunctionDecl.Contains(offset) {
+				function = decl.FunctionDecl
+			}
+		}
+	}
+
+	return function
+}
+
+func arrayOf(typ string, arraySize ast.Expr) string {
+	if arraySize != nil {
+		return typ + "[]"
+	}
+	return typ
+}
+
+func (e *typeEnv) addVarDecl(filename string, decl *ast.VarDecl, offset int) {
+	for _, v := range decl.Vars {
+		if v.NameSpan.End > offset {
+			return
+		}
+		e.vars[v.Name] = arrayOf(decl.Type, v.ArraySize)
+		e.symbols[v.Name] = symbol{filename, v.NameSpan}
+		if decl.Const && v.Init != nil {
+			e.consts[v.Name] = v.Init
+		} else {
+			delete(e.consts, v.Name)
+		}
+	}
+}
+
+func (e *typeEnv) addLocals(block *ast.BlockStmt, offset int) {
+	for _, stmt := range block.Stmts {
+		if stmt.Start >= offset {
+			return
+		}
+		e.addLocalsFromStmt(stmt, offset)
+	}
+}
+
+func (e *typeEnv) addLocalsFromStmt(stmt *ast.Stmt, offset int) {
+	switch {
+	case stmt == nil:
+	case stmt.VarDecl != nil:
+		e.addVarDecl(e.filename, stmt.VarDecl, offset)
+	case !stmt.Contains(offset):
+	case stmt.Block != nil:
+		e.addLocals(stmt.Block, offset)
+	case stmt.If != nil:
+		e.addLocalsFromStmt(stmt.If.Then, offset)
+		e.addLocalsFromStmt(stmt.If.Else, offset)
+	case stmt.For != nil:
+		e.addLocalsFromStmt(stmt.For.Init, offset)
+		e.addLocalsFromStmt(stmt.For.Body, offset)
+	case stmt.While != nil:
+		e.addLocalsFromStmt(stmt.While.Body, offset)
+	case stmt.DoWhile != nil:
+		e.addLocalsFromStmt(stmt.DoWhile.Body, offset)
+	case stmt.Switch != nil && stmt.Switch.Body != nil:
+		e.addLocals(stmt.Switch.Body, offset)
+	}
+}
+
+// inspectWithEnv traverses a node like ast.Inspect, and calls f with the
+// typeEnv at each node. It starts from env, the typeEnv at the start of the
+// node, and adds local declarations to it as the traversal passes them and
+// removes them when it leaves their block, so that the typeEnv is not built
+// again for each node. f must not keep the typeEnv.
+func inspectWithEnv(env *typeEnv, node ast.Node, f func(ast.Node, *typeEnv) bool) {
+	type scope struct {
+		end     int
+		vars    map[string]string
+		consts  map[string]ast.Expr
+		symbols map[string]symbol
+	}
+	var scopes []scope
+	// decl is the declaration of the variables that are visited next. The
+	// fields of structs are not variables.
+	var decl *ast.VarDecl
+
+	ast.Inspect(node, func(node ast.Node) bool {
+		for len(scopes) > 0 && scopes[len(scopes)-1].end <= node.Extent().Start {
+			s := scopes[len(scopes)-1]
+			env.vars, env.consts, env.symbols = s.vars, s.consts, s.symbols
+			scopes = scopes[:len(scopes)-1]
+		}
+
+		switch n := node.(type) {
+		case *ast.Declaration:
+			decl = n.ConstDecl
+		case *ast.Stmt:
+			decl = n.VarDecl
+		case *ast.BlockStmt, *ast.ForStmt:
+			scopes = append(scopes, scope{
+				end:     n.Extent().End,
+				vars:    maps.Clone(env.vars),
+				consts:  maps.Clone(env.consts),
+				symbols: maps.Clone(env.symbols),
+			})
+		}
+
+		descend := f(node, env)
+		if d, ok := node.(*ast.Declarator); ok && decl != nil {
+			env.addVarDecl(env.filename, decl, d.NameSpan.End)
+		}
+		return descend
+	})
+}
+
+// lookup returns the type of a variable, including built-ins.
+func (e *typeEnv) lookup(name string) string {
+	if typ, ok := e.vars[name]; ok {
+		return typ
+	}
+	return builtinVariableType(e.context, name)
+}
+
+func (e *typeEnv) isType(name string) bool {
+	_, isStruct := e.structs[name]
+	_, isBuiltin := dataTypes[name]
+	return isStruct || isBuiltin
+}
+
+// typeOf returns the type of an expression.
+func (e *typeEnv) typeOf(expr ast.Expr) string {
+	switch x := expr.(type) {
+	case *ast.Literal:
+		return map[ast.LiteralKind]string{ast.LiteralInt: "int", ast.LiteralUint: "uint", ast.LiteralFloat: "float", ast.LiteralBool: "bool"}[x.Kind]
+	case *ast.Ident:
+		return e.lookup(x.Name)
+	case *ast.ParenExpr:
+		return e.typeOf(x.X)
+	case *ast.UnaryExpr:
+		if x.Op == "!" {
+			return "bool"
+		}
+		return e.typeOf(x.X)
+	case *ast.BinaryExpr:
+		return e.binaryType(x)
+	case *ast.AssignExpr:
+		return e.typeOf(x.Left)
+	case *ast.TernaryExpr:
+		if typ := e.typeOf(x.Then); typ != "" {
+			return typ
+		}
+		return e.typeOf(x.Else)
+	case *ast.CallExpr:
+		return e.callType(x)
+	case *ast.MemberExpr:
+		typ := e.typeOf(x.X)
+		if fields, ok := e.structs[typ]; ok {
+			return fields[x.Name]
+		}
+		return swizzleType(typ, x.Name)
+	case *ast.IndexExpr:
+		typ := e.typeOf(x.X)
+		switch {
+		case isArray(typ):
+			return strings.TrimSuffix(typ, "[]")
+		case isMatrix(typ):
+			return vectorType("float", componentCount(typ))
+		case componentCount(typ) > 1:
+			return scalarType(typ)
+		}
+	}
+	return ""
+}
+
+func (e *typeEnv) binaryType(x *ast.BinaryExpr) string {
+	switch x.Op {
+	case "==", "!=", "<", ">", "<=", ">=", "&&", "||", "^^":
+		return "bool"
+	}
+
+	left, right := e.typeOf(x.X), e.typeOf(x.Y)
+	switch {
+	case left == "" || right == "":
+		return ""
+	case x.Op == "*" && isMatrix(left) && !isMatrix(right) && componentCount(right) > 1:
+		// Matrix times column vector
+		return right
+	case x.Op == "*" && !isMatrix(left) && isMatrix(right) && componentCount(left) > 1:
+		// Row vector times matrix
+		return left
+	case componentCount(right) > componentCount(left):
+		return right
+	}
+	return left
+}
+
+func (e *typeEnv) callType(x *ast.CallExpr) string {
+	callee := x.Callee()
+	if callee == nil {
+		// Arrays have a length() method.
+		if member, ok := x.Func.(*ast.MemberExpr); ok && member.Name == "length" && isArray(e.typeOf(member.X)) {
+			return "int"
+		}
+		return ""
+	}
+	if e.isType(callee.Name) {
+		return callee.Name
+	}
+	if typ, ok := e.functions[callee.Name]; ok {
+		return typ
+	}
+	argTypes := make([]string, len(x.Args))
+	for i, arg := range x.Args {
+		argTypes[i] = e.typeOf(arg)
+	}
+	if fn, ok := builtinOverload(callee.Name, argTypes); ok {
+		return fn.resolveReturnType(argTypes)
+	}
+	return ""
+}
@@ -0,0 +1,299 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/armsnyder/gdshader-language-server/internal/godot"
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+)
+
+// workspace is the set of shader files on disk in the folders that the
+// client opened. They are checked even when they aren't open in the editor.
+type workspace struct {
+	mu sync.Mutex
+	// roots are the paths of the workspace folders.
+	roots []string
+	// files are the URIs of the shader files that were found in the roots.
+	files map[string]bool
+}
+
+// setRoots sets the workspace folders from the initialize params. Folders
+// that are not on disk are ignored.
+func (w *workspace) setRoots(params lsp.InitializeParams) {
+	uris := make([]string, 0, len(params.WorkspaceFolders))
+	for _, folder := range params.WorkspaceFolders {
+		uris = append(uris, folder.URI)
+	}
+	if len(uris) == 0 && params.RootURI != "" {
+		uris = append(uris, params.RootURI)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.roots = nil
+	for _, uri := range uris {
+		if path, err := lsp.URIToPath(uri); err == nil {
+			w.roots = append(w.roots, path)
+		}
+	}
+}
+
+func (w *workspace) getRoots() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return slices.Clone(w.roots)
+}
+
+// rootOf returns the workspace folder that contains a path.
+func (w *workspace) rootOf(path string) (string, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, root := range w.roots {
+		if rel, err := filepath.Rel(root, path); err == nil && filepath.IsLocal(rel) {
+			return root, true
+		}
+	}
+	return "", false
+}
+
+func (w *workspace) setFiles(uris []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.files = make(map[string]bool, len(uris))
+	for _, uri := range uris {
+		w.files[uri] = true
+	}
+}
+
+func (w *workspace) contains(uri string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.files[uri]
+}
+
+// isShaderFile reports whether a path is a shader or shader include file.
+func isShaderFile(path string) bool {
+	ext := filepath.Ext(path)
+	return ext == ".gdshader" || ext == ".gdshaderinc"
+}
+
+// indexing is the indexing of the workspace in the background, which is
+// stopped when the server shuts down.
+type indexing struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// start runs the indexing in the background.
+func (i *indexing) start(ctx context.Context, index func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	i.mu.Lock()
+	i.cancel, i.done = cancel, done
+	i.mu.Unlock()
+	go func() {
+		defer close(done)
+		defer cancel()
+		index(ctx)
+	}()
+}
+
+// stop cancels the indexing, and waits for it to finish.
+func (i *indexing) stop() {
+	i.mu.Lock()
+	cancel, done := i.cancel, i.done
+	i.mu.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// Initialized implements lsp.InitializedHandler. The workspace is indexed in
+// the background, so that the client can make requests in the meantime.
+func (h *Handler) Initialized(ctx context.Context) error {
+	if h.Client == nil || len(h.workspace.getRoots()) == 0 {
+		return nil
+	}
+	h.indexing.start(ctx, h.indexWorkspace)
+	return nil
+}
+
+// indexWorkspace finds the shader files of the workspace, their includes and
+// the materials that use them, and publishes the diagnostics of the shaders
+// that are not open and of the materials. Its progress is shown in the
+// client.
+func (h *Handler) indexWorkspace(ctx context.Context) {
+	progressSupported := h.supports((*lsp.ClientCapabilities).WorkDoneProgress)
+
+	progress := lsp.StartProgress(ctx, h.Client, progressSupported, "Indexing shaders")
+	var uris, resources []string
+	roots := h.workspace.getRoots()
+	for i, root := range roots {
+		progress.Report(ctx, filepath.Base(root), i*100/len(roots))
+		shaders, found, err := h.findFiles(ctx, root)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			h.showMessage(ctx, lsp.MessageWarning, fmt.Sprintf("Failed to index shaders in %s: %v", root, err))
+		}
+		uris = append(uris, shaders...)
+		resources = append(resources, found...)
+	}
+	h.workspace.setFiles(uris)
+	for _, uri := range uris {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := h.indexIncludes(ctx, uri); err != nil {
+			h.logMessage(ctx, lsp.MessageWarning, fmt.Sprintf("Failed to read %s: %v", uri, err))
+		}
+	}
+	var materialFiles []string
+	for _, path := range resources {
+		if ctx.Err() != nil {
+			return
+		}
+		r, err := h.loadResource(ctx, path)
+		if err != nil {
+			h.logMessage(ctx, lsp.MessageWarning, fmt.Sprintf("Failed to read %s: %v", path, err))
+			continue
+		}
+		if len(r.resource.Materials) > 0 {
+			materialFiles = append(materialFiles, path)
+		}
+	}
+	progress.End(ctx, fmt.Sprintf("Found %d shaders", len(uris)))
+	h.logMessage(ctx, lsp.MessageInfo, fmt.Sprintf("Indexed %d shaders in %s", len(uris), strings.Join(roots, ", ")))
+
+	// Clients that pull diagnostics ask for the ones they need.
+	if h.supports((*lsp.ClientCapabilities).PullDiagnostics) {
+		return
+	}
+
+	progress = lsp.StartProgress(ctx, h.Client, progressSupported, "Checking shaders")
+	for i, uri := range uris {
+		if ctx.Err() != nil {
+			return
+		}
+		progress.Report(ctx, fmt.Sprintf("%d/%d", i+1, len(uris)), i*100/len(uris))
+		if _, err := h.Snapshot(uri); err == nil {
+			// Open documents publish their own diagnostics.
+			continue
+		}
+		a, err := h.publishFileDiagnostics(ctx, uri)
+		if err != nil {
+			if ctx.Err() == nil {
+				h.logMessage(ctx, lsp.MessageWarning, fmt.Sprintf("Failed to check %s: %v", uri, err))
+			}
+			continue
+		}
+		for _, inc := range a.includes {
+			if inc.err != nil && inc.err.code == "unresolved-include" {
+				h.logMessage(ctx, lsp.MessageWarning, fmt.Sprintf("Can't resolve #include \"%s\" in %s: %s", inc.path, uri, inc.err.message))
+			}
+		}
+	}
+	for _, path := range materialFiles {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := h.publishResourceDiagnostics(ctx, path); err != nil && ctx.Err() == nil {
+			h.logMessage(ctx, lsp.MessageWarning, fmt.Sprintf("Failed to check %s: %v", path, err))
+		}
+	}
+	progress.End(ctx, fmt.Sprintf("Checked %d shaders", len(uris)))
+}
+
+// findFiles returns the URIs of the shader files in a directory and the
+// paths of its resource files, and loads the Godot projects that it finds
+// along the way. Hidden directories, such as .godot and .git, are skipped.
+// Files that can't be read are logged and skipped.
+func (h *Handler) findFiles(ctx context.Context, root string) ([]string, []string, error) {
+	var uris, resources []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			if path == root {
+				return err
+			}
+			h.logMessage(ctx, lsp.MessageWarning, fmt.Sprintf("Failed to read %s: %v", path, err))
+			return nil
+		}
+		if d.IsDir() && path != root && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && isShaderFile(path) {
+			uris = append(uris, lsp.PathToURI(path))
+		}
+		if !d.IsDir() && godot.IsResourceFile(path) {
+			resources = append(resources, path)
+		}
+		if !d.IsDir() && d.Name() == godot.ProjectFile {
+			h.loadProject(ctx, filepath.Dir(path))
+		}
+		return nil
+	})
+	return uris, resources, err
+}
+
+// publishFileDiagnostics publishes the diagnostics of a shader file on
+// disk, which is not open in the client, and returns its analysis.
+func (h *Handler) publishFileDiagnostics(ctx context.Context, uri string) (*analysis, error) {
+	path, err := lsp.URIToPath(uri)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := h.readSnapshot(path)
+	if err != nil {
+		return nil, err
+	}
+
+	a, diagnostics, err := h.analyzeSnapshot(ctx, uri, doc)
+	if err != nil {
+		return nil, err
+	}
+
+	params := lsp.PublishDiagnosticsParams{URI: uri, Diagnostics: []lsp.Diagnostic{}}
+	for _, d := range diagnostics {
+		params.Diagnostics = append(params.Diagnostics, d.toLSP(a.doc))
+	}
+	return a, h.Client.Notify(ctx, "textDocument/publishDiagnostics", params)
+}
+
+// logMessage logs a message in the client, and in the server log if there
+// is no client or it fails to.
+func (h *Handler) logMessage(ctx context.Context, typ lsp.MessageType, message string) {
+	if h.Client == nil {
+		slog.Info(message)
+		return
+	}
+	if err := lsp.LogMessage(ctx, h.Client, typ, message); err != nil {
+		slog.Warn(message, "error", err)
+	}
+}
+
+// showMessage shows a message to the user, for events that they must act on.
+func (h *Handler) showMessage(ctx context.Context, typ lsp.MessageType, message string) {
+	slog.Warn(message)
+	if h.Client == nil {
+		return
+	}
+	if err := lsp.ShowMessage(ctx, h.Client, typ, message); err != nil {
+		slog.Warn("Failed to show message", "error", err)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package app_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+	"github.com/armsnyder/gdshader-language-server/internal/lsptest"
+	"github.com/samber/lo"
+)
+
+// writeFiles writes files to a new directory, and returns its path.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		lo.Must0(os.MkdirAll(filepath.Dir(path), 0o755))
+		lo.Must0(os.WriteFile(path, []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestHandler_IndexWorkspace(t *testing.T) {
+	g := NewWithT(t)
+	const broken = "shader_type spatial;\nvoid fragment() {\n\tALBEDO = texure(TEXTURE, UV).rgb;\n}\n"
+	dir := writeFiles(t, map[string]string{
+		"water.gdshader":        broken,
+		"lib/noise.gdshaderinc": "float noise(vec2 p) {\n\treturn 0.0;\n}\n",
+		".godot/cache.gdshader": broken,
+		"lib/readme.txt":        "Not a shader",
+	})
+	waterURI := lsp.PathToURI(filepath.Join(dir, "water.gdshader"))
+	noiseURI := lsp.PathToURI(filepath.Join(dir, "lib", "noise.gdshaderinc"))
+
+	var created []string
+	c := startSession(t, lsptest.Options{
+		Capabilities:     lsp.ClientCapabilities{Window: &lsp.WindowClientCapabilities{WorkDoneProgress: true}},
+		WorkspaceFolders: []lsp.WorkspaceFolder{{URI: lsp.PathToURI(dir), Name: "project"}},
+		HandleRequest: func(method string, params json.RawMessage) (any, error) {
+			created = append(created, method)
+			return nil, nil
+		},
+	})
+
+	// Indexing: begin, report, end. Checking: begin, a report per file, end.
+	var kinds []string
+	for _, params := range c.WaitNotifications("$/progress", 7) {
+		var progress struct {
+			Value struct {
+				Kind string `json:"kind"`
+			} `json:"value"`
+		}
+		lo.Must0(json.Unmarshal(params, &progress))
+		kinds = append(kinds, progress.Value.Kind)
+	}
+	g.Expect(kinds).To(Equal([]string{"begin", "report", "end", "begin", "report", "report", "end"}))
+	g.Expect(created).To(Equal([]string{"window/workDoneProgress/create", "window/workDoneProgress/create"}))
+
+	var logMessage lsp.LogMessageParams
+	lo.Must0(json.Unmarshal(c.WaitNotifications("window/logMessage", 1)[0], &logMessage))
+	g.Expect(logMessage.Type).To(Equal(lsp.MessageInfo))
+	g.Expect(logMessage.Message).To(HavePrefix("Indexed 2 shaders"))
+
+	g.Expect(c.WaitDiagnostics(waterURI)).To(HaveLen(1))
+	g.Expect(c.WaitDiagnostics(noiseURI)).To(BeEmpty())
+
+	// The diagnostics of the file on disk come back when it is closed.
+	c.Open(waterURI, "shader_type spatial;\n")
+	g.Expect(c.WaitDiagnostics(waterURI)).To(BeEmpty())
+	c.Close(waterURI)
+	g.Expect(c.WaitDiagnostics(waterURI)).To(HaveLen(1))
+}
+
+func TestHandler_IndexWorkspace_MissingFolder(t *testing.T) {
+	g := NewWithT(t)
+	dir := filepath.Join(t.TempDir(), "missing")
+
+	c := startSession(t, lsptest.Options{
+		WorkspaceFolders: []lsp.WorkspaceFolder{{URI: lsp.PathToURI(dir), Name: "missing"}},
+	})
+
+	var showMessage lsp.ShowMessageParams
+	lo.Must0(json.Unmarshal(c.WaitNotifications("window/showMessage", 1)[0], &showMessage))
+	g.Expect(showMessage.Type).To(Equal(lsp.MessageWarning))
+	g.Expect(showMessage.Message).To(ContainSubstring(dir))
+}
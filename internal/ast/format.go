@@ -0,0 +1,436 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package ast
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+)
+
+// FormatOptions controls the output of Format.
+type FormatOptions struct {
+	// TabSize is the number of spaces per indentation level when
+	// InsertSpaces is set.
+	TabSize int
+	// InsertSpaces indents with spaces instead of tabs.
+	InsertSpaces bool
+	// TrimTrailingWhitespace also trims trailing whitespace inside block
+	// comments. Trailing whitespace in code is always removed.
+	TrimTrailingWhitespace bool
+	// InsertFinalNewline ensures the file ends with a newline.
+	InsertFinalNewline bool
+	// TrimFinalNewlines removes all but one newline at the end of the file.
+	TrimFinalNewlines bool
+}
+
+// TextEdit replaces the source in Span with NewText.
+type TextEdit struct {
+	Span
+	NewText string
+}
+
+// ApplyEdits returns a copy of src with the edits applied. Edits must not
+// overlap.
+func ApplyEdits(src []byte, edits []TextEdit) []byte {
+	edits = slices.Clone(edits)
+	slices.SortStableFunc(edits, func(a, b TextEdit) int { return a.Start - b.Start })
+	var buf bytes.Buffer
+	last := 0
+	for _, edit := range edits {
+		buf.Write(src[last:edit.Start])
+		buf.WriteString(edit.NewText)
+		last = edit.End
+	}
+	buf.Write(src[last:])
+	return buf.Bytes()
+}
+
+// Format formats a .gdshader file following the style of the Godot editor,
+// and returns the edits needed to do so.
+//
+// Only the whitespace between tokens is changed, so comments are always
+// kept and formatting can never change the meaning of the code. The AST is
+// used to tell apart constructs that look the same to the lexer, such as
+// unary and binary operators. Because the parser is error tolerant, files
+// with syntax errors can still be formatted.
+func Format(src []byte, options FormatOptions) []TextEdit {
+	f := newFormatter(src, options)
+	f.format()
+	return f.edits
+}
+
+type formatter struct {
+	src     []byte
+	options FormatOptions
+	tokens  []Token
+	edits   []TextEdit
+
+	// Token offsets classified using the AST.
+	prefixOps  map[int]bool
+	postfixOps map[int]bool
+	listBraces map[int]bool
+	caseColons map[int]bool
+	doWhiles   map[int]bool
+
+	indent     int
+	parenDepth int
+	braces     []braceFrame
+	// atStmtStart is set when the current line begins a new statement, as
+	// opposed to continuing the previous line.
+	atStmtStart bool
+	// lastEndedStmt is set when the last non-comment token ended a
+	// statement.
+	lastEndedStmt bool
+	// prevCode is the last non-comment token.
+	prevCode *Token
+}
+
+type braceFrame struct {
+	list bool
+	// inCase is set on a switch body after its first case label. The
+	// statements of each case are indented one extra level.
+	inCase bool
+	// caseBlock is set on a block that directly follows a case label, like
+	// "case 0: {". Its content is indented like the statements of the case.
+	caseBlock bool
+}
+
+var controlKeywords = map[string]bool{"if": true, "for": true, "while": true, "switch": true, "return": true}
+
+func newFormatter(src []byte, options FormatOptions) *formatter {
+	f := &formatter{
+		src:         src,
+		options:     options,
+		prefixOps:   make(map[int]bool),
+		postfixOps:  make(map[int]bool),
+		listBraces:  make(map[int]bool),
+		caseColons:  make(map[int]bool),
+		doWhiles:    make(map[int]bool),
+		atStmtStart: true,
+	}
+
+	for _, tok := range Tokenize(src) {
+		if tok.Kind == TokenEOF {
+			break
+		}
+		if tok.Kind == TokenDirective || (tok.Kind == TokenComment && strings.HasPrefix(tok.Text, "//")) {
+			// Trailing whitespace belongs to the gap after the token.
+			tok.Text = strings.TrimRight(tok.Text, " \t")
+			tok.End = tok.Start + len(tok.Text)
+		}
+		f.tokens = append(f.tokens, tok)
+	}
+
+	file, _ := Parse("", bytes.NewReader(src))
+	Inspect(file, func(node Node) bool {
+		switch n := node.(type) {
+		case *UnaryExpr:
+			if n.Postfix {
+				f.postfixOps[n.End-len(n.Op)] = true
+			} else {
+				f.prefixOps[n.Start] = true
+			}
+		case *InitListExpr:
+			f.listBraces[n.Start] = true
+			f.listBraces[n.End-1] = true
+		case *CaseStmt:
+			f.caseColons[n.End-1] = true
+		case *DoWhileStmt:
+			if n.Body != nil {
+				f.doWhiles[f.nextTokenStart(n.Body.End)] = true
+			}
+		}
+		return true
+	})
+
+	return f
+}
+
+func (f *formatter) nextTokenStart(offset int) int {
+	for offset < len(f.src) && isSpace(f.src[offset]) {
+		offset++
+	}
+	return offset
+}
+
+func (f *formatter) format() {
+	var prev *Token
+
+	for i := range f.tokens {
+		tok := &f.tokens[i]
+
+		closing := braceFrame{}
+		if f.isBlockBrace(tok, "}") {
+			closing = f.closeBrace()
+		}
+
+		gapStart := 0
+		if prev != nil {
+			gapStart = prev.End
+		}
+		f.setGap(gapStart, tok.Start, f.gap(prev, tok, closing.caseBlock))
+
+		if tok.Kind == TokenComment && strings.HasPrefix(tok.Text, "/*") && f.options.TrimTrailingWhitespace {
+			f.trimBlockComment(tok)
+		}
+
+		f.afterToken(tok)
+		prev = tok
+	}
+
+	f.formatEnd(prev)
+}
+
+func (f *formatter) isBlockBrace(tok *Token, text string) bool {
+	return tok.Kind == TokenPunct && tok.Text == text && !f.listBraces[tok.Start]
+}
+
+func (f *formatter) closeBrace() braceFrame {
+	n := len(f.braces)
+	if n == 0 {
+		return braceFrame{}
+	}
+	frame := f.braces[n-1]
+	f.braces = f.braces[:n-1]
+	switch {
+	case frame.inCase:
+		f.indent -= 2
+	case !frame.caseBlock:
+		f.indent--
+	}
+	f.indent = max(f.indent, 0)
+	return frame
+}
+
+func (f *formatter) topFrame() *braceFrame {
+	if n := len(f.braces); n > 0 {
+		return &f.braces[n-1]
+	}
+	return nil
+}
+
+func (f *formatter) afterToken(tok *Token) {
+	if tok.Kind == TokenComment {
+		return
+	}
+	f.atStmtStart = false
+	f.lastEndedStmt = f.endsStatement(tok)
+	prevCode := f.prevCode
+	f.prevCode = tok
+
+	if tok.Kind != TokenPunct {
+		return
+	}
+	switch tok.Text {
+	case "(":
+		f.parenDepth++
+	case ")":
+		f.parenDepth = max(f.parenDepth-1, 0)
+	case "{":
+		switch {
+		case f.listBraces[tok.Start]:
+			f.braces = append(f.braces, braceFrame{list: true})
+		case f.isCaseLabelEnd(prevCode):
+			f.braces = append(f.braces, braceFrame{caseBlock: true})
+		default:
+			f.braces = append(f.braces, braceFrame{})
+			f.indent++
+		}
+		f.parenDepth = 0
+	case ":":
+		if frame := f.topFrame(); f.caseColons[tok.Start] && frame != nil && !frame.inCase {
+			frame.inCase = true
+			f.indent++
+		}
+	}
+}
+
+func (f *formatter) setGap(start, end int, text string) {
+	if string(f.src[start:end]) != text {
+		f.edits = append(f.edits, TextEdit{Span: Span{start, end}, NewText: text})
+	}
+}
+
+// gap returns the whitespace to put between two tokens.
+func (f *formatter) gap(prev, tok *Token, closesCaseBlock bool) string {
+	if prev == nil {
+		return ""
+	}
+
+	original := f.src[prev.End:tok.Start]
+	originalNewlines := bytes.Count(original, []byte("\n"))
+
+	forced := f.forcesNewline(prev, tok)
+	switch {
+	case forced:
+	case f.forbidsNewline(prev, tok):
+		return f.space(prev, tok)
+	case originalNewlines > 0:
+		// Keep line breaks that the author chose, such as in long argument
+		// lists. The continuation is indented one extra level.
+	default:
+		return f.space(prev, tok)
+	}
+
+	if prev.Kind == TokenComment {
+		// A comment does not change whether we are inside a statement.
+		f.atStmtStart = f.atStmtStart || f.lastEndedStmt
+	} else {
+		f.atStmtStart = forced
+	}
+
+	newlines := 1
+	if originalNewlines > 1 && !f.isBlockBrace(prev, "{") && !f.isBlockBrace(tok, "}") {
+		newlines = 2
+	}
+
+	return strings.Repeat("\n", newlines) + f.indentation(tok, closesCaseBlock)
+}
+
+func (f *formatter) indentation(tok *Token, closesCaseBlock bool) string {
+	level := f.indent
+	if frame := f.topFrame(); frame != nil && frame.inCase && f.atStmtStart && tok.Kind == TokenIdent && (tok.Text == "case" || tok.Text == "default") {
+		level--
+	}
+	if closesCaseBlock {
+		level--
+	}
+	if !f.atStmtStart {
+		level++
+	}
+
+	if f.options.InsertSpaces {
+		return strings.Repeat(" ", level*max(f.options.TabSize, 1))
+	}
+	return strings.Repeat("\t", level)
+}
+
+func (f *formatter) forcesNewline(prev, tok *Token) bool {
+	switch {
+	case prev.Kind == TokenDirective || tok.Kind == TokenDirective:
+		return true
+	case prev.Kind == TokenComment:
+		return strings.HasPrefix(prev.Text, "//") || bytes.ContainsRune(f.src[prev.End:tok.Start], '\n')
+	case tok.Kind == TokenComment:
+		return bytes.ContainsRune(f.src[prev.End:tok.Start], '\n') && (f.atStmtStart || f.endsStatement(prev))
+	case f.isBlockBrace(tok, "}"):
+		return true
+	case f.isBlockBrace(tok, "{") && f.isCaseLabelEnd(prev):
+		return false
+	}
+	return f.endsStatement(prev) && !f.continuesStatement(tok)
+}
+
+// endsStatement reports whether a line break normally follows the token.
+func (f *formatter) endsStatement(tok *Token) bool {
+	switch {
+	case tok.Kind == TokenComment:
+		return false
+	case tok.Kind != TokenPunct:
+		return false
+	case tok.Text == ";":
+		return f.parenDepth == 0
+	case tok.Text == "{" || tok.Text == "}":
+		return !f.listBraces[tok.Start]
+	case tok.Text == ":":
+		return f.caseColons[tok.Start]
+	}
+	return false
+}
+
+// continuesStatement reports whether the token belongs on the same line as a
+// preceding closing brace.
+func (f *formatter) continuesStatement(tok *Token) bool {
+	switch {
+	case tok.Kind == TokenIdent:
+		return tok.Text == "else" || f.doWhiles[tok.Start]
+	case tok.Kind == TokenPunct:
+		return tok.Text == ";" || tok.Text == "," || tok.Text == ")"
+	}
+	return false
+}
+
+func (f *formatter) isCaseLabelEnd(tok *Token) bool {
+	return tok != nil && tok.Kind == TokenPunct && f.caseColons[tok.Start]
+}
+
+func (f *formatter) forbidsNewline(prev, tok *Token) bool {
+	switch {
+	case prev.Kind == TokenComment:
+		return false
+	case f.isBlockBrace(tok, "{"):
+		// Opening braces go on the same line.
+		return true
+	case f.isBlockBrace(prev, "}"):
+		return f.continuesStatement(tok)
+	case tok.Kind == TokenPunct:
+		return tok.Text == ";" || tok.Text == ","
+	}
+	return false
+}
+
+// space returns the separator between two tokens on the same line.
+func (f *formatter) space(prev, tok *Token) string {
+	switch {
+	case tok.Kind == TokenComment:
+		return " "
+	case prev.Kind == TokenPunct && (prev.Text == "(" || prev.Text == "[" || prev.Text == "."):
+		return ""
+	case f.prefixOps[prev.Start] || f.postfixOps[tok.Start]:
+		return ""
+	case f.listBraces[prev.Start] && prev.Text == "{" && f.listBraces[tok.Start]:
+		// Empty initializer list
+		return ""
+	case tok.Kind != TokenPunct:
+		return " "
+	}
+
+	switch tok.Text {
+	case ")", "]", ",", ";", ".":
+		return ""
+	case ":":
+		if f.caseColons[tok.Start] {
+			return ""
+		}
+	case "(":
+		if (prev.Kind == TokenIdent && !controlKeywords[prev.Text]) || prev.Text == "]" {
+			return ""
+		}
+	case "[":
+		if prev.Kind == TokenIdent || prev.Text == "]" || prev.Text == ")" {
+			return ""
+		}
+	}
+	return " "
+}
+
+func (f *formatter) trimBlockComment(tok *Token) {
+	lines := strings.Split(tok.Text, "\n")
+	offset := tok.Start
+	for _, line := range lines[:len(lines)-1] {
+		trimmed := strings.TrimRight(line, " \t")
+		if len(trimmed) < len(line) {
+			f.edits = append(f.edits, TextEdit{Span: Span{offset + len(trimmed), offset + len(line)}})
+		}
+		offset += len(line) + 1
+	}
+}
+
+func (f *formatter) formatEnd(last *Token) {
+	if last == nil {
+		f.setGap(0, len(f.src), "")
+		return
+	}
+
+	original := f.src[last.End:]
+	newlines := bytes.Count(original, []byte("\n"))
+	if f.options.TrimFinalNewlines {
+		newlines = min(newlines, 1)
+	}
+	if f.options.InsertFinalNewline {
+		newlines = max(newlines, 1)
+	}
+	f.setGap(last.End, len(f.src), strings.Repeat("\n", newlines))
+}
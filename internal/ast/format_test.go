@@ -0,0 +1,126 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package ast_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/samber/lo"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/armsnyder/gdshader-language-server/internal/ast"
+)
+
+var update = flag.Bool("update", false, "Update golden files")
+
+var godotStyle = ast.FormatOptions{
+	TrimTrailingWhitespace: true,
+	InsertFinalNewline:     true,
+	TrimFinalNewlines:      true,
+}
+
+func format(src []byte, options ast.FormatOptions) []byte {
+	return ast.ApplyEdits(src, ast.Format(src, options))
+}
+
+func TestFormatGolden(t *testing.T) {
+	inputs := lo.Must(filepath.Glob("testdata/format/*.input.gdshader"))
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".input.gdshader")
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+			goldenPath := strings.Replace(input, ".input.", ".golden.", 1)
+
+			got := format(lo.Must(os.ReadFile(input)), godotStyle)
+			if *update {
+				lo.Must0(os.WriteFile(goldenPath, got, 0o600))
+			}
+
+			golden := lo.Must(os.ReadFile(goldenPath))
+			g.Expect(string(got)).To(Equal(string(golden)))
+			g.Expect(string(format(golden, godotStyle))).To(Equal(string(golden)), "formatting is not idempotent")
+		})
+	}
+}
+
+func TestFormatValidProgramsIsIdempotent(t *testing.T) {
+	files := lo.Must(filepath.Glob("testdata/valid/*.gdshader"))
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			g := NewWithT(t)
+			once := format(lo.Must(os.ReadFile(file)), godotStyle)
+			g.Expect(string(format(once, godotStyle))).To(Equal(string(once)))
+		})
+	}
+}
+
+func TestFormatOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		options ast.FormatOptions
+		want    string
+	}{
+		{
+			name:    "spaces",
+			src:     "void f() {\nif (true) {\nreturn;\n}\n}\n",
+			options: ast.FormatOptions{InsertSpaces: true, TabSize: 2},
+			want:    "void f() {\n  if (true) {\n    return;\n  }\n}\n",
+		},
+		{
+			name:    "no final newline",
+			src:     "shader_type spatial;",
+			options: ast.FormatOptions{},
+			want:    "shader_type spatial;",
+		},
+		{
+			name:    "insert final newline",
+			src:     "shader_type spatial;",
+			options: ast.FormatOptions{InsertFinalNewline: true},
+			want:    "shader_type spatial;\n",
+		},
+		{
+			name:    "keep final newlines",
+			src:     "shader_type spatial;\n\n\n",
+			options: ast.FormatOptions{},
+			want:    "shader_type spatial;\n\n\n",
+		},
+		{
+			name:    "trim final newlines",
+			src:     "shader_type spatial;\n\n\n",
+			options: ast.FormatOptions{TrimFinalNewlines: true},
+			want:    "shader_type spatial;\n",
+		},
+		{
+			name:    "keep whitespace in block comments",
+			src:     "/* a  \n b */\n",
+			options: ast.FormatOptions{},
+			want:    "/* a  \n b */\n",
+		},
+		{
+			name:    "trim whitespace in block comments",
+			src:     "/* a  \n b */\n",
+			options: ast.FormatOptions{TrimTrailingWhitespace: true},
+			want:    "/* a\n b */\n",
+		},
+		{
+			name:    "syntax error",
+			src:     "void f() {\nx =  ;\ny=1;\n}\n",
+			options: ast.FormatOptions{},
+			want:    "void f() {\n\tx =;\n\ty = 1;\n}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(string(format([]byte(tt.src), tt.options))).To(Equal(tt.want))
+		})
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package ast
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// TokenKind identifies the lexical class of a Token.
+type TokenKind int
+
+// Token kinds.
+const (
+	TokenEOF TokenKind = iota
+	TokenIdent
+	TokenInt
+	TokenFloat
+	TokenString
+	TokenPunct
+	TokenComment
+	TokenDirective
+	TokenIllegal
+)
+
+// Token is a single lexical token of a .gdshader file.
+type Token struct {
+	Span
+	Kind TokenKind
+	Text string
+}
+
+// Operators and punctuation, longest first so that the lexer is greedy.
+var puncts = []string{
+	"<<=", ">>=",
+	"++", "--", "<<", ">>", "<=", ">=", "==", "!=", "&&", "||", "^^",
+	"+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=",
+	"+", "-", "*", "/", "%", "<", ">", "=", "!", "&", "|", "^", "~",
+	"?", ":", ";", ",", ".", "(", ")", "{", "}", "[", "]",
+}
+
+// Tokenize splits src into tokens, including comments and preprocessor
+// directives. Whitespace is dropped. The lexer never fails; characters it
+// does not understand become TokenIllegal tokens. The final token is always
+// TokenEOF.
+func Tokenize(src []byte) []Token {
+	var tokens []Token
+	for i := 0; ; {
+		for i < len(src) && isSpace(src[i]) {
+			i++
+		}
+		if i >= len(src) {
+			return append(tokens, Token{Span: Span{i, i}, Kind: TokenEOF})
+		}
+		kind, n := scanToken(src[i:])
+		tokens = append(tokens, Token{Span: Span{i, i + n}, Kind: kind, Text: string(src[i : i+n])})
+		i += n
+	}
+}
+
+func scanToken(s []byte) (TokenKind, int) {
+	c := s[0]
+	switch {
+	case c == '/' && len(s) > 1 && s[1] == '/':
+		return TokenComment, untilLineEnd(s)
+	case c == '/' && len(s) > 1 && s[1] == '*':
+		if i := strings.Index(string(s[2:]), "*/"); i >= 0 {
+			return TokenComment, i + 4
+		}
+		return TokenComment, len(s)
+	case c == '#':
+		return TokenDirective, untilLineEnd(s)
+	case c == '"':
+		return TokenString, scanString(s)
+	case isDigit(c) || (c == '.' && len(s) > 1 && isDigit(s[1])):
+		return scanNumber(s)
+	case isIdentStart(c):
+		n := 1
+		for n < len(s) && isIdentPart(s[n]) {
+			n++
+		}
+		return TokenIdent, n
+	}
+	for _, p := range puncts {
+		if strings.HasPrefix(string(s[:min(len(s), 3)]), p) {
+			return TokenPunct, len(p)
+		}
+	}
+	_, size := utf8.DecodeRune(s)
+	return TokenIllegal, size
+}
+
+func untilLineEnd(s []byte) int {
+	for i, c := range s {
+		if c == '\n' || c == '\r' {
+			return i
+		}
+	}
+	return len(s)
+}
+
+func scanString(s []byte) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		case '\n':
+			return i
+		}
+	}
+	return len(s)
+}
+
+func scanNumber(s []byte) (TokenKind, int) {
+	if len(s) > 1 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X') {
+		n := 2
+		for n < len(s) && isHexDigit(s[n]) {
+			n++
+		}
+		if n < len(s) && s[n] == 'u' {
+			n++
+		}
+		return TokenInt, n
+	}
+
+	kind, n := TokenInt, 0
+	for n < len(s) && isDigit(s[n]) {
+		n++
+	}
+	if n < len(s) && s[n] == '.' {
+		kind = TokenFloat
+		n++
+		for n < len(s) && isDigit(s[n]) {
+			n++
+		}
+	}
+	if n < len(s) && (s[n] == 'e' || s[n] == 'E') {
+		m := n + 1
+		if m < len(s) && (s[m] == '+' || s[m] == '-') {
+			m++
+		}
+		if m < len(s) && isDigit(s[m]) {
+			kind = TokenFloat
+			for n = m; n < len(s) && isDigit(s[n]); n++ {
+			}
+		}
+	}
+	if n < len(s) {
+		switch {
+		case s[n] == 'f' && kind == TokenFloat:
+			n++
+		case s[n] == 'u' && kind == TokenInt:
+			n++
+		}
+	}
+	return kind, n
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
@@ -4,18 +4,846 @@
 package ast
 
 import (
+	"bytes"
+	"fmt"
 	"io"
-
-	"github.com/alecthomas/participle/v2"
+	"strings"
 )
 
-var parser *participle.Parser[File]
+// Error is a syntax error found while parsing.
+type Error struct {
+	Span
+	Message string
+	// Expected is set to the missing token when the error is caused by a
+	// single expected token, such as a semicolon.
+	Expected string
+}
 
-func init() {
-	parser = participle.MustBuild[File]()
+func (e *Error) Error() string {
+	return fmt.Sprintf("offset %d: %s", e.Start, e.Message)
+}
+
+// ErrorList is the error returned by Parse when the source has one or more
+// syntax errors.
+type ErrorList []*Error
+
+func (l ErrorList) Error() string {
+	messages := make([]string, len(l))
+	for i, err := range l {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
 }
 
 // Parse parses a .gdshader file into a tree of AST nodes.
+//
+// The parser is error tolerant, since documents are usually incomplete while
+// they are being edited. If the source has syntax errors, the returned error
+// is an ErrorList and the returned File contains everything that could be
+// parsed.
 func Parse(filename string, reader io.Reader) (*File, error) {
-	return parser.Parse(filename, reader, participle.AllowTrailing(true))
+	src, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{src: src, file: &File{Span: Span{0, len(src)}, Filename: filename}}
+
+	for _, tok := range Tokenize(src) {
+		if tok.Kind == TokenComment {
+			p.file.Comments = append(p.file.Comments, &Comment{Span: tok.Span, Text: tok.Text})
+			continue
+		}
+		p.tokens = append(p.tokens, tok)
+	}
+
+	p.parseFile()
+
+	if len(p.errors) > 0 {
+		return p.file, p.errors
+	}
+	return p.file, nil
+}
+
+type parser struct {
+	src      []byte
+	tokens   []Token
+	pos      int
+	file     *File
+	errors   ErrorList
+	needSync bool
+}
+
+var precisions = map[string]bool{"lowp": true, "mediump": true, "highp": true}
+
+func (p *parser) peek() Token {
+	return p.peekN(0)
+}
+
+func (p *parser) peekN(n int) Token {
+	return p.tokens[min(p.pos+n, len(p.tokens)-1)]
+}
+
+func (p *parser) next() Token {
+	tok := p.peek()
+	if tok.Kind != TokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) prevEnd() int {
+	if p.pos == 0 {
+		return 0
+	}
+	return p.tokens[p.pos-1].End
+}
+
+func (p *parser) is(text string) bool {
+	tok := p.peek()
+	return (tok.Kind == TokenPunct || tok.Kind == TokenIdent) && tok.Text == text
+}
+
+func (p *parser) accept(text string) bool {
+	if p.is(text) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) bool {
+	if p.accept(text) {
+		return true
+	}
+
+	if text == ";" {
+		// Point at the end of the previous token, which is where the
+		// semicolon belongs. If the next token is on a new line, the
+		// statement is otherwise complete and there is no need to skip
+		// ahead.
+		end := p.prevEnd()
+		p.addError(&Error{Span: Span{end, end}, Message: `expected ";"`, Expected: text})
+		p.needSync = !bytes.ContainsRune(p.src[end:p.peek().Start], '\n')
+		return false
+	}
+
+	p.errorExpected(fmt.Sprintf("%q", text))
+	p.errors[len(p.errors)-1].Expected = text
+	return false
+}
+
+func (p *parser) expectIdent(what string) (string, Span) {
+	tok := p.peek()
+	if tok.Kind != TokenIdent {
+		p.errorExpected(what)
+		return "", Span{tok.Start, tok.Start}
+	}
+	p.next()
+	return tok.Text, tok.Span
+}
+
+func (p *parser) errorExpected(what string) {
+	tok := p.peek()
+	found := tok.Text
+	if tok.Kind == TokenEOF {
+		found = "end of file"
+	}
+	p.addError(&Error{Span: tok.Span, Message: fmt.Sprintf("expected %s, found %q", what, found)})
+	p.needSync = true
+}
+
+func (p *parser) addError(err *Error) {
+	// Only report the first error at a given location to avoid noise.
+	if n := len(p.errors); n > 0 && p.errors[n-1].Start >= err.Start {
+		return
+	}
+	p.errors = append(p.errors, err)
+}
+
+// sync skips tokens until the end of the current statement or declaration.
+func (p *parser) sync() {
+	p.needSync = false
+	if p.pos > 0 {
+		if prev := p.tokens[p.pos-1]; prev.Kind == TokenPunct && (prev.Text == ";" || prev.Text == "}") {
+			return
+		}
+	}
+	depth := 0
+	for {
+		tok := p.peek()
+		switch {
+		case tok.Kind == TokenEOF:
+			return
+		case tok.Kind != TokenPunct:
+		case tok.Text == "{":
+			depth++
+		case tok.Text == "}":
+			if depth == 0 {
+				return
+			}
+			depth--
+			if depth == 0 {
+				p.next()
+				return
+			}
+		case tok.Text == ";" && depth == 0:
+			p.next()
+			return
+		}
+		p.next()
+	}
+}
+
+func (p *parser) parseFile() {
+	for p.peek().Kind != TokenEOF {
+		start := p.pos
+		if decl := p.parseDeclaration(); decl != nil {
+			p.file.Declarations = append(p.file.Declarations, decl)
+		}
+		if p.needSync {
+			p.sync()
+		}
+		if p.pos == start {
+			p.errorExpected("declaration")
+			p.next()
+			p.needSync = false
+		}
+	}
+}
+
+func (p *parser) parseDeclaration() *Declaration {
+	tok := p.peek()
+	decl := &Declaration{}
+
+	switch {
+	case tok.Kind == TokenDirective:
+		decl.Directive = p.parseDirective()
+	case tok.Kind != TokenIdent:
+		return nil
+	case tok.Text == "shader_type":
+		decl.ShaderType = p.parseShaderType()
+	case tok.Text == "render_mode":
+		decl.RenderMode = p.parseRenderMode()
+	case tok.Text == "group_uniforms":
+		decl.GroupUniforms = p.parseGroupUniforms()
+	case tok.Text == "uniform" || tok.Text == "global" || tok.Text == "instance":
+		decl.UniformDecl = p.parseUniform()
+	case tok.Text == "varying":
+		decl.VaryingDecl = p.parseVarying()
+	case tok.Text == "const":
+		decl.ConstDecl = p.parseVarDecl()
+		p.expect(";")
+		decl.ConstDecl.End = p.prevEnd()
+	case tok.Text == "struct":
+		decl.StructDecl = p.parseStruct()
+	default:
+		decl.FunctionDecl = p.parseFunction()
+		if decl.FunctionDecl == nil {
+			return nil
+		}
+	}
+
+	decl.Span = Span{tok.Start, p.prevEnd()}
+	return decl
+}
+
+func (p *parser) parseDirective() *Directive {
+	tok := p.next()
+	name := strings.TrimPrefix(tok.Text, "#")
+	nameLen := strings.IndexFunc(name, func(r rune) bool { return r == ' ' || r == '\t' })
+	if nameLen < 0 {
+		nameLen = len(name)
+	}
+	rest := name[nameLen:]
+	value := strings.TrimSpace(rest)
+	valueStart := tok.Start + 1 + nameLen + strings.Index(rest, value)
+	return &Directive{
+		Span:      tok.Span,
+		Name:      name[:nameLen],
+		Value:     value,
+		ValueSpan: Span{valueStart, valueStart + len(value)},
+	}
+}
+
+func (p *parser) parseShaderType() *ShaderTypeDecl {
+	start := p.next().Start
+	decl := &ShaderTypeDecl{}
+	decl.Name, decl.NameSpan = p.expectIdent("shader type")
+	p.expect(";")
+	decl.Span = Span{start, p.prevEnd()}
+	return decl
+}
+
+func (p *parser) parseRenderMode() *RenderModeDecl {
+	start := p.next().Start
+	decl := &RenderModeDecl{}
+	for {
+		name, span := p.expectIdent("render mode")
+		if name == "" {
+			break
+		}
+		decl.Modes = append(decl.Modes, &Ident{Span: span, Name: name})
+		if !p.accept(",") {
+			break
+		}
+	}
+	p.expect(";")
+	decl.Span = Span{start, p.prevEnd()}
+	return decl
+}
+
+func (p *parser) parseGroupUniforms() *GroupUniformsDecl {
+	start := p.next().Start
+	decl := &GroupUniformsDecl{}
+	if p.peek().Kind == TokenIdent {
+		decl.Name = p.next().Text
+		for p.is(".") && p.peekN(1).Kind == TokenIdent {
+			p.next()
+			decl.Name += "." + p.next().Text
+		}
+	}
+	p.expect(";")
+	decl.Span = Span{start, p.prevEnd()}
+	return decl
+}
+
+func (p *parser) parseUniform() *UniformDecl {
+	start := p.peek().Start
+	decl := &UniformDecl{}
+	if p.is("global") || p.is("instance") {
+		decl.Scope = p.next().Text
+	}
+	p.expect("uniform")
+	decl.Precision = p.parsePrecision()
+	decl.Type, decl.TypeSpan = p.expectIdent("type")
+	decl.Name, decl.NameSpan = p.expectIdent("uniform name")
+	decl.ArraySize = p.parseArraySize()
+
+	if p.accept(":") {
+		for {
+			hint := p.parseHint()
+			if hint == nil {
+				break
+			}
+			decl.Hints = append(decl.Hints, hint)
+			if !p.accept(",") {
+				break
+			}
+		}
+	}
+
+	if p.accept("=") {
+		decl.Default = p.parseExpr()
+	}
+
+	p.expect(";")
+	decl.Span = Span{start, p.prevEnd()}
+	return decl
+}
+
+func (p *parser) parseHint() *Hint {
+	start := p.peek().Start
+	name, _ := p.expectIdent("hint")
+	if name == "" {
+		return nil
+	}
+	hint := &Hint{Name: name}
+	if p.is("(") {
+		hint.Args = p.parseHintArgs()
+	}
+	hint.Span = Span{start, p.prevEnd()}
+	return hint
+}
+
+// parseHintArgs parses the arguments of a hint, which can be strings, such
+// as the names of hint_enum("A", "B").
+func (p *parser) parseHintArgs() []Expr {
+	p.next()
+	var args []Expr
+	for !p.is(")") && p.peek().Kind != TokenEOF {
+		if tok := p.peek(); tok.Kind == TokenString {
+			p.next()
+			args = append(args, &Literal{Span: tok.Span, Kind: LiteralString, Value: tok.Text})
+		} else {
+			args = append(args, p.parseAssign())
+		}
+		if !p.accept(",") {
+			break
+		}
+	}
+	p.expect(")")
+	return args
+}
+
+func (p *parser) parseVarying() *VaryingDecl {
+	start := p.next().Start
+	decl := &VaryingDecl{}
+	if p.is("flat") || p.is("smooth") {
+		decl.Interpolation = p.next().Text
+	}
+	decl.Precision = p.parsePrecision()
+	decl.Type, decl.TypeSpan = p.expectIdent("type")
+	decl.Name, decl.NameSpan = p.expectIdent("varying name")
+	decl.ArraySize = p.parseArraySize()
+	p.expect(";")
+	decl.Span = Span{start, p.prevEnd()}
+	return decl
+}
+
+func (p *parser) parseStruct() *StructDecl {
+	start := p.next().Start
+	decl := &StructDecl{}
+	decl.Name, decl.NameSpan = p.expectIdent("struct name")
+	if p.expect("{") {
+		for !p.is("}") && p.peek().Kind != TokenEOF {
+			before := p.pos
+			field := p.parseVarDecl()
+			p.expect(";")
+			field.End = p.prevEnd()
+			decl.Fields = append(decl.Fields, field)
+			if p.needSync {
+				p.sync()
+			}
+			if p.pos == before {
+				p.next()
+			}
+		}
+		p.expect("}")
+	}
+	p.expect(";")
+	decl.Span = Span{start, p.prevEnd()}
+	return decl
+}
+
+func (p *parser) parseFunction() *FunctionDecl {
+	start := p.peek().Start
+	decl := &FunctionDecl{}
+	decl.Precision = p.parsePrecision()
+	decl.ReturnType, decl.ReturnTypeSpan = p.expectIdent("declaration")
+	if decl.ReturnType == "" {
+		return nil
+	}
+	decl.Name, decl.NameSpan = p.expectIdent("function name")
+	if decl.Name == "" || !p.expect("(") {
+		decl.Span = Span{start, p.prevEnd()}
+		return decl
+	}
+
+	if p.is("void") && p.peekN(1).Text == ")" {
+		p.next()
+	}
+	for !p.is(")") && p.peek().Kind != TokenEOF {
+		param := p.parseParam()
+		if param == nil {
+			break
+		}
+		decl.Params = append(decl.Params, param)
+		if !p.accept(",") {
+			break
+		}
+	}
+
+	if p.expect(")") && p.is("{") {
+		decl.Body = p.parseBlock()
+	} else if !p.needSync {
+		p.errorExpected(`"{"`)
+	}
+
+	decl.Span = Span{start, p.prevEnd()}
+	return decl
+}
+
+func (p *parser) parseParam() *Param {
+	start := p.peek().Start
+	param := &Param{}
+	param.Const = p.accept("const")
+	if p.is("in") || p.is("out") || p.is("inout") {
+		param.Qualifier = p.next().Text
+	}
+	param.Precision = p.parsePrecision()
+	param.Type, param.TypeSpan = p.expectIdent("parameter type")
+	if param.Type == "" {
+		return nil
+	}
+	param.Name, param.NameSpan = p.expectIdent("parameter name")
+	param.ArraySize = p.parseArraySize()
+	param.Span = Span{start, p.prevEnd()}
+	return param
+}
+
+func (p *parser) parsePrecision() string {
+	if tok := p.peek(); tok.Kind == TokenIdent && precisions[tok.Text] {
+		p.next()
+		return tok.Text
+	}
+	return ""
+}
+
+// parseArraySize parses an optional array suffix. An unsized array, like
+// "[]", returns a BadExpr with an empty span.
+func (p *parser) parseArraySize() Expr {
+	if !p.is("[") {
+		return nil
+	}
+	p.next()
+	if p.is("]") {
+		p.next()
+		return &BadExpr{Span: Span{p.prevEnd() - 1, p.prevEnd() - 1}}
+	}
+	size := p.parseExpr()
+	p.expect("]")
+	return size
+}
+
+// parseVarDecl parses a variable declaration without the trailing
+// semicolon.
+func (p *parser) parseVarDecl() *VarDecl {
+	start := p.peek().Start
+	decl := &VarDecl{}
+	decl.Const = p.accept("const")
+	decl.Precision = p.parsePrecision()
+	decl.Type, decl.TypeSpan = p.expectIdent("type")
+	typeArraySize := p.parseArraySize()
+
+	for decl.Type != "" {
+		declarator := &Declarator{}
+		declarator.Name, declarator.NameSpan = p.expectIdent("variable name")
+		if declarator.Name == "" {
+			break
+		}
+		declarator.Start = declarator.NameSpan.Start
+		declarator.ArraySize = p.parseArraySize()
+		if declarator.ArraySize == nil {
+			declarator.ArraySize = typeArraySize
+		}
+		if p.accept("=") {
+			declarator.Init = p.parseAssign()
+		}
+		declarator.End = p.prevEnd()
+		decl.Vars = append(decl.Vars, declarator)
+		if !p.accept(",") {
+			break
+		}
+	}
+
+	decl.Span = Span{start, p.prevEnd()}
+	return decl
+}
+
+func (p *parser) parseBlock() *BlockStmt {
+	start := p.next().Start
+	block := &BlockStmt{}
+	for !p.is("}") && p.peek().Kind != TokenEOF {
+		before := p.pos
+		block.Stmts = append(block.Stmts, p.parseStmt())
+		if p.needSync {
+			p.sync()
+		}
+		if p.pos == before {
+			p.errorExpected("statement")
+			p.next()
+			p.needSync = false
+		}
+	}
+	p.expect("}")
+	block.Span = Span{start, p.prevEnd()}
+	return block
+}
+
+func (p *parser) isVarDeclStart() bool {
+	tok := p.peek()
+	if tok.Kind != TokenIdent {
+		return false
+	}
+	if tok.Text == "const" || precisions[tok.Text] {
+		return true
+	}
+	if next := p.peekN(1); next.Kind == TokenIdent {
+		return true
+	}
+	// Array types like "float[3] a" or "float[] a".
+	if p.peekN(1).Text == "[" {
+		if p.peekN(2).Text == "]" {
+			return p.peekN(3).Kind == TokenIdent
+		}
+		return p.peekN(3).Text == "]" && p.peekN(4).Kind == TokenIdent
+	}
+	return false
+}
+
+func (p *parser) parseStmt() *Stmt {
+	tok := p.peek()
+	stmt := &Stmt{}
+
+	switch {
+	case p.is("{"):
+		stmt.Block = p.parseBlock()
+	case p.is(";"):
+		p.next()
+	case tok.Kind != TokenIdent:
+		stmt.Expr = p.parseExpr()
+		p.expect(";")
+	case tok.Text == "if":
+		stmt.If = p.parseIf()
+	case tok.Text == "for":
+		stmt.For = p.parseFor()
+	case tok.Text == "while":
+		p.next()
+		stmt.While = &WhileStmt{Cond: p.parseCond()}
+		stmt.While.Body = p.parseStmt()
+		stmt.While.Span = Span{tok.Start, p.prevEnd()}
+	case tok.Text == "do":
+		p.next()
+		stmt.DoWhile = &DoWhileStmt{Body: p.parseStmt()}
+		p.expect("while")
+		stmt.DoWhile.Cond = p.parseCond()
+		p.expect(";")
+		stmt.DoWhile.Span = Span{tok.Start, p.prevEnd()}
+	case tok.Text == "switch":
+		p.next()
+		stmt.Switch = &SwitchStmt{Tag: p.parseCond()}
+		if p.is("{") {
+			stmt.Switch.Body = p.parseBlock()
+		} else {
+			p.errorExpected(`"{"`)
+		}
+		stmt.Switch.Span = Span{tok.Start, p.prevEnd()}
+	case tok.Text == "case" || tok.Text == "default":
+		p.next()
+		stmt.Case = &CaseStmt{}
+		if tok.Text == "case" {
+			stmt.Case.Value = p.parseExpr()
+		}
+		p.expect(":")
+		stmt.Case.Span = Span{tok.Start, p.prevEnd()}
+	case tok.Text == "return":
+		p.next()
+		stmt.Return = &ReturnStmt{}
+		if !p.is(";") {
+			stmt.Return.Value = p.parseExpr()
+		}
+		p.expect(";")
+		stmt.Return.Span = Span{tok.Start, p.prevEnd()}
+	case tok.Text == "break" || tok.Text == "continue" || tok.Text == "discard":
+		p.next()
+		p.expect(";")
+		stmt.Jump = &JumpStmt{Span: Span{tok.Start, p.prevEnd()}, Keyword: tok.Text}
+	case p.isVarDeclStart():
+		stmt.VarDecl = p.parseVarDecl()
+		p.expect(";")
+		stmt.VarDecl.End = p.prevEnd()
+	default:
+		stmt.Expr = p.parseExpr()
+		p.expect(";")
+	}
+
+	stmt.Span = Span{tok.Start, p.prevEnd()}
+	return stmt
+}
+
+func (p *parser) parseCond() Expr {
+	if !p.expect("(") {
+		return &BadExpr{Span: Span{p.peek().Start, p.peek().Start}}
+	}
+	cond := p.parseExpr()
+	p.expect(")")
+	return cond
+}
+
+func (p *parser) parseIf() *IfStmt {
+	start := p.next().Start
+	stmt := &IfStmt{Cond: p.parseCond()}
+	stmt.Then = p.parseStmt()
+	if p.accept("else") {
+		stmt.Else = p.parseStmt()
+	}
+	stmt.Span = Span{start, p.prevEnd()}
+	return stmt
+}
+
+func (p *parser) parseFor() *ForStmt {
+	start := p.next().Start
+	stmt := &ForStmt{}
+	if p.expect("(") {
+		stmt.Init = p.parseStmt()
+		if !p.is(";") {
+			stmt.Cond = p.parseExpr()
+		}
+		p.expect(";")
+		if !p.is(")") {
+			stmt.Post = p.parseExpr()
+		}
+		p.expect(")")
+	}
+	stmt.Body = p.parseStmt()
+	stmt.Span = Span{start, p.prevEnd()}
+	return stmt
+}
+
+func (p *parser) parseExpr() Expr {
+	return p.parseAssign()
+}
+
+var assignOps = map[string]bool{
+	"=": true, "+=": true, "-=": true, "*=": true, "/=": true, "%=": true,
+	"<<=": true, ">>=": true, "&=": true, "|=": true, "^=": true,
+}
+
+func (p *parser) parseAssign() Expr {
+	left := p.parseTernary()
+	if tok := p.peek(); tok.Kind == TokenPunct && assignOps[tok.Text] {
+		p.next()
+		right := p.parseAssign()
+		return &AssignExpr{Span: Span{left.Extent().Start, right.Extent().End}, Op: tok.Text, Left: left, Right: right}
+	}
+	return left
+}
+
+func (p *parser) parseTernary() Expr {
+	cond := p.parseBinary(1)
+	if !p.accept("?") {
+		return cond
+	}
+	then := p.parseAssign()
+	p.expect(":")
+	els := p.parseAssign()
+	return &TernaryExpr{Span: Span{cond.Extent().Start, els.Extent().End}, Cond: cond, Then: then, Else: els}
+}
+
+var binaryPrecedence = map[string]int{
+	"||": 1,
+	"^^": 2,
+	"&&": 3,
+	"|":  4,
+	"^":  5,
+	"&":  6,
+	"==": 7, "!=": 7,
+	"<": 8, ">": 8, "<=": 8, ">=": 8,
+	"<<": 9, ">>": 9,
+	"+": 10, "-": 10,
+	"*": 11, "/": 11, "%": 11,
+}
+
+func (p *parser) parseBinary(minPrec int) Expr {
+	x := p.parseUnary()
+	for {
+		tok := p.peek()
+		prec := binaryPrecedence[tok.Text]
+		if tok.Kind != TokenPunct || prec < minPrec || prec == 0 {
+			return x
+		}
+		p.next()
+		y := p.parseBinary(prec + 1)
+		x = &BinaryExpr{Span: Span{x.Extent().Start, y.Extent().End}, Op: tok.Text, X: x, Y: y}
+	}
+}
+
+func (p *parser) parseUnary() Expr {
+	tok := p.peek()
+	if tok.Kind == TokenPunct {
+		switch tok.Text {
+		case "-", "+", "!", "~", "++", "--":
+			p.next()
+			x := p.parseUnary()
+			return &UnaryExpr{Span: Span{tok.Start, x.Extent().End}, Op: tok.Text, X: x}
+		}
+	}
+	return p.parsePostfix()
+}
+
+func (p *parser) parsePostfix() Expr {
+	x := p.parsePrimary()
+	for {
+		tok := p.peek()
+		switch {
+		case tok.Kind != TokenPunct:
+			return x
+		case tok.Text == ".":
+			p.next()
+			name, span := p.expectIdent("field or swizzle")
+			x = &MemberExpr{Span: Span{x.Extent().Start, p.prevEnd()}, X: x, Name: name, NameSpan: span}
+		case tok.Text == "[":
+			p.next()
+			var index Expr
+			if !p.is("]") {
+				index = p.parseExpr()
+			}
+			p.expect("]")
+			x = &IndexExpr{Span: Span{x.Extent().Start, p.prevEnd()}, X: x, Index: index}
+		case tok.Text == "++" || tok.Text == "--":
+			p.next()
+			x = &UnaryExpr{Span: Span{x.Extent().Start, tok.End}, Op: tok.Text, X: x, Postfix: true}
+		case tok.Text == "(":
+			fn := x
+			// Array constructors like float[](...) are treated as a call to
+			// the element type.
+			if index, ok := x.(*IndexExpr); ok {
+				if ident, ok := index.X.(*Ident); ok {
+					fn = ident
+				}
+			}
+			args := p.parseArgs()
+			x = &CallExpr{Span: Span{x.Extent().Start, p.prevEnd()}, Func: fn, Args: args}
+		default:
+			return x
+		}
+	}
+}
+
+func (p *parser) parseArgs() []Expr {
+	p.next()
+	var args []Expr
+	for !p.is(")") && p.peek().Kind != TokenEOF {
+		args = append(args, p.parseAssign())
+		if !p.accept(",") {
+			break
+		}
+	}
+	p.expect(")")
+	return args
+}
+
+func (p *parser) parsePrimary() Expr {
+	tok := p.peek()
+	switch tok.Kind {
+	case TokenIdent:
+		p.next()
+		if tok.Text == "true" || tok.Text == "false" {
+			return &Literal{Span: tok.Span, Kind: LiteralBool, Value: tok.Text}
+		}
+		return &Ident{Span: tok.Span, Name: tok.Text}
+	case TokenInt:
+		p.next()
+		kind := LiteralInt
+		if strings.HasSuffix(tok.Text, "u") {
+			kind = LiteralUint
+		}
+		return &Literal{Span: tok.Span, Kind: kind, Value: tok.Text}
+	case TokenFloat:
+		p.next()
+		return &Literal{Span: tok.Span, Kind: LiteralFloat, Value: tok.Text}
+	case TokenPunct:
+		switch tok.Text {
+		case "(":
+			p.next()
+			x := p.parseExpr()
+			p.expect(")")
+			return &ParenExpr{Span: Span{tok.Start, p.prevEnd()}, X: x}
+		case "{":
+			p.next()
+			list := &InitListExpr{}
+			for !p.is("}") && p.peek().Kind != TokenEOF {
+				list.Elems = append(list.Elems, p.parseAssign())
+				if !p.accept(",") {
+					break
+				}
+			}
+			p.expect("}")
+			list.Span = Span{tok.Start, p.prevEnd()}
+			return list
+		}
+	}
+	p.errorExpected("expression")
+	return &BadExpr{Span: Span{tok.Start, tok.Start}}
 }
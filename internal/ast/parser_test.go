@@ -5,11 +5,12 @@ package ast_test
 
 import (
 	"bytes"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
-	"github.com/alecthomas/participle/v2/lexer"
 	"github.com/armsnyder/gdshader-language-server/internal/ast"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/samber/lo"
@@ -24,6 +25,7 @@ func TestParser(t *testing.T) {
 	shader, err := ast.Parse(filename, bytes.NewReader(content))
 	g.Expect(err).ToNot(HaveOccurred())
 	expected := &ast.File{
+		Filename: filename,
 		Declarations: []*ast.Declaration{
 			{
 				UniformDecl: &ast.UniformDecl{
@@ -62,4 +64,138 @@ func TestCanParseAllValidPrograms(t *testing.T) {
 	}
 }
 
-var IgnorePos = cmpopts.IgnoreTypes(lexer.Position{})
+var IgnorePos = cmpopts.IgnoreTypes(ast.Span{})
+
+func TestParseExpressionPrecedence(t *testing.T) {
+	g := NewWithT(t)
+	shader, err := ast.Parse("test.gdshader", strings.NewReader("void f() { x = a + b * c.xy[0]; }"))
+	g.Expect(err).ToNot(HaveOccurred())
+
+	stmt := shader.Declarations[0].FunctionDecl.Body.Stmts[0]
+	expected := &ast.AssignExpr{
+		Op:   "=",
+		Left: &ast.Ident{Name: "x"},
+		Right: &ast.BinaryExpr{
+			Op: "+",
+			X:  &ast.Ident{Name: "a"},
+			Y: &ast.BinaryExpr{
+				Op: "*",
+				X:  &ast.Ident{Name: "b"},
+				Y: &ast.IndexExpr{
+					X:     &ast.MemberExpr{X: &ast.Ident{Name: "c"}, Name: "xy"},
+					Index: &ast.Literal{Kind: ast.LiteralInt, Value: "0"},
+				},
+			},
+		},
+	}
+	g.Expect(stmt.Expr).To(BeComparableTo(expected, IgnorePos))
+}
+
+func TestParseMethodCall(t *testing.T) {
+	g := NewWithT(t)
+	shader, err := ast.Parse("test.gdshader", strings.NewReader("void f() { n = arr.length(); }"))
+	g.Expect(err).ToNot(HaveOccurred())
+
+	stmt := shader.Declarations[0].FunctionDecl.Body.Stmts[0]
+	expected := &ast.AssignExpr{
+		Op:   "=",
+		Left: &ast.Ident{Name: "n"},
+		Right: &ast.CallExpr{
+			Func: &ast.MemberExpr{X: &ast.Ident{Name: "arr"}, Name: "length"},
+		},
+	}
+	g.Expect(stmt.Expr).To(BeComparableTo(expected, IgnorePos))
+}
+
+func TestParseHintStrings(t *testing.T) {
+	g := NewWithT(t)
+	shader, err := ast.Parse("test.gdshader", strings.NewReader(`uniform int mode : hint_enum("A", "B") = 0;`))
+	g.Expect(err).ToNot(HaveOccurred())
+
+	expected := &ast.UniformDecl{
+		Type: "int",
+		Name: "mode",
+		Hints: []*ast.Hint{{
+			Name: "hint_enum",
+			Args: []ast.Expr{
+				&ast.Literal{Kind: ast.LiteralString, Value: `"A"`},
+				&ast.Literal{Kind: ast.LiteralString, Value: `"B"`},
+			},
+		}},
+		Default: &ast.Literal{Kind: ast.LiteralInt, Value: "0"},
+	}
+	g.Expect(shader.Declarations[0].UniformDecl).To(BeComparableTo(expected, IgnorePos))
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		src          string
+		wantErrors   []string
+		wantExpected string
+		wantOffset   int
+		wantDecls    int
+		wantStmts    int
+	}{
+		{
+			name:         "missing semicolon before newline",
+			src:          "void f() {\n\tfloat a = 1.0\n\ta = 2.0;\n}",
+			wantErrors:   []string{`expected ";"`},
+			wantExpected: ";",
+			wantOffset:   len("void f() {\n\tfloat a = 1.0"),
+			wantDecls:    1,
+			wantStmts:    2,
+		},
+		{
+			name:       "bad expression",
+			src:        "void f() {\n\ta = ;\n\ta = 2.0;\n}",
+			wantErrors: []string{`expected expression, found ";"`},
+			wantOffset: len("void f() {\n\ta = "),
+			wantDecls:  1,
+			wantStmts:  2,
+		},
+		{
+			name:       "garbage declaration",
+			src:        "shader_type spatial;\n} uniform float x;",
+			wantErrors: []string{`expected declaration, found "}"`},
+			wantOffset: len("shader_type spatial;\n"),
+			wantDecls:  2,
+		},
+		{
+			name:         "unterminated function",
+			src:          "void f() {\n\ta = 1.0;\n",
+			wantErrors:   []string{`expected "}", found "end of file"`},
+			wantExpected: "}",
+			wantOffset:   len("void f() {\n\ta = 1.0;\n"),
+			wantDecls:    1,
+			wantStmts:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			shader, err := ast.Parse("test.gdshader", strings.NewReader(tt.src))
+
+			var errs ast.ErrorList
+			g.Expect(errors.As(err, &errs)).To(BeTrue(), "error should be an ErrorList")
+			g.Expect(lo.Map(errs, func(e *ast.Error, _ int) string { return e.Message })).To(Equal(tt.wantErrors))
+			g.Expect(errs[0].Expected).To(Equal(tt.wantExpected))
+			g.Expect(errs[0].Start).To(Equal(tt.wantOffset))
+
+			g.Expect(shader.Declarations).To(HaveLen(tt.wantDecls))
+			if tt.wantStmts > 0 {
+				g.Expect(shader.Declarations[0].FunctionDecl.Body.Stmts).To(HaveLen(tt.wantStmts))
+			}
+		})
+	}
+}
+
+func TestTokenizeKeepsComments(t *testing.T) {
+	g := NewWithT(t)
+	tokens := ast.Tokenize([]byte("a /* b */ // c\n#define D 1\n1.5e3 0x1Fu"))
+	g.Expect(lo.Map(tokens, func(tok ast.Token, _ int) ast.TokenKind { return tok.Kind })).To(Equal([]ast.TokenKind{
+		ast.TokenIdent, ast.TokenComment, ast.TokenComment, ast.TokenDirective, ast.TokenFloat, ast.TokenInt, ast.TokenEOF,
+	}))
+	g.Expect(tokens[3].Text).To(Equal("#define D 1"))
+}
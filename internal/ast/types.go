@@ -3,60 +3,371 @@
 
 package ast
 
-import "github.com/alecthomas/participle/v2/lexer"
+// Span is a half-open range of byte offsets into the source file.
+type Span struct {
+	Start int
+	End   int
+}
+
+// Extent implements Node.
+func (s Span) Extent() Span {
+	return s
+}
+
+// Contains reports whether the offset falls inside the span. The end offset
+// is included so that a cursor placed right after a node still touches it.
+func (s Span) Contains(offset int) bool {
+	return s.Start <= offset && offset <= s.End
+}
+
+// Node is implemented by every AST node.
+type Node interface {
+	Extent() Span
+}
 
 // File is the root of a parsed .gdshader file.
 type File struct {
-	Pos          lexer.Position
-	Declarations []*Declaration `@@*`
+	Span
+	Filename     string
+	Declarations []*Declaration
+	Comments     []*Comment
+}
+
+// Comment is a line or block comment.
+type Comment struct {
+	Span
+	Text string
 }
 
-// Declaration is a top-level declaration.
+// Declaration is a top-level declaration. Exactly one field is set.
 type Declaration struct {
-	UniformDecl  *UniformDecl  `@@`
-	FunctionDecl *FunctionDecl `| @@`
+	Span
+	ShaderType    *ShaderTypeDecl
+	RenderMode    *RenderModeDecl
+	UniformDecl   *UniformDecl
+	GroupUniforms *GroupUniformsDecl
+	VaryingDecl   *VaryingDecl
+	ConstDecl     *VarDecl
+	StructDecl    *StructDecl
+	FunctionDecl  *FunctionDecl
+	Directive     *Directive
+}
+
+// ShaderTypeDecl is a shader_type declaration.
+type ShaderTypeDecl struct {
+	Span
+	Name     string
+	NameSpan Span
+}
+
+// RenderModeDecl is a render_mode declaration.
+type RenderModeDecl struct {
+	Span
+	Modes []*Ident
 }
 
 // UniformDecl is a uniform variable declaration.
 type UniformDecl struct {
-	Type string `"uniform" @Ident`
-	Name string `@Ident ";"`
+	Span
+	// Scope is "global", "instance" or empty.
+	Scope     string
+	Precision string
+	Type      string
+	TypeSpan  Span
+	Name      string
+	NameSpan  Span
+	ArraySize Expr
+	Hints     []*Hint
+	Default   Expr
+}
+
+// Hint is a uniform hint such as source_color or hint_range(0, 1).
+type Hint struct {
+	Span
+	Name string
+	Args []Expr
+}
+
+// GroupUniformsDecl opens or, when Name is empty, closes a uniform group.
+type GroupUniformsDecl struct {
+	Span
+	Name string
+}
+
+// VaryingDecl is a varying variable declaration.
+type VaryingDecl struct {
+	Span
+	Interpolation string
+	Precision     string
+	Type          string
+	TypeSpan      Span
+	Name          string
+	NameSpan      Span
+	ArraySize     Expr
+}
+
+// VarDecl declares one or more variables of the same type, either locally or
+// as a global constant.
+type VarDecl struct {
+	Span
+	Const     bool
+	Precision string
+	Type      string
+	TypeSpan  Span
+	Vars      []*Declarator
+}
+
+// Declarator is a single variable within a VarDecl.
+type Declarator struct {
+	Span
+	Name      string
+	NameSpan  Span
+	ArraySize Expr
+	Init      Expr
+}
+
+// StructDecl is a struct type declaration.
+type StructDecl struct {
+	Span
+	Name     string
+	NameSpan Span
+	Fields   []*VarDecl
 }
 
 // FunctionDecl is a function declaration.
 type FunctionDecl struct {
-	ReturnType string     `@Ident`
-	Name       string     `@Ident "(" ")"`
-	Body       *BlockStmt `@@`
+	Span
+	Precision      string
+	ReturnType     string
+	ReturnTypeSpan Span
+	Name           string
+	NameSpan       Span
+	Params         []*Param
+	Body           *BlockStmt
+}
+
+// Param is a function parameter.
+type Param struct {
+	Span
+	Const bool
+	// Qualifier is "in", "out", "inout" or empty.
+	Qualifier string
+	Precision string
+	Type      string
+	TypeSpan  Span
+	Name      string
+	NameSpan  Span
+	ArraySize Expr
+}
+
+// Directive is a preprocessor directive such as #include or #define.
+type Directive struct {
+	Span
+	Name      string
+	Value     string
+	ValueSpan Span
 }
 
 // BlockStmt is a block of statements enclosed in braces.
 type BlockStmt struct {
-	Stmts []*Stmt `"{" @@* "}"`
+	Span
+	Stmts []*Stmt
 }
 
-// Stmt is a code statement.
+// Stmt is a code statement. Exactly one field is set, except for the empty
+// statement where none are.
 type Stmt struct {
-	Assignment *Assignment `@@`
+	Span
+	Block   *BlockStmt
+	VarDecl *VarDecl
+	Expr    Expr
+	If      *IfStmt
+	For     *ForStmt
+	While   *WhileStmt
+	DoWhile *DoWhileStmt
+	Switch  *SwitchStmt
+	Case    *CaseStmt
+	Return  *ReturnStmt
+	Jump    *JumpStmt
+}
+
+// IfStmt is an if statement with an optional else branch.
+type IfStmt struct {
+	Span
+	Cond Expr
+	Then *Stmt
+	Else *Stmt
+}
+
+// ForStmt is a for loop.
+type ForStmt struct {
+	Span
+	Init *Stmt
+	Cond Expr
+	Post Expr
+	Body *Stmt
+}
+
+// WhileStmt is a while loop.
+type WhileStmt struct {
+	Span
+	Cond Expr
+	Body *Stmt
 }
 
-// Assignment is an assignment statement.
-type Assignment struct {
-	Left   string `@Ident`
-	Equals string `@"="`
-	Expr   *Expr  `@@`
-	Semi   string `@";"`
+// DoWhileStmt is a do-while loop.
+type DoWhileStmt struct {
+	Span
+	Body *Stmt
+	Cond Expr
+}
+
+// SwitchStmt is a switch statement.
+type SwitchStmt struct {
+	Span
+	Tag  Expr
+	Body *BlockStmt
+}
+
+// CaseStmt is a case label inside a switch. Value is nil for default.
+type CaseStmt struct {
+	Span
+	Value Expr
+}
+
+// ReturnStmt is a return statement.
+type ReturnStmt struct {
+	Span
+	Value Expr
+}
+
+// JumpStmt is a break, continue or discard statement.
+type JumpStmt struct {
+	Span
+	Keyword string
 }
 
 // Expr is an expression.
-type Expr struct {
-	FuncCall *FuncCall `@@`
+type Expr interface {
+	Node
+	exprNode()
+}
+
+// Ident is a reference to a variable, function or type by name.
+type Ident struct {
+	Span
+	Name string
+}
+
+// LiteralKind is the type of a literal value.
+type LiteralKind int
+
+// Literal kinds.
+const (
+	LiteralInt LiteralKind = iota
+	LiteralUint
+	LiteralFloat
+	LiteralBool
+	LiteralString
+)
+
+// Literal is a numeric or boolean literal, or a string literal in the
+// arguments of a hint. The Value of a string literal includes its quotes.
+type Literal struct {
+	Span
+	Kind  LiteralKind
+	Value string
+}
+
+// UnaryExpr is a prefix or postfix unary operation.
+type UnaryExpr struct {
+	Span
+	Op      string
+	X       Expr
+	Postfix bool
+}
+
+// BinaryExpr is a binary operation.
+type BinaryExpr struct {
+	Span
+	Op string
+	X  Expr
+	Y  Expr
 }
 
-// FuncCall is a function call exporession.
-type FuncCall struct {
-	FuncName string   `@Ident`
-	LParen   string   `@"("`
-	Args     []string `@Ident ("," @Ident)*`
-	RParen   string   `@")"`
+// AssignExpr is an assignment or compound assignment.
+type AssignExpr struct {
+	Span
+	Op    string
+	Left  Expr
+	Right Expr
 }
+
+// TernaryExpr is a conditional expression.
+type TernaryExpr struct {
+	Span
+	Cond Expr
+	Then Expr
+	Else Expr
+}
+
+// CallExpr is a function call, a type constructor or a method call. Func
+// is an *Ident, except for method calls such as arr.length(), where it is a
+// *MemberExpr.
+type CallExpr struct {
+	Span
+	Func Expr
+	Args []Expr
+}
+
+// Callee returns the function or type that is called by name, or nil for a
+// method call.
+func (x *CallExpr) Callee() *Ident {
+	ident, _ := x.Func.(*Ident)
+	return ident
+}
+
+// MemberExpr is a struct field access or a vector swizzle.
+type MemberExpr struct {
+	Span
+	X        Expr
+	Name     string
+	NameSpan Span
+}
+
+// IndexExpr is an array or vector index.
+type IndexExpr struct {
+	Span
+	X     Expr
+	Index Expr
+}
+
+// ParenExpr is a parenthesized expression.
+type ParenExpr struct {
+	Span
+	X Expr
+}
+
+// InitListExpr is a brace-enclosed array initializer.
+type InitListExpr struct {
+	Span
+	Elems []Expr
+}
+
+// BadExpr is a placeholder for an expression that failed to parse.
+type BadExpr struct {
+	Span
+}
+
+func (*Ident) exprNode()        {}
+func (*Literal) exprNode()      {}
+func (*UnaryExpr) exprNode()    {}
+func (*BinaryExpr) exprNode()   {}
+func (*AssignExpr) exprNode()   {}
+func (*TernaryExpr) exprNode()  {}
+func (*CallExpr) exprNode()     {}
+func (*MemberExpr) exprNode()   {}
+func (*IndexExpr) exprNode()    {}
+func (*ParenExpr) exprNode()    {}
+func (*InitListExpr) exprNode() {}
+func (*BadExpr) exprNode()      {}
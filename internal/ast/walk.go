@@ -0,0 +1,116 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package ast
+
+import "reflect"
+
+// Inspect traverses the AST in depth-first order. It calls f for each node,
+// and descends into the node's children if f returns true. Nil nodes are
+// skipped.
+func Inspect(node Node, f func(Node) bool) {
+	if isNil(node) || !f(node) {
+		return
+	}
+
+	var children []Node
+
+	switch n := node.(type) {
+	case *File:
+		for _, decl := range n.Declarations {
+			children = append(children, decl)
+		}
+	case *Declaration:
+		children = append(children, n.ShaderType, n.RenderMode, n.UniformDecl, n.GroupUniforms, n.VaryingDecl, n.ConstDecl, n.StructDecl, n.FunctionDecl, n.Directive)
+	case *RenderModeDecl:
+		for _, mode := range n.Modes {
+			children = append(children, mode)
+		}
+	case *UniformDecl:
+		children = append(children, n.ArraySize)
+		for _, hint := range n.Hints {
+			children = append(children, hint)
+		}
+		children = append(children, n.Default)
+	case *Hint:
+		children = appendExprs(children, n.Args)
+	case *VaryingDecl:
+		children = append(children, n.ArraySize)
+	case *VarDecl:
+		for _, v := range n.Vars {
+			children = append(children, v)
+		}
+	case *Declarator:
+		children = append(children, n.ArraySize, n.Init)
+	case *StructDecl:
+		for _, field := range n.Fields {
+			children = append(children, field)
+		}
+	case *FunctionDecl:
+		for _, param := range n.Params {
+			children = append(children, param)
+		}
+		children = append(children, n.Body)
+	case *Param:
+		children = append(children, n.ArraySize)
+	case *BlockStmt:
+		for _, stmt := range n.Stmts {
+			children = append(children, stmt)
+		}
+	case *Stmt:
+		children = append(children, n.Block, n.VarDecl, n.Expr, n.If, n.For, n.While, n.DoWhile, n.Switch, n.Case, n.Return, n.Jump)
+	case *IfStmt:
+		children = append(children, n.Cond, n.Then, n.Else)
+	case *ForStmt:
+		children = append(children, n.Init, n.Cond, n.Post, n.Body)
+	case *WhileStmt:
+		children = append(children, n.Cond, n.Body)
+	case *DoWhileStmt:
+		children = append(children, n.Body, n.Cond)
+	case *SwitchStmt:
+		children = append(children, n.Tag, n.Body)
+	case *CaseStmt:
+		children = append(children, n.Value)
+	case *ReturnStmt:
+		children = append(children, n.Value)
+	case *UnaryExpr:
+		children = append(children, n.X)
+	case *BinaryExpr:
+		children = append(children, n.X, n.Y)
+	case *AssignExpr:
+		children = append(children, n.Left, n.Right)
+	case *TernaryExpr:
+		children = append(children, n.Cond, n.Then, n.Else)
+	case *CallExpr:
+		children = append(children, n.Func)
+		children = appendExprs(children, n.Args)
+	case *MemberExpr:
+		children = append(children, n.X)
+	case *IndexExpr:
+		children = append(children, n.X, n.Index)
+	case *ParenExpr:
+		children = append(children, n.X)
+	case *InitListExpr:
+		children = appendExprs(children, n.Elems)
+	}
+
+	for _, child := range children {
+		Inspect(child, f)
+	}
+}
+
+func appendExprs(nodes []Node, exprs []Expr) []Node {
+	for _, expr := range exprs {
+		nodes = append(nodes, expr)
+	}
+	return nodes
+}
+
+// isNil reports whether the node is nil, including typed nil pointers.
+func isNil(node Node) bool {
+	if node == nil {
+		return true
+	}
+	v := reflect.ValueOf(node)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
@@ -0,0 +1,461 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package godot
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ConfigFile is a file in the text format that Godot uses for project.godot,
+// and for text resources and scenes. It is a list of sections with a tag in
+// brackets, each followed by properties with values.
+type ConfigFile struct {
+	Sections []*Section
+}
+
+// Section is a tag in brackets, such as [application] or
+// [ext_resource type="Shader" path="res://water.gdshader" id="1"], and the
+// properties that follow it. Properties before the first tag are in a
+// section with an empty name.
+type Section struct {
+	Name       string
+	Attributes []Property
+	Properties []Property
+	// Start is the offset of the tag.
+	Start int
+}
+
+// Property is a key and its value, either an attribute of a tag or a
+// property of a section.
+type Property struct {
+	Key   string
+	Value Value
+	// Start is the offset of the key.
+	Start int
+}
+
+// Section returns the first section with a name, or nil if there is none.
+func (f *ConfigFile) Section(name string) *Section {
+	for _, s := range f.Sections {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// Get returns the value of a property.
+func (s *Section) Get(key string) (Value, bool) {
+	return getProperty(s.Properties, key)
+}
+
+// Attribute returns the value of an attribute of the tag.
+func (s *Section) Attribute(key string) (Value, bool) {
+	return getProperty(s.Attributes, key)
+}
+
+func getProperty(properties []Property, key string) (Value, bool) {
+	for _, p := range properties {
+		if p.Key == key {
+			return p.Value, true
+		}
+	}
+	return Value{}, false
+}
+
+// SyntaxError is a problem in the syntax of a config file.
+type SyntaxError struct {
+	Offset int
+	// Line is the line of the offset, counting from 1.
+	Line    int
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// ParseConfigFile parses a file in the Godot text format. If there is a
+// syntax error, it returns the sections up to the error along with a
+// *SyntaxError, since the rest of the file can't be made sense of.
+func ParseConfigFile(reader io.Reader) (*ConfigFile, error) {
+	src, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &configParser{src: string(src)}
+	f := &ConfigFile{}
+	err = p.parse(f)
+	return f, err
+}
+
+type configParser struct {
+	src string
+	pos int
+}
+
+func (p *configParser) parse(f *ConfigFile) error {
+	var section *Section
+	for {
+		p.skipSpaceAndComments()
+		if p.pos >= len(p.src) {
+			return nil
+		}
+
+		if p.src[p.pos] == '[' {
+			tag, err := p.parseTag()
+			if err != nil {
+				return err
+			}
+			section = tag
+			f.Sections = append(f.Sections, section)
+			continue
+		}
+
+		if section == nil {
+			section = &Section{}
+			f.Sections = append(f.Sections, section)
+		}
+		property, err := p.parseProperty()
+		if err != nil {
+			return err
+		}
+		section.Properties = append(section.Properties, property)
+	}
+}
+
+// parseTag parses a tag such as [name key=value key=value].
+func (p *configParser) parseTag() (*Section, error) {
+	section := &Section{Start: p.pos}
+	p.pos++
+
+	p.skipInlineSpace()
+	section.Name = p.scanWhile(func(r rune) bool { return r != ']' && !unicode.IsSpace(r) })
+	if section.Name == "" {
+		return nil, p.errorf("missing tag name")
+	}
+
+	for {
+		p.skipInlineSpace()
+		if p.pos >= len(p.src) || p.src[p.pos] == '\n' {
+			return nil, p.errorf("unterminated tag")
+		}
+		if p.src[p.pos] == ']' {
+			p.pos++
+			return section, nil
+		}
+
+		start := p.pos
+		key := p.scanWhile(func(r rune) bool { return r != '=' && r != ']' && !unicode.IsSpace(r) })
+		if key == "" || !p.consume('=') {
+			return nil, p.errorf("expected key=value in tag")
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		section.Attributes = append(section.Attributes, Property{Key: key, Value: value, Start: start})
+	}
+}
+
+// parseProperty parses a line such as key=value. The value can span
+// several lines.
+func (p *configParser) parseProperty() (Property, error) {
+	property := Property{Start: p.pos}
+
+	if p.src[p.pos] == '"' {
+		key, err := p.parseString()
+		if err != nil {
+			return Property{}, err
+		}
+		property.Key = key
+		p.skipInlineSpace()
+	} else {
+		property.Key = strings.TrimSpace(p.scanWhile(func(r rune) bool { return r != '=' && r != '\n' }))
+	}
+	if property.Key == "" || !p.consume('=') {
+		return Property{}, p.errorf("expected key=value")
+	}
+
+	value, err := p.parseValue()
+	if err != nil {
+		return Property{}, err
+	}
+	property.Value = value
+	return property, nil
+}
+
+func (p *configParser) parseValue() (Value, error) {
+	p.skipSpaceAndComments()
+	if p.pos >= len(p.src) {
+		return Value{}, p.errorf("expected value")
+	}
+
+	v := Value{Start: p.pos}
+	var err error
+	switch c := p.src[p.pos]; {
+	case c == '"':
+		v.Kind = KindString
+		v.Str, err = p.parseString()
+	case (c == '&' || c == '^') && p.pos+1 < len(p.src) && p.src[p.pos+1] == '"':
+		v.Kind = KindStringName
+		if c == '^' {
+			v.Kind = KindNodePath
+		}
+		p.pos++
+		v.Str, err = p.parseString()
+	case c == '[':
+		v.Kind = KindArray
+		p.pos++
+		v.Items, err = p.parseList(']')
+	case c == '{':
+		v.Kind = KindDictionary
+		p.pos++
+		v.Entries, err = p.parseEntries('}')
+	case c == '-' || c == '+' || c == '.' || ('0' <= c && c <= '9'):
+		v.Kind = KindNumber
+		err = p.parseNumber(&v)
+	case c == '_' || unicode.IsLetter(rune(c)):
+		err = p.parseIdentifierValue(&v)
+	default:
+		r, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+		return Value{}, p.errorf("unexpected %q", r)
+	}
+	if err != nil {
+		return Value{}, err
+	}
+
+	v.End = p.pos
+	v.Text = p.src[v.Start:v.End]
+	return v, nil
+}
+
+// parseIdentifierValue parses a keyword such as true, or a constructor
+// such as Vector2(1, 2).
+func (p *configParser) parseIdentifierValue(v *Value) error {
+	name := p.scanWhile(func(r rune) bool { return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) })
+
+	if p.consume('(') {
+		v.Kind = KindConstructor
+		v.Str = name
+		// Objects have properties, such as Object(Node, "name":"x"), which
+		// are kept as entries after the plain arguments.
+		for {
+			p.skipSpaceAndComments()
+			if p.consume(')') {
+				return nil
+			}
+			item, err := p.parseValue()
+			if err != nil {
+				return err
+			}
+			p.skipSpaceAndComments()
+			if p.consume(':') {
+				value, err := p.parseValue()
+				if err != nil {
+					return err
+				}
+				v.Entries = append(v.Entries, Entry{Key: item, Value: value})
+			} else {
+				v.Items = append(v.Items, item)
+			}
+			p.skipSpaceAndComments()
+			if !p.consume(',') && (p.pos >= len(p.src) || p.src[p.pos] != ')') {
+				return p.errorf("expected , or ) in %s", name)
+			}
+		}
+	}
+
+	switch name {
+	case "true", "false":
+		v.Kind = KindBool
+		v.Bool = name == "true"
+	case "null", "nil":
+		v.Kind = KindNull
+	case "inf":
+		v.Kind = KindNumber
+		v.Number = math.Inf(1)
+	case "inf_neg":
+		v.Kind = KindNumber
+		v.Number = math.Inf(-1)
+	case "nan":
+		v.Kind = KindNumber
+		v.Number = math.NaN()
+	default:
+		return p.errorf("unknown value %s", name)
+	}
+	return nil
+}
+
+func (p *configParser) parseNumber(v *Value) error {
+	start := p.pos
+	text := p.scanWhile(func(r rune) bool {
+		return r == '-' || r == '+' || r == '.' || r == '_' || unicode.IsDigit(r) || unicode.IsLetter(r)
+	})
+	n, err := strconv.ParseFloat(strings.ReplaceAll(text, "_", ""), 64)
+	if err != nil {
+		if i, intErr := strconv.ParseInt(text, 0, 64); intErr == nil {
+			n, err = float64(i), nil
+		}
+	}
+	if err != nil {
+		p.pos = start
+		return p.errorf("bad number %s", text)
+	}
+	v.Number = n
+	return nil
+}
+
+// parseList parses comma-separated values until the closing character.
+func (p *configParser) parseList(closing byte) ([]Value, error) {
+	var items []Value
+	for {
+		p.skipSpaceAndComments()
+		if p.consume(closing) {
+			return items, nil
+		}
+		item, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		p.skipSpaceAndComments()
+		if !p.consume(',') && (p.pos >= len(p.src) || p.src[p.pos] != closing) {
+			return nil, p.errorf("expected , or %c", closing)
+		}
+	}
+}
+
+// parseEntries parses comma-separated key: value pairs until the closing
+// character.
+func (p *configParser) parseEntries(closing byte) ([]Entry, error) {
+	var entries []Entry
+	for {
+		p.skipSpaceAndComments()
+		if p.consume(closing) {
+			return entries, nil
+		}
+		key, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpaceAndComments()
+		if !p.consume(':') {
+			return nil, p.errorf("expected : after dictionary key")
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, Entry{Key: key, Value: value})
+		p.skipSpaceAndComments()
+		if !p.consume(',') && (p.pos >= len(p.src) || p.src[p.pos] != closing) {
+			return nil, p.errorf("expected , or %c", closing)
+		}
+	}
+}
+
+// parseString parses a quoted string, which can span several lines.
+func (p *configParser) parseString() (string, error) {
+	start := p.pos
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		p.pos++
+		switch c {
+		case '"':
+			return b.String(), nil
+		case '\\':
+			if p.pos >= len(p.src) {
+				break
+			}
+			e := p.src[p.pos]
+			p.pos++
+			switch e {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case 'u':
+				if p.pos+4 <= len(p.src) {
+					if r, err := strconv.ParseUint(p.src[p.pos:p.pos+4], 16, 32); err == nil {
+						b.WriteRune(rune(r))
+						p.pos += 4
+						continue
+					}
+				}
+				b.WriteString(`\u`)
+			default:
+				b.WriteByte(e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	p.pos = start
+	return "", p.errorf("unterminated string")
+}
+
+// skipSpaceAndComments skips whitespace, including newlines, and comments,
+// which start with a semicolon and end at the end of the line.
+func (p *configParser) skipSpaceAndComments() {
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; {
+		case c == ';':
+			if i := strings.IndexByte(p.src[p.pos:], '\n'); i >= 0 {
+				p.pos += i
+			} else {
+				p.pos = len(p.src)
+			}
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *configParser) skipInlineSpace() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t' || p.src[p.pos] == '\r') {
+		p.pos++
+	}
+}
+
+func (p *configParser) scanWhile(accept func(r rune) bool) string {
+	start := p.pos
+	for p.pos < len(p.src) {
+		r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+		if !accept(r) {
+			break
+		}
+		p.pos += size
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *configParser) consume(c byte) bool {
+	if p.pos < len(p.src) && p.src[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *configParser) errorf(format string, args ...any) error {
+	return &SyntaxError{
+		Offset:  p.pos,
+		Line:    strings.Count(p.src[:p.pos], "\n") + 1,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
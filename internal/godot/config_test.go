@@ -0,0 +1,112 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package godot_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/armsnyder/gdshader-language-server/internal/godot"
+	"github.com/samber/lo"
+)
+
+func TestParseConfigFile(t *testing.T) {
+	g := NewWithT(t)
+	const src = `; Engine configuration file.
+config_version=5
+
+[gd_resource type="ShaderMaterial" load_steps=2 format=3]
+
+[ext_resource type="Shader" path="res://water.gdshader" id="1_abc"]
+
+[resource]
+shader = ExtResource("1_abc")
+shader_parameter/tint = Color(1, 0.5, 0, 1)
+"quoted key" = &"name"
+path=^"../Node"
+list=[1, -2.5e1, "three", null, inf_neg]
+dict={
+"type": "float",
+"value": true
+}
+multiline="one
+two \"quoted\" é"
+`
+
+	f, err := godot.ParseConfigFile(strings.NewReader(src))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(f.Sections).To(HaveLen(4))
+
+	g.Expect(f.Sections[0].Name).To(BeEmpty())
+	g.Expect(lo.Must(f.Sections[0].Get("config_version"))).To(HaveField("Number", 5.0))
+
+	g.Expect(lo.Must(f.Section("gd_resource").Attribute("type"))).To(HaveField("Str", "ShaderMaterial"))
+	g.Expect(lo.Must(f.Section("ext_resource").Attribute("id"))).To(HaveField("Str", "1_abc"))
+
+	resource := f.Section("resource")
+	g.Expect(resource.Properties).To(HaveLen(7))
+
+	shader, _ := resource.Get("shader")
+	g.Expect(shader.Kind).To(Equal(godot.KindConstructor))
+	g.Expect(shader.Str).To(Equal("ExtResource"))
+	g.Expect(shader.Strings()).To(Equal([]string{"1_abc"}))
+
+	tint, _ := resource.Get("shader_parameter/tint")
+	g.Expect(tint.Text).To(Equal("Color(1, 0.5, 0, 1)"))
+	g.Expect(src[tint.Start:tint.End]).To(Equal(tint.Text))
+	g.Expect(tint.Items).To(HaveLen(4))
+	g.Expect(src[resource.Properties[1].Start:]).To(HavePrefix("shader_parameter/tint"))
+
+	g.Expect(lo.Must(resource.Get("quoted key"))).To(And(HaveField("Kind", godot.KindStringName), HaveField("Str", "name")))
+	g.Expect(lo.Must(resource.Get("path"))).To(And(HaveField("Kind", godot.KindNodePath), HaveField("Str", "../Node")))
+
+	list, _ := resource.Get("list")
+	g.Expect(list.Items).To(HaveLen(5))
+	g.Expect(list.Items[1].Number).To(Equal(-25.0))
+	g.Expect(list.Items[3].Kind).To(Equal(godot.KindNull))
+	g.Expect(list.Strings()).To(Equal([]string{"three"}))
+
+	dict, _ := resource.Get("dict")
+	g.Expect(lo.Must(dict.Lookup("type"))).To(HaveField("Str", "float"))
+	g.Expect(lo.Must(dict.Lookup("value"))).To(HaveField("Bool", true))
+
+	g.Expect(lo.Must(resource.Get("multiline"))).To(HaveField("Str", "one\ntwo \"quoted\" é"))
+}
+
+func TestParseConfigFile_SyntaxError(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		wantLine int
+		wantKeys []string
+	}{
+		{name: "MissingValue", src: "[a]\nx=1\ny=\n", wantLine: 4, wantKeys: []string{"x"}},
+		{name: "UnterminatedTag", src: "[a]\nx=1\n[b\ny=2\n", wantLine: 3, wantKeys: []string{"x"}},
+		{name: "UnterminatedDictionary", src: "[a]\nx={\n\"a\": 1\n", wantLine: 4},
+		{name: "UnterminatedString", src: "[a]\nx=1\ny=\"abc\n", wantLine: 3, wantKeys: []string{"x"}},
+		{name: "UnknownValue", src: "[a]\nx=maybe\n", wantLine: 2},
+		{name: "MissingEquals", src: "[a]\nx\n", wantLine: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			f, err := godot.ParseConfigFile(strings.NewReader(tt.src))
+
+			var syntaxErr *godot.SyntaxError
+			g.Expect(err).To(BeAssignableToTypeOf(syntaxErr))
+			g.Expect(err.(*godot.SyntaxError).Line).To(Equal(tt.wantLine))
+
+			var keys []string
+			for _, s := range f.Sections {
+				for _, p := range s.Properties {
+					keys = append(keys, p.Key)
+				}
+			}
+			g.Expect(keys).To(Equal(tt.wantKeys))
+		})
+	}
+}
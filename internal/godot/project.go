@@ -0,0 +1,175 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package godot
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ProjectFile is the name of the file at the root of every Godot project.
+const ProjectFile = "project.godot"
+
+// Project is the configuration of a Godot project, from its project.godot.
+type Project struct {
+	// Root is the directory of project.godot, which res:// paths are
+	// relative to.
+	Root string
+	// ConfigVersion is 5 for Godot 4 projects, and 4 for Godot 3 projects.
+	ConfigVersion int
+	Name          string
+	// Features are the engine features that the project was made with, such
+	// as "4.3" and "Forward Plus".
+	Features      []string
+	Autoloads     []Autoload
+	ShaderGlobals []ShaderGlobal
+}
+
+// Autoload is a script or scene that Godot loads when the project starts.
+type Autoload struct {
+	Name string
+	// Path is the res:// path of the script or scene.
+	Path string
+	// Singleton is set if the autoload is available as a global variable.
+	Singleton bool
+}
+
+// ShaderGlobal is a global shader uniform, which every shader of the
+// project can declare with "global uniform".
+type ShaderGlobal struct {
+	Name string
+	// Type is the type of the uniform as project.godot writes it, which is
+	// a shader type such as "vec3" or "sampler2D", or one of "color",
+	// "transform_2d" and "transform".
+	Type  string
+	Value Value
+	// Start is the offset of the name in project.godot.
+	Start int
+}
+
+// FindProject returns the directory of the nearest project.godot, looking
+// in dir and then its parents.
+func FindProject(dir string) (string, bool) {
+	for {
+		if info, err := os.Stat(filepath.Join(dir, ProjectFile)); err == nil && !info.IsDir() {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// LoadProject reads the project.godot in a directory. Like ParseProject, it
+// returns what it could make sense of along with a syntax error.
+func LoadProject(root string) (*Project, error) {
+	f, err := os.Open(filepath.Join(root, ProjectFile))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseProject(root, f)
+}
+
+// ParseProject parses a project.godot of a project in the root directory.
+// If there is a syntax error, it returns the settings before the error
+// along with a *SyntaxError.
+func ParseProject(root string, reader io.Reader) (*Project, error) {
+	config, err := ParseConfigFile(reader)
+	if config == nil {
+		return nil, err
+	}
+
+	p := &Project{Root: root}
+
+	if s := config.Section(""); s != nil {
+		if v, ok := s.Get("config_version"); ok && v.Kind == KindNumber {
+			p.ConfigVersion = int(v.Number)
+		}
+	}
+
+	if s := config.Section("application"); s != nil {
+		if v, ok := s.Get("config/name"); ok {
+			p.Name = v.Str
+		}
+		if v, ok := s.Get("config/features"); ok {
+			p.Features = v.Strings()
+		}
+	}
+
+	if s := config.Section("autoload"); s != nil {
+		for _, property := range s.Properties {
+			path, singleton := strings.CutPrefix(property.Value.Str, "*")
+			p.Autoloads = append(p.Autoloads, Autoload{Name: property.Key, Path: path, Singleton: singleton})
+		}
+	}
+
+	if s := config.Section("shader_globals"); s != nil {
+		for _, property := range s.Properties {
+			global := ShaderGlobal{Name: property.Key, Start: property.Start}
+			if v, ok := property.Value.Lookup("type"); ok {
+				global.Type = v.Str
+			}
+			global.Value, _ = property.Value.Lookup("value")
+			p.ShaderGlobals = append(p.ShaderGlobals, global)
+		}
+	}
+
+	return p, err
+}
+
+var versionFeature = regexp.MustCompile(`^\d+\.\d+$`)
+
+// EngineVersion returns the version of Godot that the project was made
+// with, such as "4.3", or an empty string if it isn't known.
+func (p *Project) EngineVersion() string {
+	for _, feature := range p.Features {
+		if versionFeature.MatchString(feature) {
+			return feature
+		}
+	}
+	return ""
+}
+
+// ShaderGlobal returns the global shader uniform with a name.
+func (p *Project) ShaderGlobal(name string) (ShaderGlobal, bool) {
+	for _, global := range p.ShaderGlobals {
+		if global.Name == name {
+			return global, true
+		}
+	}
+	return ShaderGlobal{}, false
+}
+
+// ResolvePath converts a res:// path to a path on disk. It reports false if
+// the path is not a res:// path, or if it leaves the project.
+func (p *Project) ResolvePath(resPath string) (string, bool) {
+	rel, ok := strings.CutPrefix(resPath, "res://")
+	if !ok {
+		return "", false
+	}
+	rel = filepath.FromSlash(rel)
+	if !filepath.IsLocal(rel) && rel != "" {
+		return "", false
+	}
+	return filepath.Join(p.Root, rel), true
+}
+
+// ResPath converts a path on disk to a res:// path. It reports false if the
+// path is not in the project.
+func (p *Project) ResPath(path string) (string, bool) {
+	rel, err := filepath.Rel(p.Root, path)
+	if err != nil || !filepath.IsLocal(rel) {
+		return "", false
+	}
+	if rel == "." {
+		return "res://", true
+	}
+	return "res://" + filepath.ToSlash(rel), true
+}
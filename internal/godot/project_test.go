@@ -0,0 +1,117 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package godot_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/armsnyder/gdshader-language-server/internal/godot"
+	"github.com/samber/lo"
+)
+
+const projectGodot = `; Engine configuration file.
+; It's best edited using the editor UI and not directly,
+; since the parameters that go here are not all obvious.
+
+config_version=5
+
+[application]
+
+config/name="Water Demo"
+run/main_scene="res://main.tscn"
+config/features=PackedStringArray("4.3", "Forward Plus")
+config/icon="res://icon.svg"
+
+[autoload]
+
+Global="*res://global.gd"
+Loader="res://loader.gd"
+
+[shader_globals]
+
+wind_strength={
+"type": "float",
+"value": 0.5
+}
+sky_tint={
+"type": "color",
+"value": Color(0.2, 0.4, 1, 1)
+}
+`
+
+func TestParseProject(t *testing.T) {
+	g := NewWithT(t)
+	root := filepath.FromSlash("/games/water")
+
+	p, err := godot.ParseProject(root, strings.NewReader(projectGodot))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(p.Root).To(Equal(root))
+	g.Expect(p.ConfigVersion).To(Equal(5))
+	g.Expect(p.Name).To(Equal("Water Demo"))
+	g.Expect(p.Features).To(Equal([]string{"4.3", "Forward Plus"}))
+	g.Expect(p.EngineVersion()).To(Equal("4.3"))
+	g.Expect(p.Autoloads).To(Equal([]godot.Autoload{
+		{Name: "Global", Path: "res://global.gd", Singleton: true},
+		{Name: "Loader", Path: "res://loader.gd"},
+	}))
+
+	g.Expect(p.ShaderGlobals).To(HaveLen(2))
+	wind := lo.Must(p.ShaderGlobal("wind_strength"))
+	g.Expect(wind.Type).To(Equal("float"))
+	g.Expect(wind.Value.Number).To(Equal(0.5))
+	g.Expect(projectGodot[wind.Start:]).To(HavePrefix("wind_strength="))
+	g.Expect(lo.Must(p.ShaderGlobal("sky_tint")).Value.Text).To(Equal("Color(0.2, 0.4, 1, 1)"))
+	_, ok := p.ShaderGlobal("missing")
+	g.Expect(ok).To(BeFalse())
+}
+
+func TestParseProject_SyntaxError(t *testing.T) {
+	g := NewWithT(t)
+
+	p, err := godot.ParseProject("/", strings.NewReader("config_version=5\n\n[application]\nconfig/name=\"Broken\"\nconfig/features=PackedStringArray(\"4.3\"\n"))
+	g.Expect(err).To(MatchError(ContainSubstring("line 6")))
+	g.Expect(p.ConfigVersion).To(Equal(5))
+	g.Expect(p.Name).To(Equal("Broken"))
+}
+
+func TestProject_Paths(t *testing.T) {
+	g := NewWithT(t)
+	root := filepath.FromSlash("/games/water")
+	p := &godot.Project{Root: root}
+
+	g.Expect(lo.Must(p.ResolvePath("res://shaders/water.gdshader"))).To(Equal(filepath.Join(root, "shaders", "water.gdshader")))
+	g.Expect(lo.Must(p.ResolvePath("res://"))).To(Equal(root))
+	_, ok := p.ResolvePath("res://../secret")
+	g.Expect(ok).To(BeFalse())
+	_, ok = p.ResolvePath("shaders/water.gdshader")
+	g.Expect(ok).To(BeFalse())
+
+	g.Expect(lo.Must(p.ResPath(filepath.Join(root, "shaders", "water.gdshader")))).To(Equal("res://shaders/water.gdshader"))
+	g.Expect(lo.Must(p.ResPath(root))).To(Equal("res://"))
+	_, ok = p.ResPath(filepath.FromSlash("/games/other/water.gdshader"))
+	g.Expect(ok).To(BeFalse())
+}
+
+func TestFindProject(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+	root := filepath.Join(dir, "game")
+	shaders := filepath.Join(root, "shaders", "water")
+	lo.Must0(os.MkdirAll(shaders, 0o755))
+	lo.Must0(os.WriteFile(filepath.Join(root, godot.ProjectFile), []byte(projectGodot), 0o644))
+
+	g.Expect(lo.Must(godot.FindProject(shaders))).To(Equal(root))
+	g.Expect(lo.Must(godot.FindProject(root))).To(Equal(root))
+	_, ok := godot.FindProject(dir)
+	g.Expect(ok).To(BeFalse())
+
+	p, err := godot.LoadProject(root)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(p.Name).To(Equal("Water Demo"))
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package godot
+
+import (
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ShaderParameterPrefix is the prefix of the properties of a ShaderMaterial
+// that set the uniforms of its shader.
+const ShaderParameterPrefix = "shader_parameter/"
+
+// Resource is a text resource (.tres) or scene (.tscn). Only what refers
+// to shaders is kept.
+type Resource struct {
+	// Type is the class of the resource, such as "ShaderMaterial", or
+	// "PackedScene" for scenes.
+	Type         string
+	ExtResources []ExtResource
+	Materials    []ShaderMaterial
+}
+
+// ExtResource is a resource in another file that a resource refers to.
+type ExtResource struct {
+	ID   string
+	Type string
+	// Path is the res:// path of the file.
+	Path string
+}
+
+// ShaderMaterial is a material in a resource, which renders with a shader.
+type ShaderMaterial struct {
+	// ID is the id of the sub-resource, or empty if the material is the
+	// resource itself.
+	ID string
+	// ShaderPath is the res:// path of the shader file, or empty if the
+	// shader is not set or is embedded in the resource.
+	ShaderPath string
+	Parameters []ShaderParameter
+	// Start is the offset of the tag of the material.
+	Start int
+}
+
+// ShaderParameter is the value that a material gives to a uniform.
+type ShaderParameter struct {
+	// Name is the name of the uniform.
+	Name  string
+	Value Value
+	// Start is the offset of the property, which starts with
+	// ShaderParameterPrefix.
+	Start int
+}
+
+// IsResourceFile reports whether a path is a text resource or scene.
+func IsResourceFile(path string) bool {
+	ext := filepath.Ext(path)
+	return ext == ".tres" || ext == ".tscn"
+}
+
+// ParseResource parses a text resource or scene. If there is a syntax
+// error, it returns the resource up to the error along with a
+// *SyntaxError.
+func ParseResource(reader io.Reader) (*Resource, error) {
+	config, err := ParseConfigFile(reader)
+	if config == nil {
+		return nil, err
+	}
+
+	r := &Resource{}
+	for i, s := range config.Sections {
+		switch {
+		case i == 0 && s.Name == "gd_scene":
+			r.Type = "PackedScene"
+		case i == 0 && s.Name == "gd_resource":
+			r.Type = attributeString(s, "type")
+		case s.Name == "ext_resource":
+			r.ExtResources = append(r.ExtResources, ExtResource{
+				ID:   attributeString(s, "id"),
+				Type: attributeString(s, "type"),
+				Path: attributeString(s, "path"),
+			})
+		case s.Name == "sub_resource" && attributeString(s, "type") == "ShaderMaterial":
+			r.Materials = append(r.Materials, r.parseMaterial(attributeString(s, "id"), s))
+		case s.Name == "resource" && r.Type == "ShaderMaterial":
+			r.Materials = append(r.Materials, r.parseMaterial("", s))
+		}
+	}
+	return r, err
+}
+
+// parseMaterial reads the shader and parameters of a material. The shader
+// refers to an ext_resource, which comes before it in the file.
+func (r *Resource) parseMaterial(id string, s *Section) ShaderMaterial {
+	m := ShaderMaterial{ID: id, Start: s.Start}
+	if v, ok := s.Get("shader"); ok && v.Kind == KindConstructor && v.Str == "ExtResource" && len(v.Items) == 1 {
+		if ext, ok := r.ExtResource(idString(v.Items[0])); ok {
+			m.ShaderPath = ext.Path
+		}
+	}
+	for _, property := range s.Properties {
+		if name, ok := strings.CutPrefix(property.Key, ShaderParameterPrefix); ok {
+			m.Parameters = append(m.Parameters, ShaderParameter{Name: name, Value: property.Value, Start: property.Start})
+		}
+	}
+	return m
+}
+
+// ExtResource returns the ext_resource with an id.
+func (r *Resource) ExtResource(id string) (ExtResource, bool) {
+	for _, ext := range r.ExtResources {
+		if ext.ID == id {
+			return ext, true
+		}
+	}
+	return ExtResource{}, false
+}
+
+func attributeString(s *Section, key string) string {
+	v, _ := s.Attribute(key)
+	return idString(v)
+}
+
+// idString returns a string value, or a number such as the ids of Godot 3
+// resources as a string.
+func idString(v Value) string {
+	if v.Kind == KindNumber {
+		return strconv.FormatFloat(v.Number, 'f', -1, 64)
+	}
+	return v.Str
+}
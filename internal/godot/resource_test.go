@@ -0,0 +1,119 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package godot_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/armsnyder/gdshader-language-server/internal/godot"
+)
+
+const waterMaterial = `[gd_resource type="ShaderMaterial" load_steps=3 format=3 uid="uid://c5n4nqy8p7ggd"]
+
+[ext_resource type="Shader" uid="uid://b1b7xqrv0r1yl" path="res://water.gdshader" id="1_x4k2m"]
+[ext_resource type="Texture2D" path="res://foam.png" id="2_foam"]
+
+[resource]
+render_priority = 0
+shader = ExtResource("1_x4k2m")
+shader_parameter/strength = 0.5
+shader_parameter/tint = Color(0.2, 0.4, 1, 1)
+shader_parameter/foam = ExtResource("2_foam")
+`
+
+const waterScene = `[gd_scene load_steps=4 format=3]
+
+[ext_resource type="Shader" path="res://water.gdshader" id="1"]
+
+[sub_resource type="Shader" id="Shader_embedded"]
+code = "shader_type spatial;
+uniform float depth;
+"
+
+[sub_resource type="ShaderMaterial" id="ShaderMaterial_water"]
+shader = ExtResource("1")
+shader_parameter/strength = 2.0
+
+[sub_resource type="ShaderMaterial" id="ShaderMaterial_embedded"]
+shader = SubResource("Shader_embedded")
+shader_parameter/depth = 1.0
+
+[node name="Water" type="MeshInstance3D"]
+material_override = SubResource("ShaderMaterial_water")
+`
+
+func TestParseResource_Material(t *testing.T) {
+	g := NewWithT(t)
+
+	r, err := godot.ParseResource(strings.NewReader(waterMaterial))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(r.Type).To(Equal("ShaderMaterial"))
+	g.Expect(r.ExtResources).To(Equal([]godot.ExtResource{
+		{ID: "1_x4k2m", Type: "Shader", Path: "res://water.gdshader"},
+		{ID: "2_foam", Type: "Texture2D", Path: "res://foam.png"},
+	}))
+	g.Expect(r.Materials).To(HaveLen(1))
+
+	m := r.Materials[0]
+	g.Expect(m.ID).To(BeEmpty())
+	g.Expect(m.ShaderPath).To(Equal("res://water.gdshader"))
+	g.Expect(m.Start).To(Equal(strings.Index(waterMaterial, "[resource]")))
+	g.Expect(m.Parameters).To(HaveLen(3))
+	g.Expect(m.Parameters[0].Name).To(Equal("strength"))
+	g.Expect(m.Parameters[0].Value.Number).To(Equal(0.5))
+	g.Expect(m.Parameters[0].Start).To(Equal(strings.Index(waterMaterial, "shader_parameter/strength")))
+	g.Expect(m.Parameters[1].Name).To(Equal("tint"))
+	g.Expect(m.Parameters[1].Value.Text).To(Equal("Color(0.2, 0.4, 1, 1)"))
+	g.Expect(m.Parameters[2].Name).To(Equal("foam"))
+}
+
+func TestParseResource_Scene(t *testing.T) {
+	g := NewWithT(t)
+
+	r, err := godot.ParseResource(strings.NewReader(waterScene))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(r.Type).To(Equal("PackedScene"))
+	g.Expect(r.Materials).To(HaveLen(2))
+	g.Expect(r.Materials[0].ID).To(Equal("ShaderMaterial_water"))
+	g.Expect(r.Materials[0].ShaderPath).To(Equal("res://water.gdshader"))
+	g.Expect(r.Materials[0].Parameters).To(HaveLen(1))
+	// The embedded shader has no file.
+	g.Expect(r.Materials[1].ID).To(Equal("ShaderMaterial_embedded"))
+	g.Expect(r.Materials[1].ShaderPath).To(BeEmpty())
+	g.Expect(r.Materials[1].Parameters[0].Name).To(Equal("depth"))
+}
+
+func TestParseResource_Godot3(t *testing.T) {
+	g := NewWithT(t)
+
+	r, err := godot.ParseResource(strings.NewReader(`[gd_resource type="ShaderMaterial" load_steps=2 format=2]
+
+[ext_resource path="res://water.shader" type="Shader" id=1]
+
+[resource]
+shader = ExtResource( 1 )
+`))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(r.ExtResources[0].ID).To(Equal("1"))
+	g.Expect(r.Materials[0].ShaderPath).To(Equal("res://water.shader"))
+}
+
+func TestParseResource_SyntaxError(t *testing.T) {
+	g := NewWithT(t)
+
+	r, err := godot.ParseResource(strings.NewReader(waterScene + "[node name=\n"))
+	var syntaxError *godot.SyntaxError
+	g.Expect(err).To(BeAssignableToTypeOf(syntaxError))
+	g.Expect(r.Materials).To(HaveLen(2))
+}
+
+func TestIsResourceFile(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(godot.IsResourceFile("water.tres")).To(BeTrue())
+	g.Expect(godot.IsResourceFile("main.tscn")).To(BeTrue())
+	g.Expect(godot.IsResourceFile("water.gdshader")).To(BeFalse())
+}
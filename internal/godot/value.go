@@ -0,0 +1,74 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package godot
+
+// Kind is the kind of a value.
+type Kind int
+
+// Kinds of values.
+const (
+	KindNull Kind = iota
+	KindBool
+	KindNumber
+	KindString
+	KindStringName // &"name"
+	KindNodePath   // ^"path"
+	KindArray
+	KindDictionary
+	// KindConstructor is a typed value, such as Vector3(1, 2, 3),
+	// PackedStringArray("a"), or a reference such as ExtResource("1").
+	KindConstructor
+)
+
+// Value is a value in a Godot text file.
+type Value struct {
+	Kind   Kind
+	Bool   bool
+	Number float64
+	// Str is the content of a string, string name or node path, or the
+	// name of a constructor.
+	Str string
+	// Items are the elements of an array, or the arguments of a constructor.
+	Items []Value
+	// Entries are the entries of a dictionary, or the properties of an
+	// Object constructor.
+	Entries []Entry
+	// Text is the value as it is written in the file.
+	Text string
+	// Start and End are the offsets of the value in the file.
+	Start, End int
+}
+
+// Entry is an entry of a dictionary.
+type Entry struct {
+	Key   Value
+	Value Value
+}
+
+// Lookup returns the value of a dictionary entry with a string key.
+func (v Value) Lookup(key string) (Value, bool) {
+	for _, e := range v.Entries {
+		if e.Key.IsString() && e.Key.Str == key {
+			return e.Value, true
+		}
+	}
+	return Value{}, false
+}
+
+// IsString reports whether the value is a string, string name or node path.
+func (v Value) IsString() bool {
+	return v.Kind == KindString || v.Kind == KindStringName || v.Kind == KindNodePath
+}
+
+// Strings returns the strings in an array or a constructor such as
+// PackedStringArray. Items that are not strings are skipped.
+func (v Value) Strings() []string {
+	var result []string
+	for _, item := range v.Items {
+		if item.IsString() {
+			result = append(result, item.Str)
+		}
+	}
+	return result
+}
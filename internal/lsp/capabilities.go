@@ -0,0 +1,76 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package lsp
+
+import "slices"
+
+// HoverMarkdown reports whether the client can display markdown in hovers.
+func (c *ClientCapabilities) HoverMarkdown() bool {
+	if c.TextDocument == nil || c.TextDocument.Hover == nil {
+		return false
+	}
+	return slices.Contains(c.TextDocument.Hover.ContentFormat, MarkupMarkdown)
+}
+
+// CompletionMarkdown reports whether the client can display markdown in the
+// documentation of completion items.
+func (c *ClientCapabilities) CompletionMarkdown() bool {
+	item := c.completionItem()
+	return item != nil && slices.Contains(item.DocumentationFormat, MarkupMarkdown)
+}
+
+// CompletionSnippets reports whether completion items can insert snippets.
+func (c *ClientCapabilities) CompletionSnippets() bool {
+	item := c.completionItem()
+	return item != nil && item.SnippetSupport
+}
+
+func (c *ClientCapabilities) completionItem() *CompletionItemClientCapabilities {
+	if c.TextDocument == nil || c.TextDocument.Completion == nil {
+		return nil
+	}
+	return c.TextDocument.Completion.CompletionItem
+}
+
+// CompletionKind reports whether the client knows a completion item kind.
+func (c *ClientCapabilities) CompletionKind(kind CompletionItemKind) bool {
+	if c.TextDocument != nil && c.TextDocument.Completion != nil && c.TextDocument.Completion.CompletionItemKind != nil &&
+		len(c.TextDocument.Completion.CompletionItemKind.ValueSet) > 0 {
+		return slices.Contains(c.TextDocument.Completion.CompletionItemKind.ValueSet, kind)
+	}
+	return kind >= CompletionText && kind <= CompletionReference
+}
+
+// HierarchicalDocumentSymbols reports whether the client accepts document
+// symbols with children, rather than a flat list.
+func (c *ClientCapabilities) HierarchicalDocumentSymbols() bool {
+	return c.TextDocument != nil && c.TextDocument.DocumentSymbol != nil && c.TextDocument.DocumentSymbol.HierarchicalDocumentSymbolSupport
+}
+
+// SemanticTokensRelative reports whether the client accepts semantic tokens
+// in the relative format.
+func (c *ClientCapabilities) SemanticTokensRelative() bool {
+	return c.TextDocument != nil && c.TextDocument.SemanticTokens != nil && slices.Contains(c.TextDocument.SemanticTokens.Formats, TokenFormatRelative)
+}
+
+// PullDiagnostics reports whether the client pulls diagnostics. Otherwise,
+// the server must publish them.
+func (c *ClientCapabilities) PullDiagnostics() bool {
+	return c.TextDocument != nil && c.TextDocument.Diagnostic != nil
+}
+
+// WorkDoneProgress reports whether the client can show progress that the
+// server starts.
+func (c *ClientCapabilities) WorkDoneProgress() bool {
+	return c.Window != nil && c.Window.WorkDoneProgress
+}
+
+// PositionEncodings returns the position encodings that the client
+// supports, in order of preference.
+func (c *ClientCapabilities) PositionEncodings() []PositionEncodingKind {
+	if c.General == nil || len(c.General.PositionEncodings) == 0 {
+		return []PositionEncodingKind{PositionEncodingUTF16}
+	}
+	return c.General.PositionEncodings
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Client sends messages from the server to the client.
+type Client interface {
+	Notify(ctx context.Context, method string, params any) error
+	// Request sends a request and waits for the response. The result is
+	// decoded into result, unless it is nil. An error response from the
+	// client is returned as a *ResponseError.
+	Request(ctx context.Context, method string, params, result any) error
+}
+
+// RegisterCapability asks the client to register capabilities dynamically,
+// such as file watchers.
+func RegisterCapability(ctx context.Context, c Client, registrations ...Registration) error {
+	return c.Request(ctx, "client/registerCapability", RegistrationParams{Registrations: registrations}, nil)
+}
+
+// Configuration pulls configuration from the client. There is one result for
+// each item, in the same order.
+func Configuration(ctx context.Context, c Client, items ...ConfigurationItem) ([]json.RawMessage, error) {
+	var result []json.RawMessage
+	err := c.Request(ctx, "workspace/configuration", ConfigurationParams{Items: items}, &result)
+	return result, err
+}
+
+// LogMessage asks the client to log a message.
+func LogMessage(ctx context.Context, c Client, typ MessageType, message string) error {
+	return c.Notify(ctx, "window/logMessage", LogMessageParams{Type: typ, Message: message})
+}
+
+// ShowMessage shows a message to the user. It is for events that the user
+// must act on, since it interrupts them.
+func ShowMessage(ctx context.Context, c Client, typ MessageType, message string) error {
+	return c.Notify(ctx, "window/showMessage", ShowMessageParams{Type: typ, Message: message})
+}
+
+// ShowMessageRequest shows a message with actions to the user. It returns
+// the action that the user chose, or nil if they dismissed the message.
+func ShowMessageRequest(ctx context.Context, c Client, params ShowMessageRequestParams) (*MessageActionItem, error) {
+	var result *MessageActionItem
+	err := c.Request(ctx, "window/showMessageRequest", params, &result)
+	return result, err
+}
+
+// CreateWorkDoneProgress asks the client to create a progress indicator
+// that the server reports to with the token.
+func CreateWorkDoneProgress(ctx context.Context, c Client, token ProgressToken) error {
+	return c.Request(ctx, "window/workDoneProgress/create", WorkDoneProgressCreateParams{Token: token}, nil)
+}
+
+// ApplyEdit asks the client to apply a workspace edit.
+func ApplyEdit(ctx context.Context, c Client, params ApplyWorkspaceEditParams) (*ApplyWorkspaceEditResult, error) {
+	var result ApplyWorkspaceEditResult
+	if err := c.Request(ctx, "workspace/applyEdit", params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
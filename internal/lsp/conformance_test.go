@@ -0,0 +1,204 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package lsp_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+)
+
+const (
+	initializeRequest = `{"jsonrpc":"2.0","id":0,"method":"initialize","params":{"capabilities":{}}}`
+	completionRequest = `{"jsonrpc":"2.0","id":1,"method":"textDocument/completion","params":{"textDocument":{"uri":"file:///a.gdshader"}}}`
+	completionResult  = `{"jsonrpc":"2.0","id":1,"result":{"isIncomplete":false,"items":[]}}`
+	initializeResult  = `{"jsonrpc":"2.0","id":0,"result":{"capabilities":{"textDocumentSync":{"openClose":true,"change":2},"completionProvider":{},"hoverProvider":true},"serverInfo":{"name":"","version":""}}}`
+)
+
+func TestConformance_Lifecycle(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []string
+		want     []string
+	}{
+		{
+			name:     "RequestBeforeInitialize",
+			messages: []string{completionRequest},
+			want:     []string{`{"jsonrpc":"2.0","id":1,"error":{"code":-32002,"message":"Server not initialized"}}`},
+		},
+		{
+			name: "NotificationBeforeInitialize",
+			messages: []string{
+				`{"jsonrpc":"2.0","method":"textDocument/didChange","params":{"textDocument":{"uri":"file:///a.gdshader"},"contentChanges":[]}}`,
+				initializeRequest,
+			},
+			want: []string{initializeResult},
+		},
+		{
+			name:     "InitializeTwice",
+			messages: []string{initializeRequest, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"capabilities":{}}}`},
+			want: []string{
+				initializeResult,
+				`{"jsonrpc":"2.0","id":1,"error":{"code":-32600,"message":"Server already initialized"}}`,
+			},
+		},
+		{
+			name:     "RequestAfterShutdown",
+			messages: []string{initializeRequest, `{"jsonrpc":"2.0","id":2,"method":"shutdown"}`, completionRequest},
+			want: []string{
+				initializeResult,
+				`{"jsonrpc":"2.0","id":2,"result":null}`,
+				`{"jsonrpc":"2.0","id":1,"error":{"code":-32600,"message":"Server is shut down"}}`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			send, received, _ := serve(t, &lsp.Server{Handler: &slowHandler{}})
+
+			for _, message := range tt.messages {
+				send(message)
+			}
+			// Rejections are written right away, so they may overtake the
+			// responses to earlier requests.
+			var got []string
+			for range tt.want {
+				var message string
+				g.Eventually(received).Should(Receive(&message))
+				got = append(got, message)
+			}
+			g.Expect(got).To(ConsistOf(tt.want))
+			g.Consistently(received, 50*time.Millisecond).ShouldNot(Receive())
+		})
+	}
+}
+
+func TestConformance_Exit(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []string
+		wantErr  error
+	}{
+		{
+			name:     "AfterShutdown",
+			messages: []string{initializeRequest, `{"jsonrpc":"2.0","id":1,"method":"shutdown"}`},
+		},
+		{
+			name:     "WithoutShutdown",
+			messages: []string{initializeRequest},
+			wantErr:  lsp.ErrExitWithoutShutdown,
+		},
+		{
+			name:    "BeforeInitialize",
+			wantErr: lsp.ErrExitWithoutShutdown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			send, _, served := serve(t, &lsp.Server{Handler: &slowHandler{}})
+
+			for _, message := range tt.messages {
+				send(message)
+			}
+			send(`{"jsonrpc":"2.0","method":"exit"}`)
+
+			var err error
+			g.Eventually(served).Should(Receive(&err))
+			if tt.wantErr == nil {
+				g.Expect(err).ToNot(HaveOccurred())
+			} else {
+				g.Expect(err).To(MatchError(tt.wantErr))
+			}
+		})
+	}
+}
+
+func TestConformance_Messages(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{
+			name:    "Batch",
+			message: `[` + completionRequest + `,{"jsonrpc":"2.0","method":"initialized"},{"jsonrpc":"2.0","id":2,"method":"unknown"}]`,
+			want:    `[` + completionResult + `,{"jsonrpc":"2.0","id":2,"error":{"code":-32601,"message":"Unknown method \"unknown\""}}]`,
+		},
+		{
+			name:    "BatchWithInvalidMessage",
+			message: `[1,` + completionRequest + `]`,
+			want:    `[{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"Message is not an object"}},` + completionResult + `]`,
+		},
+		{
+			name:    "EmptyBatch",
+			message: `[]`,
+			want:    `{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"Empty batch"}}`,
+		},
+		{
+			name:    "InvalidJSON",
+			message: `{"jsonrpc":"2.0","id":1,"method":`,
+			want:    `{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"invalid JSON"}}`,
+		},
+		{
+			name:    "MissingVersion",
+			message: `{"id":1,"method":"textDocument/completion"}`,
+			want:    `{"jsonrpc":"2.0","id":1,"error":{"code":-32600,"message":"Unsupported JSON-RPC version"}}`,
+		},
+		{
+			name:    "MissingMethod",
+			message: `{"jsonrpc":"2.0","id":1}`,
+			want:    `{"jsonrpc":"2.0","id":1,"error":{"code":-32600,"message":"Missing method"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			send, received := startServer(t, &lsp.Server{Handler: &slowHandler{}})
+
+			send(tt.message)
+			g.Eventually(received).Should(Receive(Equal(tt.want)))
+		})
+	}
+}
+
+func TestConformance_BatchOfNotifications(t *testing.T) {
+	g := NewWithT(t)
+	send, received := startServer(t, &lsp.Server{Handler: &slowHandler{}})
+
+	send(`[{"jsonrpc":"2.0","method":"initialized"},{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"file:///a.gdshader"}}}]`)
+	send(completionRequest)
+
+	// Nothing is written for the batch.
+	g.Eventually(received).Should(Receive(Equal(completionResult)))
+}
+
+func TestConformance_ResponseInBatch(t *testing.T) {
+	g := NewWithT(t)
+	handler := &configHandler{}
+	server := &lsp.Server{Handler: handler}
+	handler.client = server
+	send, received := startServer(t, server)
+
+	send(`{"jsonrpc":"2.0","id":1,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///a.gdshader"}}}`)
+	g.Eventually(received).Should(Receive(HavePrefix(`{"jsonrpc":"2.0","id":1,"method":"workspace/configuration"`)))
+
+	// The client answers the server in the same batch as a new request.
+	send(`[{"jsonrpc":"2.0","id":1,"result":[{"indent":4}]},{"jsonrpc":"2.0","id":2,"method":"textDocument/completion","params":{"textDocument":{"uri":"file:///a.gdshader"}}}]`)
+
+	var first, second string
+	g.Eventually(received).Should(Receive(&first))
+	g.Eventually(received).Should(Receive(&second))
+	g.Expect([]string{first, second}).To(ConsistOf(
+		`{"jsonrpc":"2.0","id":1,"result":{"contents":{"kind":"plaintext","value":"{\"indent\":4}"}}}`,
+		`[{"jsonrpc":"2.0","id":2,"result":{"isIncomplete":false,"items":[]}}]`,
+	))
+}
@@ -11,7 +11,7 @@ import (
 	"io"
 	"slices"
 	"strings"
-	"unicode/utf8"
+	"sync"
 
 	"github.com/zyedidia/rope"
 )
@@ -31,52 +31,141 @@ const (
 )
 
 // Filesystem can be embedded into handlers in order to implement the basic
-// document sync methods of the LSP.
+// document sync methods of the LSP. It is safe for concurrent use, so
+// requests can read snapshots while notifications change the documents.
 type Filesystem struct {
-	Documents  map[string]*Document
 	BufferType BufferType
+	// PositionEncoding is how the characters of positions are counted. It
+	// must be set before documents are opened, and defaults to UTF-16.
+	PositionEncoding PositionEncodingKind
+
+	mu        sync.Mutex
+	documents map[string]*Document
+}
+
+// Snapshot returns an immutable view of the current content of a document.
+func (f *Filesystem) Snapshot(uri string) (*Snapshot, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	doc, ok := f.documents[uri]
+	if !ok {
+		return nil, fmt.Errorf("document not found: %s", uri)
+	}
+	return doc.Snapshot(), nil
 }
 
 // DidOpenTextDocument implements DocumentSyncHandler.
 func (f *Filesystem) DidOpenTextDocument(_ context.Context, params DidOpenTextDocumentParams) error {
-	var buf Buffer
-	switch f.BufferType {
-	case BufferTypeGap:
-		buf = &GapBuffer{}
-	case BufferTypeRope:
-		buf = &RopeBuffer{}
-	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
 
-	if f.Documents == nil {
-		f.Documents = make(map[string]*Document)
+	if f.documents == nil {
+		f.documents = make(map[string]*Document)
 	}
-	f.Documents[params.TextDocument.URI] = NewDocument([]byte(params.TextDocument.Text), buf)
+	doc := NewDocument([]byte(params.TextDocument.Text), f.newBuffer())
+	doc.version = params.TextDocument.Version
+	doc.languageID = params.TextDocument.LanguageID
+	doc.encoding = f.PositionEncoding
+	f.documents[params.TextDocument.URI] = doc
 
 	return nil
 }
 
 // DidCloseTextDocument implements lsp.Handler.
 func (f *Filesystem) DidCloseTextDocument(_ context.Context, params DidCloseTextDocumentParams) error {
-	delete(f.Documents, params.TextDocument.URI)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.documents, params.TextDocument.URI)
 	return nil
 }
 
-// DidChangeTextDocument implements lsp.Handler.
+// newBuffer returns an empty buffer of the configured type, or nil for the
+// default.
+func (f *Filesystem) newBuffer() Buffer {
+	switch f.BufferType {
+	case BufferTypeGap:
+		return &GapBuffer{}
+	case BufferTypeRope:
+		return &RopeBuffer{}
+	}
+	return nil
+}
+
+// DidChangeTextDocument implements lsp.Handler. A change is rejected unless
+// its version is newer than the document, since applying changes out of
+// order would silently corrupt it.
+//
+// The changes are applied all together or not at all. If one of them fails,
+// or arrives out of order, the document keeps its content from before them
+// and becomes stale, since it is out of sync with the client. A stale
+// document rejects changes until one of them replaces its whole content, or
+// it is opened again.
 func (f *Filesystem) DidChangeTextDocument(_ context.Context, params DidChangeTextDocumentParams) error {
-	doc, ok := f.Documents[params.TextDocument.URI]
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	doc, ok := f.documents[params.TextDocument.URI]
 	if !ok {
 		return fmt.Errorf("document not found: %s", params.TextDocument.URI)
 	}
 
-	for _, change := range params.ContentChanges {
-		if err := doc.ApplyChange(change); err != nil {
-			return err
-		}
+	if params.TextDocument.Version <= doc.version {
+		doc.stale = true
+		return fmt.Errorf("%w: %s: got version %d after version %d", ErrOutOfOrder, params.TextDocument.URI, params.TextDocument.Version, doc.version)
+	}
+
+	if doc.stale && !slices.ContainsFunc(params.ContentChanges, func(c TextDocumentContentChangeEvent) bool { return c.Range == nil }) {
+		return fmt.Errorf("%w: %s", ErrStale, params.TextDocument.URI)
 	}
 
+	next, err := f.applyChanges(doc, params.ContentChanges)
+	if err != nil {
+		doc.stale = true
+		return fmt.Errorf("%w: %s: %w", ErrChangeFailed, params.TextDocument.URI, err)
+	}
+	next.version = params.TextDocument.Version
+	next.stale = false
+	f.documents[params.TextDocument.URI] = next
+
 	return nil
 }
 
+// applyChanges returns the document with a batch of changes applied. A
+// single change is applied in place, since it leaves the document as it was
+// when it fails. A batch of changes is applied to a copy, so that the
+// changes before the one that fails are not applied either.
+func (f *Filesystem) applyChanges(doc *Document, changes []TextDocumentContentChangeEvent) (*Document, error) {
+	if len(changes) > 1 {
+		next := NewDocument(bytes.Clone(doc.Bytes()), f.newBuffer())
+		next.version = doc.version
+		next.languageID = doc.languageID
+		next.encoding = doc.encoding
+		doc = next
+	}
+	for _, change := range changes {
+		if err := doc.ApplyChange(change); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// ErrOutOfOrder is returned for a document change that is older than the
+// current version of the document. The document becomes stale.
+var ErrOutOfOrder = errors.New("document change out of order")
+
+// ErrChangeFailed is returned for a document change that can't be applied,
+// such as one with a range outside of the document. The document becomes
+// stale.
+var ErrChangeFailed = errors.New("document change failed")
+
+// ErrStale is returned for a document change to a stale document. A change
+// that replaces the whole content of the document brings it back in sync,
+// and so does opening it again.
+var ErrStale = errors.New("document is stale")
+
 var _ DocumentSyncHandler = (*Filesystem)(nil)
 
 // Buffer implements large text storage with methods for random access.
@@ -94,11 +183,19 @@ type Buffer interface {
 }
 
 // Document represents a text document with methods to manipulate its content.
+// It is not safe for concurrent use. Take a [Snapshot] to share its content.
 type Document struct {
-	buffer    Buffer
-	lineStart []int
-	cache     []byte
-	charBuf   []byte
+	buffer     Buffer
+	lineStart  []int
+	version    int
+	languageID string
+	encoding   PositionEncodingKind
+	// stale is set when a change to the document fails, since its content
+	// is out of sync with the client from then on.
+	stale bool
+	// snapshot caches the current content until the next change.
+	snapshot *Snapshot
+	charBuf  []byte
 }
 
 // NewDocument creates a new Document with the given initial text and buffer.
@@ -112,42 +209,55 @@ func NewDocument(text []byte, buf Buffer) *Document {
 	return doc
 }
 
+// SetPositionEncoding sets how the characters of positions are counted. The
+// default is UTF-16.
+func (d *Document) SetPositionEncoding(encoding PositionEncodingKind) {
+	d.snapshot = nil
+	d.encoding = encoding
+}
+
 // Reset reinitializes the document with the given text.
 func (d *Document) Reset(text []byte) {
-	d.cache = nil
+	d.snapshot = nil
 	d.buffer.Reset(text)
 	d.lineStart = computeLineStart(text)
 }
 
-// Bytes returns the full content of the document.
+// Bytes returns the full content of the document. The caller must not
+// modify it.
 func (d *Document) Bytes() []byte {
-	if d.cache != nil {
-		return d.cache
+	return d.Snapshot().text
+}
+
+// Snapshot returns an immutable copy of the current content.
+func (d *Document) Snapshot() *Snapshot {
+	if d.snapshot == nil {
+		d.snapshot = &Snapshot{
+			version:    d.version,
+			languageID: d.languageID,
+			encoding:   d.encoding,
+			text:       bytes.Clone(d.buffer.Bytes()),
+			lineStart:  slices.Clone(d.lineStart),
+		}
 	}
-	d.cache = d.buffer.Bytes()
-	return d.cache
+	return d.snapshot
 }
 
 // ReadAt implements io.ReaderAt.
 func (d *Document) ReadAt(p []byte, off int64) (n int, err error) {
-	if d.cache != nil {
-		return copy(p, d.cache[off:]), nil
+	if d.snapshot != nil {
+		return d.snapshot.ReadAt(p, off)
 	}
 	return d.buffer.ReadAt(p, off)
 }
 
 // Len returns the number of bytes in the document.
 func (d *Document) Len() int {
-	if d.cache != nil {
-		return len(d.cache)
-	}
 	return d.buffer.Len()
 }
 
 // ApplyChange applies a content change to the document.
 func (d *Document) ApplyChange(change TextDocumentContentChangeEvent) error {
-	d.cache = nil
-
 	if len(d.charBuf) == 0 {
 		d.charBuf = make([]byte, 1024)
 	}
@@ -157,6 +267,8 @@ func (d *Document) ApplyChange(change TextDocumentContentChangeEvent) error {
 		return nil
 	}
 
+	d.snapshot = nil
+
 	startOffset, endOffset, err := d.getChangeOffsets(change)
 	if err != nil {
 		return fmt.Errorf("get change offsets: %w", err)
@@ -208,7 +320,8 @@ func (d *Document) writeText(text string, off int) error {
 }
 
 // PositionToOffset converts a Position (line and character) to a byte offset
-// in the document. It correctly handles UTF-16 character widths.
+// in the document. Characters are counted in the position encoding of the
+// document.
 func (d *Document) PositionToOffset(pos Position) (int, error) {
 	if pos.Line >= len(d.lineStart) {
 		return 0, fmt.Errorf("invalid line: %d", pos.Line)
@@ -216,7 +329,7 @@ func (d *Document) PositionToOffset(pos Position) (int, error) {
 
 	start, end := d.lineBounds(pos.Line)
 
-	offset, u16Count := start, 0
+	offset, count := start, 0
 	for offset < end {
 		chunkSize := min(len(d.charBuf), end-offset)
 
@@ -229,7 +342,7 @@ func (d *Document) PositionToOffset(pos Position) (int, error) {
 			break
 		}
 
-		deltaOffset, done, err := decodeUntilTargetOffset(d.charBuf[:n], pos.Character, &u16Count)
+		deltaOffset, done, err := decodeUntilTargetOffset(d.charBuf[:n], pos.Character, &count, d.encoding)
 		if err != nil {
 			return 0, err
 		}
@@ -240,11 +353,26 @@ func (d *Document) PositionToOffset(pos Position) (int, error) {
 		offset += n
 	}
 
-	if u16Count >= pos.Character {
+	if count >= pos.Character {
 		return offset, nil
 	}
 
-	return 0, fmt.Errorf("line %d: target units %d out of bounds (only %d utf16 units)", pos.Line, pos.Character, u16Count)
+	return 0, fmt.Errorf("line %d: target units %d out of bounds (only %d %s units)", pos.Line, pos.Character, count, d.encoding)
+}
+
+// OffsetToPosition converts a byte offset in the document to a Position. It
+// is the inverse of [Document.PositionToOffset]. Offsets past the end are
+// clamped to the end of the document.
+func (d *Document) OffsetToPosition(offset int) (Position, error) {
+	offset = max(0, min(offset, d.buffer.Len()))
+	line := lineOf(d.lineStart, offset)
+
+	text := make([]byte, offset-d.lineStart[line])
+	if _, err := d.buffer.ReadAt(text, int64(d.lineStart[line])); err != nil && !errors.Is(err, io.EOF) {
+		return Position{}, fmt.Errorf("buffer read at line %d: %w", line, err)
+	}
+
+	return Position{Line: line, Character: countUnits(text, d.encoding)}, nil
 }
 
 func (d *Document) lineBounds(line int) (start, end int) {
@@ -255,28 +383,6 @@ func (d *Document) lineBounds(line int) (start, end int) {
 	return start, d.buffer.Len()
 }
 
-func decodeUntilTargetOffset(buf []byte, targetU16Offset int, u16Count *int) (deltaOffset int, done bool, err error) {
-	for i := 0; i < len(buf); {
-		r, size := utf8.DecodeRune(buf[i:])
-		if r == utf8.RuneError && size == 1 {
-			return 0, false, fmt.Errorf("invalid utf-8 at byte offset %d", i)
-		}
-		if *u16Count >= targetU16Offset {
-			return i, true, nil
-		}
-		*u16Count += utf16Width(r)
-		i += size
-	}
-	return 0, false, nil
-}
-
-func utf16Width(r rune) int {
-	if r <= 0xFFFF {
-		return 1
-	}
-	return 2
-}
-
 // Lines returns the number of lines in the document. A single line ending in
 // a newline character is counted as two lines. This is consistent with the
 // LSP specification.
@@ -339,9 +445,10 @@ type GapBuffer struct {
 
 const initialGapSize = 128
 
-// Bytes implements Buffer.
+// Bytes implements Buffer. The content is copied, since appending the text
+// after the gap to the text before it would overwrite the gap.
 func (g *GapBuffer) Bytes() []byte {
-	return append(g.buf[:g.gapStart], g.buf[g.gapEnd:]...)
+	return slices.Concat(g.buf[:g.gapStart], g.buf[g.gapEnd:])
 }
 
 // Delete implements Buffer.
@@ -6,10 +6,12 @@ package lsp_test
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"math/rand"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 
 	. "github.com/onsi/gomega"
@@ -44,17 +46,20 @@ func makeChange(text, rangeStr string) lsp.TextDocumentContentChangeEvent {
 	if rangeStr == "" {
 		return lsp.TextDocumentContentChangeEvent{Text: text}
 	}
-	// Parse range string: "0:1-2:2"
-	parts := strings.Split(rangeStr, "-")
-	if len(parts) != 2 {
-		panic(fmt.Sprintf("invalid range string: %q", rangeStr))
-	}
-	start := parsePos(parts[0])
-	end := parsePos(parts[1])
+	r := parseRange(rangeStr)
 	return lsp.TextDocumentContentChangeEvent{
 		Text:  text,
-		Range: &lsp.Range{Start: start, End: end},
+		Range: &r,
+	}
+}
+
+// parseRange parses a range string such as "0:1-2:2".
+func parseRange(s string) lsp.Range {
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		panic(fmt.Sprintf("invalid range string: %q", s))
 	}
+	return lsp.Range{Start: parsePos(parts[0]), End: parsePos(parts[1])}
 }
 
 func parsePos(s string) lsp.Position {
@@ -76,6 +81,9 @@ func TestDocument(t *testing.T) {
 			t.Run("ApplyChange", func(t *testing.T) { testApplyChange(t, impl) })
 			t.Run("ApplyChange_Error", func(t *testing.T) { testApplyChangeError(t, impl) })
 			t.Run("PositionToOffset", func(t *testing.T) { testPositionToOffset(t, impl) })
+			t.Run("PositionEncoding", func(t *testing.T) { testPositionEncoding(t, impl) })
+			t.Run("TokenAt", func(t *testing.T) { testTokenAt(t, impl) })
+			t.Run("Slice", func(t *testing.T) { testSlice(t, impl) })
 		})
 	}
 }
@@ -219,6 +227,140 @@ func testPositionToOffset(t *testing.T, impl lsp.Buffer) {
 	}
 }
 
+func testPositionEncoding(t *testing.T, impl lsp.Buffer) {
+	// Characters outside of the basic multilingual plane take up two UTF-16
+	// code units, and four UTF-8 code units.
+	const initial = "// 😀 é\nx = 1; // 𝔸b\n"
+	const offset = 25 // The "b".
+
+	tests := []struct {
+		encoding lsp.PositionEncodingKind
+		position lsp.Position
+	}{
+		{encoding: "", position: lsp.Position{Line: 1, Character: 12}},
+		{encoding: lsp.PositionEncodingUTF8, position: lsp.Position{Line: 1, Character: 14}},
+		{encoding: lsp.PositionEncodingUTF16, position: lsp.Position{Line: 1, Character: 12}},
+		{encoding: lsp.PositionEncodingUTF32, position: lsp.Position{Line: 1, Character: 11}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.encoding.String(), func(t *testing.T) {
+			g := NewWithT(t)
+			doc := lsp.NewDocument([]byte(initial), newBuffer(impl))
+			doc.SetPositionEncoding(tt.encoding)
+
+			g.Expect(doc.PositionToOffset(tt.position)).To(Equal(offset))
+			g.Expect(doc.OffsetToPosition(offset)).To(Equal(tt.position))
+			g.Expect(doc.Snapshot().PositionToOffset(tt.position)).To(Equal(offset))
+			g.Expect(doc.Snapshot().OffsetToPosition(offset)).To(Equal(tt.position))
+
+			g.Expect(doc.ApplyChange(lsp.TextDocumentContentChangeEvent{
+				Range: &lsp.Range{Start: tt.position, End: lsp.Position{Line: tt.position.Line, Character: tt.position.Character + 1}},
+				Text:  "c",
+			})).To(Succeed())
+			g.Expect(string(doc.Bytes())).To(Equal("// 😀 é\nx = 1; // 𝔸c\n"))
+		})
+	}
+}
+
+func testTokenAt(t *testing.T, impl lsp.Buffer) {
+	const initial = "uniform vec3 tint;\r\n\tALBEDO = tint * 𝔸x_1;\n"
+
+	tests := []struct {
+		name      string
+		position  string
+		wantWord  string
+		wantToken string
+		wantRange string
+	}{
+		{name: "start of word", position: "0:8", wantWord: "vec3", wantToken: "vec3", wantRange: "0:8-0:12"},
+		{name: "middle of word", position: "0:10", wantWord: "vec3", wantToken: "vec3", wantRange: "0:8-0:12"},
+		{name: "end of word", position: "0:12", wantWord: "vec3", wantRange: "0:12-0:12"},
+		{name: "punctuation", position: "0:17", wantWord: "tint", wantToken: ";", wantRange: "0:17-0:18"},
+		{name: "end of line before CRLF", position: "0:18", wantRange: "0:18-0:18"},
+		{name: "indentation", position: "1:0", wantRange: "1:0-1:0"},
+		{name: "after astral character", position: "1:20", wantWord: "𝔸x_1", wantToken: "𝔸x_1", wantRange: "1:17-1:22"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			doc := lsp.NewDocument([]byte(initial), newBuffer(impl))
+			pos := parsePos(tt.position)
+
+			for _, text := range []interface {
+				WordAt(lsp.Position) (string, lsp.Range, error)
+				TokenAt(lsp.Position) (string, lsp.Range, error)
+			}{doc, doc.Snapshot()} {
+				word, _, err := text.WordAt(pos)
+				g.Expect(err).ToNot(HaveOccurred(), "WordAt error")
+				g.Expect(word).To(Equal(tt.wantWord), "WordAt")
+
+				token, r, err := text.TokenAt(pos)
+				g.Expect(err).ToNot(HaveOccurred(), "TokenAt error")
+				g.Expect(token).To(Equal(tt.wantToken), "TokenAt")
+				g.Expect(r).To(Equal(parseRange(tt.wantRange)), "TokenAt range")
+			}
+		})
+	}
+}
+
+func testSlice(t *testing.T, impl lsp.Buffer) {
+	const initial = "hello\nworld\n"
+
+	tests := []struct {
+		name    string
+		rng     string
+		want    string
+		wantErr bool
+	}{
+		{name: "within a line", rng: "0:1-0:4", want: "ell"},
+		{name: "across lines", rng: "0:3-1:2", want: "lo\nwo"},
+		{name: "whole line", rng: "1:0-2:0", want: "world\n"},
+		{name: "end past last line", rng: "1:0-9:0", want: "world\n"},
+		{name: "empty", rng: "1:2-1:2", want: ""},
+		{name: "end before start", rng: "1:2-0:0", wantErr: true},
+		{name: "start past last line", rng: "9:0-9:0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			doc := lsp.NewDocument([]byte(initial), newBuffer(impl))
+
+			for _, text := range []interface {
+				Slice(lsp.Range) (*io.SectionReader, error)
+			}{doc, doc.Snapshot()} {
+				reader, err := text.Slice(parseRange(tt.rng))
+				if tt.wantErr {
+					g.Expect(err).To(HaveOccurred())
+					continue
+				}
+				g.Expect(err).ToNot(HaveOccurred(), "Slice error")
+				g.Expect(io.ReadAll(reader)).To(BeEquivalentTo(tt.want))
+			}
+		})
+	}
+}
+
+func TestDocument_OffsetToPosition(t *testing.T) {
+	g := NewWithT(t)
+	doc := lsp.NewDocument([]byte("ab\n\ncd"), nil)
+
+	// The last offset is past the end.
+	for offset, want := range []string{"0:0", "0:1", "0:2", "1:0", "2:0", "2:1", "2:2", "2:2"} {
+		g.Expect(doc.OffsetToPosition(offset)).To(Equal(parsePos(want)), "offset %d", offset)
+		g.Expect(doc.Snapshot().OffsetToPosition(offset)).To(Equal(parsePos(want)), "offset %d", offset)
+	}
+}
+
+func TestChoosePositionEncoding(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(lsp.ChoosePositionEncoding(nil)).To(Equal(lsp.PositionEncodingUTF16))
+	g.Expect(lsp.ChoosePositionEncoding([]lsp.PositionEncodingKind{lsp.PositionEncodingUTF16, lsp.PositionEncodingUTF8})).To(Equal(lsp.PositionEncodingUTF8))
+	g.Expect(lsp.ChoosePositionEncoding([]lsp.PositionEncodingKind{lsp.PositionEncodingUTF32, lsp.PositionEncodingUTF16})).To(Equal(lsp.PositionEncodingUTF32))
+}
+
 func testApplyChangeError(t *testing.T, impl lsp.Buffer) {
 	tests := []struct {
 		name    string
@@ -417,3 +559,248 @@ func benchmarkGeneral(b *testing.B, impl lsp.Buffer) {
 		}
 	}
 }
+
+func TestFilesystem_Snapshot(t *testing.T) {
+	g := NewWithT(t)
+	var fs lsp.Filesystem
+	ctx := t.Context()
+	const uri = "file:///test.gdshader"
+
+	_, err := fs.Snapshot(uri)
+	g.Expect(err).To(HaveOccurred(), "Snapshot of unknown document")
+
+	g.Expect(fs.DidOpenTextDocument(ctx, lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{URI: uri, LanguageID: "gdshader", Version: 1, Text: "hello\n"},
+	})).To(Succeed())
+	before, err := fs.Snapshot(uri)
+	g.Expect(err).ToNot(HaveOccurred(), "Snapshot before change")
+
+	g.Expect(fs.DidChangeTextDocument(ctx, lsp.DidChangeTextDocumentParams{
+		TextDocument:   lsp.VersionedTextDocumentIdentifier{URI: uri, Version: 3},
+		ContentChanges: makeChanges(" world", "0:5-0:5", "!", "0:11-0:11"),
+	})).To(Succeed())
+	after, err := fs.Snapshot(uri)
+	g.Expect(err).ToNot(HaveOccurred(), "Snapshot after change")
+
+	g.Expect(string(before.Bytes())).To(Equal("hello\n"))
+	g.Expect(before.Version()).To(Equal(1))
+	g.Expect(string(after.Bytes())).To(Equal("hello world!\n"))
+	g.Expect(after.Version()).To(Equal(3))
+	g.Expect(after.LanguageID()).To(Equal("gdshader"))
+	g.Expect(after.PositionToOffset(lsp.Position{Line: 1})).To(Equal(13))
+
+	g.Expect(fs.DidCloseTextDocument(ctx, lsp.DidCloseTextDocumentParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+	})).To(Succeed())
+	_, err = fs.Snapshot(uri)
+	g.Expect(err).To(HaveOccurred(), "Snapshot of closed document")
+	g.Expect(string(after.Bytes())).To(Equal("hello world!\n"))
+}
+
+// TestFilesystem_Snapshot_BetweenChanges takes a snapshot after every
+// change, which must not disturb the content of the buffer. The document is
+// longer than the gap of a gap buffer.
+func TestFilesystem_Snapshot_BetweenChanges(t *testing.T) {
+	bufferTypes := map[string]lsp.BufferType{"Default": lsp.BufferTypeDefault, "Gap": lsp.BufferTypeGap, "Rope": lsp.BufferTypeRope}
+	for name, bufferType := range bufferTypes {
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+			const uri = "file:///test.gdshader"
+			fs := &lsp.Filesystem{BufferType: bufferType}
+			ctx := t.Context()
+
+			var lines []string
+			for i := range 200 {
+				lines = append(lines, fmt.Sprintf("line number %d", i))
+			}
+			g.Expect(fs.DidOpenTextDocument(ctx, lsp.DidOpenTextDocumentParams{
+				TextDocument: lsp.TextDocumentItem{URI: uri, Version: 1, Text: strings.Join(lines, "\n")},
+			})).To(Succeed())
+
+			steps := []struct {
+				change lsp.TextDocumentContentChangeEvent
+				edit   func()
+			}{
+				{makeChange("X", "0:0-0:0"), func() { lines[0] = "X" + lines[0] }},
+				{makeChange("Y", "0:0-0:0"), func() { lines[0] = "Y" + lines[0] }},
+				{makeChange("", "150:5-150:12"), func() { lines[150] = "line 150" }},
+				{makeChange("the ", "1:5-1:5"), func() { lines[1] = "line the number 1" }},
+				{makeChange("", "0:0-0:2"), func() { lines[0] = "line number 0" }},
+				{makeChange("!", "199:15-199:15"), func() { lines[199] += "!" }},
+			}
+			for i, step := range steps {
+				g.Expect(fs.DidChangeTextDocument(ctx, lsp.DidChangeTextDocumentParams{
+					TextDocument:   lsp.VersionedTextDocumentIdentifier{URI: uri, Version: i + 2},
+					ContentChanges: []lsp.TextDocumentContentChangeEvent{step.change},
+				})).To(Succeed())
+				step.edit()
+				snapshot, err := fs.Snapshot(uri)
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(string(snapshot.Bytes())).To(Equal(strings.Join(lines, "\n")), "After change %d", i)
+			}
+		})
+	}
+}
+
+func TestFilesystem_DidChangeTextDocument_Failed(t *testing.T) {
+	g := NewWithT(t)
+	var fs lsp.Filesystem
+	ctx := t.Context()
+	const uri = "file:///test.gdshader"
+
+	g.Expect(fs.DidOpenTextDocument(ctx, lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{URI: uri, Version: 1, Text: "hello\n"},
+	})).To(Succeed())
+	snapshot := func() *lsp.Snapshot {
+		snapshot, err := fs.Snapshot(uri)
+		g.Expect(err).ToNot(HaveOccurred())
+		return snapshot
+	}
+	before := snapshot()
+
+	// None of the changes are applied if one of them fails.
+	err := fs.DidChangeTextDocument(ctx, lsp.DidChangeTextDocumentParams{
+		TextDocument:   lsp.VersionedTextDocumentIdentifier{URI: uri, Version: 2},
+		ContentChanges: makeChanges(" world", "0:5-0:5", "!", "5:0-5:0"),
+	})
+	g.Expect(err).To(MatchError(lsp.ErrChangeFailed))
+	g.Expect(string(snapshot().Bytes())).To(Equal("hello\n"))
+	g.Expect(snapshot().Version()).To(Equal(1))
+	g.Expect(string(before.Bytes())).To(Equal("hello\n"))
+
+	// The document is stale until its whole content is sent.
+	err = fs.DidChangeTextDocument(ctx, lsp.DidChangeTextDocumentParams{
+		TextDocument:   lsp.VersionedTextDocumentIdentifier{URI: uri, Version: 3},
+		ContentChanges: makeChanges("!", "0:5-0:5"),
+	})
+	g.Expect(err).To(MatchError(lsp.ErrStale))
+	g.Expect(snapshot().Version()).To(Equal(1))
+
+	g.Expect(fs.DidChangeTextDocument(ctx, lsp.DidChangeTextDocumentParams{
+		TextDocument:   lsp.VersionedTextDocumentIdentifier{URI: uri, Version: 4},
+		ContentChanges: []lsp.TextDocumentContentChangeEvent{{Text: "hello world\n"}},
+	})).To(Succeed())
+	g.Expect(fs.DidChangeTextDocument(ctx, lsp.DidChangeTextDocumentParams{
+		TextDocument:   lsp.VersionedTextDocumentIdentifier{URI: uri, Version: 5},
+		ContentChanges: makeChanges("!", "0:11-0:11"),
+	})).To(Succeed())
+	g.Expect(string(snapshot().Bytes())).To(Equal("hello world!\n"))
+}
+
+func TestFilesystem_DidChangeTextDocument_OutOfOrder(t *testing.T) {
+	g := NewWithT(t)
+	var fs lsp.Filesystem
+	ctx := t.Context()
+	const uri = "file:///test.gdshader"
+
+	g.Expect(fs.DidOpenTextDocument(ctx, lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{URI: uri, Version: 1, Text: "hello\n"},
+	})).To(Succeed())
+	g.Expect(fs.DidChangeTextDocument(ctx, lsp.DidChangeTextDocumentParams{
+		TextDocument:   lsp.VersionedTextDocumentIdentifier{URI: uri, Version: 3},
+		ContentChanges: makeChanges(" world", "0:5-0:5"),
+	})).To(Succeed())
+	before, err := fs.Snapshot(uri)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// A change that arrives late is rejected, and so are the ones after it,
+	// since the client applied it to its own copy.
+	for _, version := range []int{2, 3} {
+		err = fs.DidChangeTextDocument(ctx, lsp.DidChangeTextDocumentParams{
+			TextDocument:   lsp.VersionedTextDocumentIdentifier{URI: uri, Version: version},
+			ContentChanges: makeChanges("!", "0:5-0:5"),
+		})
+		g.Expect(err).To(MatchError(lsp.ErrOutOfOrder), "Version %d", version)
+	}
+	err = fs.DidChangeTextDocument(ctx, lsp.DidChangeTextDocumentParams{
+		TextDocument:   lsp.VersionedTextDocumentIdentifier{URI: uri, Version: 4},
+		ContentChanges: makeChanges("!", "0:11-0:11"),
+	})
+	g.Expect(err).To(MatchError(lsp.ErrStale))
+	g.Expect(fs.Snapshot(uri)).To(BeIdenticalTo(before))
+
+	// The whole content brings the document back in sync.
+	g.Expect(fs.DidChangeTextDocument(ctx, lsp.DidChangeTextDocumentParams{
+		TextDocument:   lsp.VersionedTextDocumentIdentifier{URI: uri, Version: 5},
+		ContentChanges: makeChanges("hello! world\n", ""),
+	})).To(Succeed())
+	g.Expect(fs.DidChangeTextDocument(ctx, lsp.DidChangeTextDocumentParams{
+		TextDocument:   lsp.VersionedTextDocumentIdentifier{URI: uri, Version: 6},
+		ContentChanges: makeChanges("!", "0:12-0:12"),
+	})).To(Succeed())
+	after, err := fs.Snapshot(uri)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(after.Bytes())).To(Equal("hello! world!\n"))
+	g.Expect(after.Version()).To(Equal(6))
+
+	// So does opening it again.
+	err = fs.DidChangeTextDocument(ctx, lsp.DidChangeTextDocumentParams{
+		TextDocument:   lsp.VersionedTextDocumentIdentifier{URI: uri, Version: 6},
+		ContentChanges: makeChanges("?", "0:0-0:0"),
+	})
+	g.Expect(err).To(MatchError(lsp.ErrOutOfOrder))
+	g.Expect(fs.DidOpenTextDocument(ctx, lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{URI: uri, Version: 7, Text: "?hello! world!\n"},
+	})).To(Succeed())
+	g.Expect(fs.DidChangeTextDocument(ctx, lsp.DidChangeTextDocumentParams{
+		TextDocument:   lsp.VersionedTextDocumentIdentifier{URI: uri, Version: 8},
+		ContentChanges: makeChanges("", "0:0-0:1"),
+	})).To(Succeed())
+}
+
+// TestFilesystem_Snapshot_Concurrent is meant to be run with the race
+// detector. Readers check that every snapshot is consistent with its
+// version while a writer keeps typing.
+func TestFilesystem_Snapshot_Concurrent(t *testing.T) {
+	bufferTypes := map[string]lsp.BufferType{"Gap": lsp.BufferTypeGap, "Rope": lsp.BufferTypeRope}
+	for name, bufferType := range bufferTypes {
+		t.Run(name, func(t *testing.T) {
+			const uri = "file:///test.gdshader"
+			const initial = "void fragment() {\n}\n"
+			const edits = 500
+
+			fs := &lsp.Filesystem{BufferType: bufferType}
+			ctx := t.Context()
+			NewWithT(t).Expect(fs.DidOpenTextDocument(ctx, lsp.DidOpenTextDocumentParams{
+				TextDocument: lsp.TextDocumentItem{URI: uri, Text: initial},
+			})).To(Succeed())
+
+			var wg sync.WaitGroup
+			for range 4 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for range edits {
+						snapshot, err := fs.Snapshot(uri)
+						if err != nil {
+							t.Error(err)
+							return
+						}
+						typed := snapshot.Version()
+						want := "void fragment() {\n" + strings.Repeat("a", typed) + "}\n"
+						if got := string(snapshot.Bytes()); got != want {
+							t.Errorf("version %d: got %q, want %q", snapshot.Version(), got, want)
+							return
+						}
+						if offset, err := snapshot.PositionToOffset(lsp.Position{Line: 1, Character: typed}); err != nil || offset != 18+typed {
+							t.Errorf("version %d: got offset %d, error %v", snapshot.Version(), offset, err)
+							return
+						}
+					}
+				}()
+			}
+
+			for i := range edits {
+				err := fs.DidChangeTextDocument(ctx, lsp.DidChangeTextDocumentParams{
+					TextDocument:   lsp.VersionedTextDocumentIdentifier{URI: uri, Version: i + 1},
+					ContentChanges: makeChanges("a", fmt.Sprintf("1:%[1]d-1:%[1]d", i)),
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			wg.Wait()
+		})
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package lsp
+
+import (
+	"fmt"
+	"slices"
+	"unicode/utf8"
+)
+
+// ChoosePositionEncoding picks the encoding to use out of the ones that the
+// client supports. UTF-8 is preferred, since it counts the same bytes that
+// documents are stored in. UTF-16 is the fallback that every client
+// supports.
+func ChoosePositionEncoding(supported []PositionEncodingKind) PositionEncodingKind {
+	for _, encoding := range []PositionEncodingKind{PositionEncodingUTF8, PositionEncodingUTF32} {
+		if slices.Contains(supported, encoding) {
+			return encoding
+		}
+	}
+	return PositionEncodingUTF16
+}
+
+// units returns the number of code units that a character takes up in the
+// encoding. An empty encoding is UTF-16, which is the LSP default.
+func (e PositionEncodingKind) units(r rune) int {
+	switch e {
+	case PositionEncodingUTF8:
+		return utf8.RuneLen(r)
+	case PositionEncodingUTF32:
+		return 1
+	default:
+		if r <= 0xFFFF {
+			return 1
+		}
+		return 2
+	}
+}
+
+func (e PositionEncodingKind) String() string {
+	if e == "" {
+		return string(PositionEncodingUTF16)
+	}
+	return string(e)
+}
+
+// decodeUntilTargetOffset decodes characters from buf, adding their code
+// units to count, until count reaches the target. It returns the offset in
+// buf where the target was reached.
+func decodeUntilTargetOffset(buf []byte, target int, count *int, encoding PositionEncodingKind) (deltaOffset int, done bool, err error) {
+	for i := 0; i < len(buf); {
+		r, size := utf8.DecodeRune(buf[i:])
+		if r == utf8.RuneError && size == 1 {
+			return 0, false, fmt.Errorf("invalid utf-8 at byte offset %d", i)
+		}
+		if *count >= target {
+			return i, true, nil
+		}
+		*count += encoding.units(r)
+		i += size
+	}
+	return 0, false, nil
+}
+
+// countUnits returns the number of code units in text. A character that is
+// cut off at the end of text is not counted.
+func countUnits(text []byte, encoding PositionEncodingKind) int {
+	if encoding == PositionEncodingUTF8 {
+		return len(text)
+	}
+	count := 0
+	for len(text) > 0 {
+		r, size := utf8.DecodeRune(text)
+		if r == utf8.RuneError && size < utf8.UTFMax && !utf8.FullRune(text) {
+			break
+		}
+		count += encoding.units(r)
+		text = text[size:]
+	}
+	return count
+}
+
+// lineOf returns the line that contains the offset.
+func lineOf(lineStart []int, offset int) int {
+	line, found := slices.BinarySearch(lineStart, offset)
+	if !found {
+		line--
+	}
+	return line
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#headerPart
+const contentLengthHeader = "content-length"
+
+// messageReader reads the content of base protocol messages from a stream.
+// A message can be any size, since its content is read as it arrives
+// rather than buffered up front.
+type messageReader struct {
+	r   *bufio.Reader
+	src *prefixReader
+	// resync is set after a header that didn't say where the content
+	// ends. Input is skipped until the next header that looks valid.
+	resync bool
+}
+
+func newMessageReader(r io.Reader) *messageReader {
+	src := &prefixReader{r: r}
+	return &messageReader{r: bufio.NewReader(src), src: src}
+}
+
+// prefixReader reads a prefix before the rest of a stream. It is how input
+// that was read too far is put back.
+type prefixReader struct {
+	prefix []byte
+	r      io.Reader
+}
+
+func (p *prefixReader) Read(b []byte) (int, error) {
+	if len(p.prefix) > 0 {
+		n := copy(b, p.prefix)
+		p.prefix = p.prefix[n:]
+		return n, nil
+	}
+	return p.r.Read(b)
+}
+
+// frameError is a message that couldn't be read. Unlike other errors, the
+// stream can still be read after it.
+type frameError struct {
+	err error
+}
+
+func (e *frameError) Error() string {
+	return "bad message: " + e.err.Error()
+}
+
+func (e *frameError) Unwrap() error {
+	return e.err
+}
+
+// read returns the content of the next message. It returns io.EOF once the
+// stream ends between messages.
+func (m *messageReader) read() ([]byte, error) {
+	if m.resync {
+		if err := m.skipToHeader(); err != nil {
+			return nil, err
+		}
+		m.resync = false
+	}
+
+	length, err := m.readHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := io.ReadAll(io.LimitReader(m.r, length))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) < length {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	return content, nil
+}
+
+// readHeader reads header fields up to the empty line that ends them, and
+// returns the length of the content that follows.
+func (m *messageReader) readHeader() (int64, error) {
+	length := int64(-1)
+	var badHeader error
+
+	for first := true; ; first = false {
+		line, err := m.r.ReadString('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) && (!first || line != "") {
+				return 0, io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			m.resync = true
+			return 0, &frameError{fmt.Errorf("malformed header line %q", line)}
+		}
+		value = strings.TrimSpace(value)
+
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case contentLengthHeader:
+			length, err = strconv.ParseInt(value, 10, 64)
+			if err != nil || length < 0 {
+				badHeader = fmt.Errorf("bad content-length %q", value)
+				length = -1
+			}
+		case "content-type":
+			if err := checkContentType(value); err != nil {
+				badHeader = err
+			}
+		}
+	}
+
+	if length < 0 {
+		m.resync = true
+		if badHeader == nil {
+			badHeader = errors.New("missing content-length")
+		}
+		return 0, &frameError{badHeader}
+	}
+
+	if badHeader != nil {
+		// The content can be skipped, since its length is known.
+		if _, err := io.CopyN(io.Discard, m.r, length); err != nil {
+			return 0, io.ErrUnexpectedEOF
+		}
+		return 0, &frameError{badHeader}
+	}
+
+	return length, nil
+}
+
+// checkContentType accepts JSON in UTF-8. The spec allows "utf8" for
+// backwards compatibility.
+func checkContentType(value string) error {
+	mediaType, params, err := mime.ParseMediaType(value)
+	if err != nil {
+		return fmt.Errorf("bad content-type %q: %w", value, err)
+	}
+	if mediaType != "application/vscode-jsonrpc" && mediaType != "application/json" {
+		return fmt.Errorf("unsupported content-type %q", mediaType)
+	}
+	if charset, ok := params["charset"]; ok && !strings.EqualFold(charset, "utf-8") && !strings.EqualFold(charset, "utf8") {
+		return fmt.Errorf("unsupported charset %q", charset)
+	}
+	return nil
+}
+
+// skipToHeader discards input up to the next Content-Length header, which
+// may directly follow the content of a message on the same line.
+func (m *messageReader) skipToHeader() error {
+	for {
+		line, err := m.r.ReadSlice('\n')
+		if err != nil && !errors.Is(err, bufio.ErrBufferFull) {
+			return err
+		}
+
+		if i := bytes.Index(bytes.ToLower(line), []byte(contentLengthHeader+":")); i >= 0 {
+			buffered, _ := m.r.Peek(m.r.Buffered())
+			m.src.prefix = slices.Concat(line[i:], buffered, m.src.prefix)
+			m.r.Reset(m.src)
+			return nil
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package lsp
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func frame(content string) string {
+	return "Content-Length: " + strconv.Itoa(len(content)) + "\r\n\r\n" + content
+}
+
+// readAll reads messages until the end of the stream. Messages that can't
+// be read are returned as "error".
+func readAll(r io.Reader) ([]string, error) {
+	reader := newMessageReader(r)
+	var messages []string
+	for {
+		content, err := reader.read()
+		var frameErr *frameError
+		switch {
+		case errors.As(err, &frameErr):
+			messages = append(messages, "error")
+		case errors.Is(err, io.EOF):
+			return messages, nil
+		case err != nil:
+			return messages, err
+		default:
+			messages = append(messages, string(content))
+		}
+	}
+}
+
+func TestMessageReader(t *testing.T) {
+	large := strings.Repeat("x", 1<<20)
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr error
+	}{
+		{
+			name:  "Empty",
+			input: "",
+		},
+		{
+			name:  "Messages",
+			input: frame("{}") + frame("[]"),
+			want:  []string{"{}", "[]"},
+		},
+		{
+			name:  "Large",
+			input: frame(large) + frame("{}"),
+			want:  []string{large, "{}"},
+		},
+		{
+			name:  "ContentType",
+			input: "Content-Type: application/vscode-jsonrpc; charset=utf-8\r\nContent-Length: 2\r\n\r\n{}",
+			want:  []string{"{}"},
+		},
+		{
+			name:  "ContentTypeUTF8",
+			input: "content-length:2\ncontent-type: application/json; charset=utf8\n\n{}",
+			want:  []string{"{}"},
+		},
+		{
+			name:  "UnsupportedCharset",
+			input: "Content-Length: 2\r\nContent-Type: application/vscode-jsonrpc; charset=latin1\r\n\r\n{}" + frame("[]"),
+			want:  []string{"error", "[]"},
+		},
+		{
+			name:  "UnsupportedContentType",
+			input: "Content-Length: 2\r\nContent-Type: text/plain\r\n\r\n{}" + frame("[]"),
+			want:  []string{"error", "[]"},
+		},
+		{
+			name:  "MissingContentLength",
+			input: "Content-Type: application/json\r\n\r\n{}" + frame("[]"),
+			want:  []string{"error", "[]"},
+		},
+		{
+			name:  "BadContentLength",
+			input: "Content-Length: -2\r\n\r\n{}" + frame("[]"),
+			want:  []string{"error", "[]"},
+		},
+		{
+			name:  "MalformedHeader",
+			input: "garbage\r\n" + frame("[]"),
+			want:  []string{"error", "[]"},
+		},
+		{
+			name:    "TruncatedHeader",
+			input:   "Content-Length: 2\r\n",
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:    "TruncatedContent",
+			input:   "Content-Length: 20\r\n\r\n{}",
+			wantErr: io.ErrUnexpectedEOF,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			messages, err := readAll(strings.NewReader(tt.input))
+			if tt.wantErr != nil {
+				g.Expect(err).To(MatchError(tt.wantErr))
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+			g.Expect(messages).To(Equal(tt.want))
+		})
+	}
+}
+
+func FuzzMessageReader(f *testing.F) {
+	f.Add([]byte(frame(`{"jsonrpc":"2.0","method":"initialized"}`)))
+	f.Add([]byte("Content-Type: application/json\r\n\r\n{}" + frame("[]")))
+	f.Add([]byte("Content-Length: 99999999999999\r\n\r\n"))
+	f.Add([]byte("garbage\ncontent-length:1\n\n1"))
+
+	f.Fuzz(func(t *testing.T, input []byte) {
+		messages, err := readAll(bytes.NewReader(input))
+
+		// A valid message is still read after a bad one, unless the input
+		// left a header or content unfinished.
+		valid := frame(`{"jsonrpc":"2.0"}`)
+		if err == nil && bytes.HasSuffix(input, []byte("\n")) && len(messages) > 0 && messages[len(messages)-1] == "error" {
+			after, err := readAll(bytes.NewReader(append(bytes.Clone(input), valid...)))
+			if err == nil && (len(after) == 0 || after[len(after)-1] != `{"jsonrpc":"2.0"}`) {
+				t.Errorf("valid message was not read after %q", input)
+			}
+		}
+	})
+}
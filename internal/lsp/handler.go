@@ -0,0 +1,166 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package lsp
+
+import "context"
+
+// DocumentSyncHandler defines methods for handling document synchronization.
+type DocumentSyncHandler interface {
+	DidOpenTextDocument(ctx context.Context, params DidOpenTextDocumentParams) error
+	DidCloseTextDocument(ctx context.Context, params DidCloseTextDocumentParams) error
+	DidChangeTextDocument(ctx context.Context, params DidChangeTextDocumentParams) error
+}
+
+// Handler provides the logic for handling LSP requests and notifications.
+// Every handler keeps documents in sync. The rest of the protocol is
+// optional: the server checks which of the other handler interfaces in this
+// package the handler implements, routes their methods to it, and derives
+// the server capabilities from them. Other methods are answered with
+// [CodeMethodNotFound].
+type Handler interface {
+	DocumentSyncHandler
+}
+
+// InitializeHandler receives the capabilities and workspace of the client.
+// It can refine the server capabilities that were derived from the handler,
+// such as the options of a provider, before they are sent to the client.
+type InitializeHandler interface {
+	Initialize(ctx context.Context, params InitializeParams, serverCapabilities *ServerCapabilities) error
+}
+
+// InitializedHandler is told when the client is ready for requests from the
+// server, which is when background work such as indexing can start. The
+// context is cancelled when the server exits.
+type InitializedHandler interface {
+	Initialized(ctx context.Context) error
+}
+
+// ShutdownHandler is told when the client asks the server to shut down. It
+// must stop the background work of the handler and wait for it, since the
+// connection to the client closes after the response.
+type ShutdownHandler interface {
+	Shutdown(ctx context.Context) error
+}
+
+// DidChangeConfigurationHandler receives settings that changed in the client.
+type DidChangeConfigurationHandler interface {
+	DidChangeConfiguration(ctx context.Context, params DidChangeConfigurationParams) error
+}
+
+// CompletionHandler provides completion.
+type CompletionHandler interface {
+	Completion(ctx context.Context, params CompletionParams) (*CompletionList, error)
+}
+
+// HoverHandler provides hover information.
+type HoverHandler interface {
+	Hover(ctx context.Context, params HoverParams) (*Hover, error)
+}
+
+// DefinitionHandler finds where a symbol is defined.
+type DefinitionHandler interface {
+	Definition(ctx context.Context, params DefinitionParams) ([]Location, error)
+}
+
+// ReferencesHandler finds the references to a symbol.
+type ReferencesHandler interface {
+	References(ctx context.Context, params ReferenceParams) ([]Location, error)
+}
+
+// InlayHintHandler provides inlay hints.
+type InlayHintHandler interface {
+	InlayHint(ctx context.Context, params InlayHintParams) ([]InlayHint, error)
+}
+
+// FormattingHandler formats whole documents.
+type FormattingHandler interface {
+	Formatting(ctx context.Context, params DocumentFormattingParams) ([]TextEdit, error)
+}
+
+// RangeFormattingHandler formats a range of a document.
+type RangeFormattingHandler interface {
+	RangeFormatting(ctx context.Context, params DocumentRangeFormattingParams) ([]TextEdit, error)
+}
+
+// OnTypeFormattingHandler formats a document while the user types one of
+// the trigger characters in its options.
+type OnTypeFormattingHandler interface {
+	OnTypeFormatting(ctx context.Context, params DocumentOnTypeFormattingParams) ([]TextEdit, error)
+	OnTypeFormattingOptions() DocumentOnTypeFormattingOptions
+}
+
+// CodeActionHandler provides code actions.
+type CodeActionHandler interface {
+	CodeAction(ctx context.Context, params CodeActionParams) ([]CodeAction, error)
+}
+
+// DiagnosticHandler provides diagnostics when the client pulls them.
+type DiagnosticHandler interface {
+	Diagnostic(ctx context.Context, params DocumentDiagnosticParams) (*FullDocumentDiagnosticReport, error)
+}
+
+// SemanticTokensHandler provides semantic tokens for whole documents, which
+// are encoded with the types and modifiers of its legend.
+type SemanticTokensHandler interface {
+	SemanticTokensFull(ctx context.Context, params SemanticTokensParams) (*SemanticTokens, error)
+	SemanticTokensLegend() SemanticTokensLegend
+}
+
+// DocumentLinkHandler provides links in documents, such as the paths of
+// included files.
+type DocumentLinkHandler interface {
+	DocumentLink(ctx context.Context, params DocumentLinkParams) ([]DocumentLink, error)
+}
+
+// ExecuteCommandHandler runs the commands that it lists, which clients can
+// bind to their own UI.
+type ExecuteCommandHandler interface {
+	ExecuteCommand(ctx context.Context, params ExecuteCommandParams) (any, error)
+	ExecuteCommands() []string
+}
+
+// serverCapabilities derives the capabilities of a server from the handler
+// interfaces that its handler implements.
+func serverCapabilities(h Handler) *ServerCapabilities {
+	capabilities := &ServerCapabilities{
+		TextDocumentSync: &TextDocumentSyncOptions{
+			OpenClose: true,
+			Change:    SyncIncremental,
+		},
+	}
+
+	if _, ok := h.(CompletionHandler); ok {
+		capabilities.CompletionProvider = &CompletionOptions{}
+	}
+	_, capabilities.HoverProvider = h.(HoverHandler)
+	_, capabilities.DefinitionProvider = h.(DefinitionHandler)
+	_, capabilities.ReferencesProvider = h.(ReferencesHandler)
+	_, capabilities.InlayHintProvider = h.(InlayHintHandler)
+	_, capabilities.DocumentFormattingProvider = h.(FormattingHandler)
+	_, capabilities.DocumentRangeFormattingProvider = h.(RangeFormattingHandler)
+	if onType, ok := h.(OnTypeFormattingHandler); ok {
+		options := onType.OnTypeFormattingOptions()
+		capabilities.DocumentOnTypeFormattingProvider = &options
+	}
+	if _, ok := h.(CodeActionHandler); ok {
+		capabilities.CodeActionProvider = &CodeActionOptions{}
+	}
+	if _, ok := h.(DiagnosticHandler); ok {
+		capabilities.DiagnosticProvider = &DiagnosticOptions{}
+	}
+	if tokens, ok := h.(SemanticTokensHandler); ok {
+		capabilities.SemanticTokensProvider = &SemanticTokensOptions{
+			Legend: tokens.SemanticTokensLegend(),
+			Full:   true,
+		}
+	}
+	if _, ok := h.(DocumentLinkHandler); ok {
+		capabilities.DocumentLinkProvider = &DocumentLinkOptions{}
+	}
+	if commands, ok := h.(ExecuteCommandHandler); ok {
+		capabilities.ExecuteCommandProvider = &ExecuteCommandOptions{Commands: commands.ExecuteCommands()}
+	}
+
+	return capabilities
+}
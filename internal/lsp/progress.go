@@ -0,0 +1,64 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package lsp
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"sync/atomic"
+)
+
+// Progress reports the progress of long-running work to the client, such
+// as indexing a workspace.
+//
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#workDoneProgress
+type Progress struct {
+	client Client
+	token  ProgressToken
+}
+
+var lastProgressToken atomic.Int64
+
+// StartProgress creates a progress indicator in the client, and begins it
+// with a title. It returns nil if the client doesn't support progress or
+// fails to create it. The methods of a nil Progress do nothing, so callers
+// don't need to check.
+func StartProgress(ctx context.Context, c Client, supported bool, title string) *Progress {
+	if !supported {
+		return nil
+	}
+
+	token := "gdshader/" + strconv.FormatInt(lastProgressToken.Add(1), 10)
+	if err := CreateWorkDoneProgress(ctx, c, token); err != nil {
+		slog.Warn("Failed to create progress", "title", title, "error", err)
+		return nil
+	}
+
+	p := &Progress{client: c, token: token}
+	p.notify(ctx, WorkDoneProgressBegin{Kind: "begin", Title: title, Percentage: new(int)})
+	return p
+}
+
+// Report updates the message and percentage, from 0 to 100, of the progress.
+func (p *Progress) Report(ctx context.Context, message string, percentage int) {
+	if p == nil {
+		return
+	}
+	p.notify(ctx, WorkDoneProgressReport{Kind: "report", Message: message, Percentage: &percentage})
+}
+
+// End ends the progress with a final message.
+func (p *Progress) End(ctx context.Context, message string) {
+	if p == nil {
+		return
+	}
+	p.notify(ctx, WorkDoneProgressEnd{Kind: "end", Message: message})
+}
+
+func (p *Progress) notify(ctx context.Context, value any) {
+	if err := p.client.Notify(ctx, "$/progress", ProgressParams{Token: p.token, Value: value}); err != nil {
+		slog.Warn("Failed to report progress", "error", err)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package lsp_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+	"github.com/samber/lo"
+)
+
+// progressClient records the messages that are sent to it as JSON.
+type progressClient struct {
+	messages  []string
+	createErr error
+}
+
+func (c *progressClient) Notify(_ context.Context, method string, params any) error {
+	c.messages = append(c.messages, method+" "+string(lo.Must(json.Marshal(params))))
+	return nil
+}
+
+func (c *progressClient) Request(_ context.Context, method string, params, _ any) error {
+	c.messages = append(c.messages, method+" "+string(lo.Must(json.Marshal(params))))
+	return c.createErr
+}
+
+func TestProgress(t *testing.T) {
+	g := NewWithT(t)
+	client := &progressClient{}
+
+	p := lsp.StartProgress(t.Context(), client, true, "Indexing")
+	p.Report(t.Context(), "1/2", 50)
+	p.End(t.Context(), "Done")
+
+	g.Expect(client.messages).To(HaveLen(4))
+	var token string
+	g.Expect(json.Unmarshal([]byte(client.messages[0][len("window/workDoneProgress/create "):]), &struct {
+		Token *string `json:"token"`
+	}{&token})).To(Succeed())
+	g.Expect(token).ToNot(BeEmpty())
+	g.Expect(client.messages[1:]).To(Equal([]string{
+		`$/progress {"token":"` + token + `","value":{"kind":"begin","title":"Indexing","percentage":0}}`,
+		`$/progress {"token":"` + token + `","value":{"kind":"report","message":"1/2","percentage":50}}`,
+		`$/progress {"token":"` + token + `","value":{"kind":"end","message":"Done"}}`,
+	}))
+}
+
+func TestProgress_Unsupported(t *testing.T) {
+	g := NewWithT(t)
+	client := &progressClient{}
+
+	p := lsp.StartProgress(t.Context(), client, false, "Indexing")
+	p.Report(t.Context(), "1/2", 50)
+	p.End(t.Context(), "Done")
+
+	g.Expect(p).To(BeNil())
+	g.Expect(client.messages).To(BeEmpty())
+}
+
+func TestProgress_CreateFailed(t *testing.T) {
+	g := NewWithT(t)
+	client := &progressClient{createErr: errors.New("no")}
+
+	p := lsp.StartProgress(t.Context(), client, true, "Indexing")
+	p.End(t.Context(), "Done")
+
+	g.Expect(p).To(BeNil())
+	g.Expect(client.messages).To(HaveLen(1))
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package lsp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"unicode"
+	"unicode/utf8"
+)
+
+// RangeToOffsets converts a range in the document to byte offsets. An end
+// position past the last line is clamped to the end of the document.
+func (d *Document) RangeToOffsets(r Range) (start, end int, err error) {
+	return rangeToOffsets(d, d.Lines(), d.Len(), r)
+}
+
+// Slice returns a reader of the content in a range of the document. It reads
+// from the document, so it must not be used after the document changes.
+func (d *Document) Slice(r Range) (*io.SectionReader, error) {
+	return sliceRange(d, d.Lines(), d.Len(), r)
+}
+
+// WordAt returns the word that contains or ends at a position, along with
+// its range. A word is a run of letters, digits and underscores. The word is
+// empty if there is none at the position.
+func (d *Document) WordAt(pos Position) (string, Range, error) {
+	return textAt(d, d.lineStart, d.Len(), d.encoding, pos, wordBounds)
+}
+
+// TokenAt returns the token that contains the character at a position, along
+// with its range. A token is a word or a single punctuation character. The
+// token is empty if the character is whitespace.
+func (d *Document) TokenAt(pos Position) (string, Range, error) {
+	return textAt(d, d.lineStart, d.Len(), d.encoding, pos, tokenBounds)
+}
+
+// RangeToOffsets is like [Document.RangeToOffsets].
+func (s *Snapshot) RangeToOffsets(r Range) (start, end int, err error) {
+	return rangeToOffsets(s, s.Lines(), s.Len(), r)
+}
+
+// Slice is like [Document.Slice]. The reader stays valid, since a snapshot
+// never changes.
+func (s *Snapshot) Slice(r Range) (*io.SectionReader, error) {
+	return sliceRange(s, s.Lines(), s.Len(), r)
+}
+
+// WordAt is like [Document.WordAt].
+func (s *Snapshot) WordAt(pos Position) (string, Range, error) {
+	return textAt(s, s.lineStart, s.Len(), s.encoding, pos, wordBounds)
+}
+
+// TokenAt is like [Document.TokenAt].
+func (s *Snapshot) TokenAt(pos Position) (string, Range, error) {
+	return textAt(s, s.lineStart, s.Len(), s.encoding, pos, tokenBounds)
+}
+
+// positionReader is the content of a Document or a Snapshot.
+type positionReader interface {
+	io.ReaderAt
+	PositionToOffset(pos Position) (int, error)
+}
+
+func rangeToOffsets(text positionReader, lines, length int, r Range) (start, end int, err error) {
+	start, err = text.PositionToOffset(r.Start)
+	if err != nil {
+		return 0, 0, fmt.Errorf("range start: %w", err)
+	}
+	end = length
+	if r.End.Line < lines {
+		if end, err = text.PositionToOffset(r.End); err != nil {
+			return 0, 0, fmt.Errorf("range end: %w", err)
+		}
+	}
+	if end < start {
+		return 0, 0, fmt.Errorf("range end %v is before start %v", r.End, r.Start)
+	}
+	return start, end, nil
+}
+
+func sliceRange(text positionReader, lines, length int, r Range) (*io.SectionReader, error) {
+	start, end, err := rangeToOffsets(text, lines, length, r)
+	if err != nil {
+		return nil, err
+	}
+	return io.NewSectionReader(text, int64(start), int64(end-start)), nil
+}
+
+// textAt finds the bounds of the text at a position within its line.
+func textAt(text positionReader, lineStart []int, length int, encoding PositionEncodingKind, pos Position, bounds func(line []byte, i int) (start, end int)) (string, Range, error) {
+	offset, err := text.PositionToOffset(pos)
+	if err != nil {
+		return "", Range{}, err
+	}
+
+	start, end := lineStart[pos.Line], length
+	if pos.Line+1 < len(lineStart) {
+		end = lineStart[pos.Line+1]
+	}
+	line := make([]byte, end-start)
+	if _, err := text.ReadAt(line, int64(start)); err != nil && !errors.Is(err, io.EOF) {
+		return "", Range{}, fmt.Errorf("read line %d: %w", pos.Line, err)
+	}
+	line = bytes.TrimRight(line, "\r\n")
+
+	i := min(offset-start, len(line))
+	wordStart, wordEnd := bounds(line, i)
+	return string(line[wordStart:wordEnd]), Range{
+		Start: Position{Line: pos.Line, Character: countUnits(line[:wordStart], encoding)},
+		End:   Position{Line: pos.Line, Character: countUnits(line[:wordEnd], encoding)},
+	}, nil
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// wordBounds returns the word that contains or ends at i, or an empty span
+// at i.
+func wordBounds(line []byte, i int) (start, end int) {
+	start, end = i, i
+	for start > 0 {
+		r, size := utf8.DecodeLastRune(line[:start])
+		if !isWordRune(r) {
+			break
+		}
+		start -= size
+	}
+	for end < len(line) {
+		r, size := utf8.DecodeRune(line[end:])
+		if !isWordRune(r) {
+			break
+		}
+		end += size
+	}
+	return start, end
+}
+
+// tokenBounds returns the word or punctuation character at i, or an empty
+// span at i for whitespace.
+func tokenBounds(line []byte, i int) (start, end int) {
+	if i >= len(line) {
+		return i, i
+	}
+	r, size := utf8.DecodeRune(line[i:])
+	switch {
+	case isWordRune(r):
+		start, end = wordBounds(line, i)
+		return start, end
+	case unicode.IsSpace(r):
+		return i, i
+	default:
+		return i, i + size
+	}
+}
@@ -0,0 +1,252 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Direction is which way a recorded message went.
+type Direction string
+
+// Directions of recorded messages.
+const (
+	DirectionIn  Direction = "in"  // From the client to the server.
+	DirectionOut Direction = "out" // From the server to the client.
+)
+
+// RecordedMessage is one line of a recording.
+type RecordedMessage struct {
+	Time      time.Time `json:"time"`
+	Direction Direction `json:"direction"`
+	// Message is the content of the message. Content that is not valid JSON
+	// is kept in Invalid instead.
+	Message json.RawMessage `json:"message,omitempty"`
+	Invalid string          `json:"invalid,omitempty"`
+}
+
+func (m *RecordedMessage) content() []byte {
+	if m.Message != nil {
+		return m.Message
+	}
+	return []byte(m.Invalid)
+}
+
+// Recorder writes every message of a session as a line of JSON, so that
+// the session can be replayed with [Replay]. It is safe for concurrent use.
+type Recorder struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewRecorder returns a recorder that writes to w.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{w: w}
+}
+
+// record writes a message. A nil recorder records nothing, and a failure
+// to record doesn't interrupt the session.
+func (r *Recorder) record(direction Direction, content []byte) {
+	if r == nil {
+		return
+	}
+
+	message := RecordedMessage{Time: time.Now(), Direction: direction}
+	if json.Valid(content) {
+		message.Message = content
+	} else {
+		message.Invalid = string(content)
+	}
+	line, err := json.Marshal(message)
+	if err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, _ = r.w.Write(append(line, '\n'))
+}
+
+// ReadRecording reads the messages of a recording.
+func ReadRecording(r io.Reader) ([]RecordedMessage, error) {
+	var messages []RecordedMessage
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<30)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var message RecordedMessage
+		if err := json.Unmarshal(scanner.Bytes(), &message); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if message.Direction != DirectionIn && message.Direction != DirectionOut {
+			return nil, fmt.Errorf("line %d: bad direction %q", line, message.Direction)
+		}
+		messages = append(messages, message)
+	}
+	return messages, scanner.Err()
+}
+
+// replayWait limits how long Replay waits for each message that the server
+// wrote in the recording.
+const replayWait = 5 * time.Second
+
+// Replay sends the client messages of a recording to a server, and compares
+// the messages that the server writes with the ones in the recording. Each
+// client message is sent once the server has written as many messages as
+// it had at that point of the recording, so that responses to the server's
+// own requests line up.
+//
+// Messages are compared as JSON, regardless of order, since concurrent
+// requests can be answered in any order. The server info in the response to
+// "initialize" is ignored, so that recordings stay valid across releases.
+// Replay returns a description of the differences, which is empty if there
+// are none.
+func Replay(server *Server, recording []RecordedMessage) (string, error) {
+	stdinReader, stdinWriter := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+	server.Stdin = stdinReader
+	server.Stdout = stdoutWriter
+
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve()
+		_ = stdinReader.Close()
+		_ = stdoutWriter.Close()
+	}()
+
+	received := make(chan []byte)
+	go func() {
+		defer close(received)
+		reader := newMessageReader(stdoutReader)
+		for {
+			payload, err := reader.read()
+			if err != nil {
+				return
+			}
+			received <- payload
+		}
+	}()
+
+	var got [][]byte
+	// await collects messages from the server until there are n.
+	await := func(n int) {
+		timeout := time.After(replayWait)
+		for len(got) < n {
+			select {
+			case payload, ok := <-received:
+				if !ok {
+					return
+				}
+				got = append(got, payload)
+			case <-timeout:
+				return
+			}
+		}
+	}
+
+	var want [][]byte
+	var initializeID string
+	for _, message := range recording {
+		if message.Direction == DirectionOut {
+			want = append(want, message.content())
+			continue
+		}
+
+		await(len(want))
+
+		var request RequestMessage
+		if json.Unmarshal(message.Message, &request) == nil && request.Method == "initialize" {
+			initializeID = normalizeMessage(request.ID, "")
+		}
+
+		content := message.content()
+		frame := "Content-Length: " + strconv.Itoa(len(content)) + "\r\n\r\n" + string(content)
+		if _, err := io.WriteString(stdinWriter, frame); err != nil {
+			// The server exited, and the rest of the recording is not
+			// read.
+			break
+		}
+	}
+
+	await(len(want))
+	_ = stdinWriter.Close()
+	for payload := range received {
+		got = append(got, payload)
+	}
+	err := <-served
+	if err != nil && !errors.Is(err, ErrExitWithoutShutdown) {
+		return "", err
+	}
+
+	return diffMessages(want, got, initializeID), nil
+}
+
+// diffMessages describes the messages that are only in want with "-", and
+// the ones that are only in got with "+".
+func diffMessages(want, got [][]byte, initializeID string) string {
+	remaining := make(map[string]int)
+	for _, message := range want {
+		remaining[normalizeMessage(message, initializeID)]++
+	}
+
+	var unexpected []string
+	for _, message := range got {
+		key := normalizeMessage(message, initializeID)
+		if remaining[key] > 0 {
+			remaining[key]--
+		} else {
+			unexpected = append(unexpected, "+ "+key)
+		}
+	}
+
+	var diff []string
+	for _, message := range want {
+		key := normalizeMessage(message, initializeID)
+		if remaining[key] > 0 {
+			remaining[key]--
+			diff = append(diff, "- "+key)
+		}
+	}
+	diff = append(diff, unexpected...)
+	slices.Sort(diff)
+
+	if len(diff) == 0 {
+		return ""
+	}
+	return strings.Join(diff, "\n") + "\n"
+}
+
+// normalizeMessage formats a message with sorted keys, so that messages that
+// only differ in formatting are equal.
+func normalizeMessage(message []byte, initializeID string) string {
+	var value any
+	if json.Unmarshal(message, &value) != nil {
+		return string(message)
+	}
+
+	if response, ok := value.(map[string]any); ok && initializeID != "" {
+		if id, err := json.Marshal(response["id"]); err == nil && string(id) == initializeID {
+			if result, ok := response["result"].(map[string]any); ok {
+				delete(result, "serverInfo")
+			}
+		}
+	}
+
+	normalized, err := json.Marshal(value)
+	if err != nil {
+		return string(message)
+	}
+	return string(normalized)
+}
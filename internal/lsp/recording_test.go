@@ -0,0 +1,80 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package lsp_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+	"github.com/armsnyder/gdshader-language-server/internal/testutil"
+	"github.com/samber/lo"
+)
+
+func TestRecorder(t *testing.T) {
+	g := NewWithT(t)
+	var recording bytes.Buffer
+	send, received := startServer(t, &lsp.Server{Handler: &slowHandler{}, Recorder: lsp.NewRecorder(&recording)})
+
+	send(completionRequest)
+	g.Eventually(received).Should(Receive(Equal(completionResult)))
+	send(`{"jsonrpc":"2.0","id":2,`)
+	g.Eventually(received).Should(Receive(HavePrefix(`{"jsonrpc":"2.0","id":null,"error":{"code":-32700,`)))
+
+	messages := lo.Must(lsp.ReadRecording(&recording))
+	g.Expect(lo.Map(messages, func(m lsp.RecordedMessage, _ int) lsp.Direction { return m.Direction })).To(Equal([]lsp.Direction{
+		lsp.DirectionIn, lsp.DirectionOut, lsp.DirectionIn, lsp.DirectionIn, lsp.DirectionOut, lsp.DirectionIn, lsp.DirectionOut,
+	}))
+	g.Expect(string(messages[3].Message)).To(Equal(completionRequest))
+	g.Expect(messages[4].Time).ToNot(BeZero())
+	g.Expect(messages[5].Invalid).To(Equal(`{"jsonrpc":"2.0","id":2,`))
+}
+
+func TestReplay(t *testing.T) {
+	recording := []string{
+		`{"direction":"in","message":` + initializeRequest + `}`,
+		`{"direction":"out","message":{"jsonrpc":"2.0","id":0,"result":{"capabilities":{"textDocumentSync":{"openClose":true,"change":2},"completionProvider":{},"hoverProvider":true},"serverInfo":{"name":"old","version":"0.0.1"}}}}`,
+		`{"direction":"in","message":{"jsonrpc":"2.0","method":"initialized"}}`,
+		`{"direction":"in","message":` + completionRequest + `}`,
+		`{"direction":"out","message":{"items":[],"isIncomplete":false,"jsonrpc":"2.0","id":1}}`,
+		`{"direction":"in","message":{"jsonrpc":"2.0","id":2,"method":"shutdown"}}`,
+		`{"direction":"out","message":{"jsonrpc":"2.0","id":2,"result":null}}`,
+		`{"direction":"in","message":{"jsonrpc":"2.0","method":"exit"}}`,
+	}
+
+	tests := []struct {
+		name    string
+		replace [2]string
+		want    string
+	}{
+		{
+			name:    "Same",
+			replace: [2]string{`"items":[],"isIncomplete":false,`, `"result":{"items":[],"isIncomplete":false},`},
+		},
+		{
+			name:    "Different",
+			replace: [2]string{`"items":[],"isIncomplete":false,`, `"result":{"items":[{"label":"x"}],"isIncomplete":false},`},
+			want: `+ {"id":1,"jsonrpc":"2.0","result":{"isIncomplete":false,"items":[]}}
+- {"id":1,"jsonrpc":"2.0","result":{"isIncomplete":false,"items":[{"label":"x"}]}}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			testutil.SetupLogger(t)
+
+			text := strings.ReplaceAll(strings.Join(recording, "\n"), tt.replace[0], tt.replace[1])
+			messages := lo.Must(lsp.ReadRecording(strings.NewReader(text)))
+
+			diff, err := lsp.Replay(&lsp.Server{Handler: &slowHandler{}}, messages)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(diff).To(Equal(tt.want))
+		})
+	}
+}
@@ -4,72 +4,234 @@
 package lsp
 
 import (
-	"bufio"
 	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
-	"net/textproto"
 	"os"
 	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
-// DocumentSyncHandler defines methods for handling document synchronization.
-type DocumentSyncHandler interface {
-	DidOpenTextDocument(ctx context.Context, params DidOpenTextDocumentParams) error
-	DidCloseTextDocument(ctx context.Context, params DidCloseTextDocumentParams) error
-	DidChangeTextDocument(ctx context.Context, params DidChangeTextDocumentParams) error
-}
-
-// Handler provides the logic for handling LSP requests and notifications.
-type Handler interface {
-	DocumentSyncHandler
-	Initialize(ctx context.Context, clientCapabilities ClientCapabilities) (*ServerCapabilities, error)
-	Completion(ctx context.Context, params CompletionParams) (*CompletionList, error)
-	Hover(ctx context.Context, params HoverParams) (*Hover, error)
-}
-
 // Server manages the LSP server lifecycle and dispatching requests and
 // notifications to a handler.
+//
+// Notifications are handled one at a time, in the order that they arrive.
+// Requests are handled concurrently with each other and with the
+// notifications that arrive after them, so the handler must be safe for
+// concurrent use. Each request sees the effects of every notification that
+// arrived before it.
+//
+// Handlers can send requests to the client with [Server.Request] while they
+// run, since responses from the client are read alongside other messages.
+//
+// The server follows the lifecycle in the specification: requests before
+// "initialize" and after "shutdown" are rejected, and notifications other
+// than "exit" are dropped.
 type Server struct {
 	Stdin   io.Reader
 	Stdout  io.Writer
 	Info    ServerInfo
 	Handler Handler
+	// RequestTimeout limits how long [Server.Request] waits for the client
+	// to respond. It defaults to 30 seconds.
+	RequestTimeout time.Duration
+	// Recorder records every message that is read or written, if it is set.
+	Recorder *Recorder
+
+	writeMu   sync.Mutex
+	pendingMu sync.Mutex
+	pending   map[string]*pendingRequest
+	running   sync.WaitGroup
+
+	callsMu    sync.Mutex
+	calls      map[string]chan *responsePayload
+	lastCallID atomic.Int64
+	done       chan struct{}
+
+	// state is only used by the read loop.
+	state serverState
 }
 
+// serverState is where the server is in its lifecycle.
+type serverState int
+
+const (
+	stateUninitialized serverState = iota
+	stateInitialized
+	stateShutdown
+)
+
+// pendingRequest is a request that has not been responded to yet.
+type pendingRequest struct {
+	*RequestMessage
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	// uri is the document that the request is about, if any.
+	uri     string
+	started bool
+	// batch collects the response if the request arrived in a batch.
+	batch *batch
+	slot  int
+}
+
+// responsePayload is a response from the client to a request that the
+// server sent.
+type responsePayload struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *ResponseError  `json:"error"`
+}
+
+var (
+	errRequestCancelled     = &ResponseError{Code: CodeRequestCancelled, Message: "Request cancelled"}
+	errContentModified      = &ResponseError{Code: CodeContentModified, Message: "Content modified"}
+	errServerNotInitialized = &ResponseError{Code: CodeServerNotInitialized, Message: "Server not initialized"}
+	errAlreadyInitialized   = &ResponseError{Code: CodeInvalidRequest, Message: "Server already initialized"}
+	errServerShutDown       = &ResponseError{Code: CodeInvalidRequest, Message: "Server is shut down"}
+)
+
+// ErrExitWithoutShutdown is returned by [Server.Serve] when the client sends
+// "exit" without "shutdown" first. The process should exit with code 1.
+var ErrExitWithoutShutdown = errors.New("exit without shutdown")
+
 // Serve runs the LSP server. It blocks until the client receives an "exit".
 func (s *Server) Serve() error {
 	if s.Stdin == nil {
 		s.Stdin = os.Stdin
 	}
-
-	scanner := bufio.NewScanner(s.Stdin)
-	scanner.Split(jsonRPCSplit)
+	if s.Stdout == nil {
+		s.Stdout = os.Stdout
+	}
+	s.pending = make(map[string]*pendingRequest)
+	s.calls = make(map[string]chan *responsePayload)
+	s.done = make(chan struct{})
+
+	reader := newMessageReader(s.Stdin)
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	queue := newMessageQueue()
+	dispatched := make(chan struct{})
+	go func() {
+		defer close(dispatched)
+		s.dispatch(ctx, queue)
+	}()
+	defer func() {
+		// Messages that are still queued are handled with a cancelled
+		// context, so that they finish quickly.
+		cancel(errRequestCancelled)
+		queue.close()
+		close(s.done)
+		<-dispatched
+		s.running.Wait()
+	}()
 
 	slog.Info("Server is running", "name", s.Info.Name, "version", s.Info.Version)
 
-	for scanner.Scan() {
-		if !s.processMessage(scanner.Bytes()) {
+	for {
+		payload, err := reader.read()
+		var frameErr *frameError
+		switch {
+		case errors.As(err, &frameErr):
+			s.respondParseError(frameErr)
+			continue
+		case errors.Is(err, io.EOF):
+			slog.Info("Client closed the connection")
 			return nil
+		case err != nil:
+			slog.Error("Read error", "error", err)
+			return err
 		}
+
+		s.Recorder.record(DirectionIn, payload)
+
+		if !s.processPayload(ctx, queue, payload) {
+			if s.state != stateShutdown {
+				return ErrExitWithoutShutdown
+			}
+			return nil
+		}
+	}
+}
+
+// processPayload processes a single message, or each message of a batch.
+// It returns false once the client sends "exit".
+func (s *Server) processPayload(ctx context.Context, queue *messageQueue, payload []byte) bool {
+	if !json.Valid(payload) {
+		s.respondParseError(errors.New("invalid JSON"))
+		return true
 	}
 
-	slog.Error("Scanner error", "error", scanner.Err())
+	trimmed := bytes.TrimLeft(payload, " \t\r\n")
+	if len(trimmed) == 0 || trimmed[0] != '[' {
+		return s.processMessage(ctx, queue, payload, nil)
+	}
 
-	return scanner.Err()
+	// https://www.jsonrpc.org/specification#batch
+	var messages []json.RawMessage
+	if err := json.Unmarshal(trimmed, &messages); err != nil || len(messages) == 0 {
+		s.reply(nil, 0, errorResponse(nil, &ResponseError{Code: CodeInvalidRequest, Message: "Empty batch"}))
+		return true
+	}
+
+	b := &batch{}
+	defer func() {
+		if b.seal() {
+			s.writeBatch(b)
+		}
+	}()
+	for _, message := range messages {
+		if !s.processMessage(ctx, queue, message, b) {
+			return false
+		}
+	}
+	return true
 }
 
-func (s *Server) processMessage(payload []byte) bool {
-	var request RequestMessage
-	if err := json.Unmarshal(payload, &request); err != nil {
-		slog.Error("Bad request", "error", err)
+// processMessage queues a message to be dispatched. It never blocks on a
+// handler, so that cancellations are seen while requests are running. The
+// response to a request is added to the batch, if there is one.
+func (s *Server) processMessage(ctx context.Context, queue *messageQueue, payload []byte, b *batch) bool {
+	var message struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Method  string          `json:"method"`
+		Params  json.RawMessage `json:"params"`
+		Result  json.RawMessage `json:"result"`
+		Error   json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(payload, &message); err != nil {
+		s.reply(b, b.reserve(), errorResponse(nil, &ResponseError{Code: CodeInvalidRequest, Message: "Message is not an object"}))
+		return true
+	}
+
+	switch {
+	case message.JSONRPC != "2.0":
+		s.reply(b, b.reserve(), errorResponse(message.ID, &ResponseError{Code: CodeInvalidRequest, Message: "Unsupported JSON-RPC version"}))
+		return true
+
+	case message.Method == "" && len(message.ID) > 0 && (len(message.Result) > 0 || len(message.Error) > 0):
+		s.processResponse(payload)
+		return true
+
+	case message.Method == "":
+		s.reply(b, b.reserve(), errorResponse(message.ID, &ResponseError{Code: CodeInvalidRequest, Message: "Missing method"}))
 		return true
 	}
 
+	request := RequestMessage{
+		JSONRPC: message.JSONRPC,
+		ID:      message.ID,
+		Method:  message.Method,
+		Params:  message.Params,
+	}
+
 	if len(request.ID) == 0 {
 		logger := slog.With("method", request.Method)
 		logger.Debug("Received notification", "params", string(request.Params))
@@ -79,29 +241,195 @@ func (s *Server) processMessage(payload []byte) bool {
 			return false
 		}
 
-		if err := s.handleNotification(request.Method, request.Params); err != nil {
-			logger.Error("Error handling notification", "error", err)
+		if s.state != stateInitialized {
+			logger.Warn("Dropped notification outside of the session")
+			return true
+		}
+
+		switch request.Method {
+
+		case "$/cancelRequest":
+			var params CancelParams
+			if err := parseParams(request.Params, &params); err != nil {
+				logger.Error("Error handling notification", "error", err)
+				return true
+			}
+			s.cancelRequests(func(p *pendingRequest) bool { return bytes.Equal(p.ID, params.ID) }, errRequestCancelled)
+			return true
+
+		case "textDocument/didChange", "textDocument/didClose":
+			// Results that are computed from the old content are stale.
+			if uri := documentURI(request.Params); uri != "" {
+				s.cancelRequests(func(p *pendingRequest) bool { return p.uri == uri }, errContentModified)
+			}
 		}
 
+		queue.push(&request)
+		return true
+	}
+
+	switch {
+	case s.state == stateUninitialized && request.Method != "initialize":
+		s.reply(b, b.reserve(), errorResponse(request.ID, errServerNotInitialized))
 		return true
+
+	case s.state != stateUninitialized && request.Method == "initialize":
+		s.reply(b, b.reserve(), errorResponse(request.ID, errAlreadyInitialized))
+		return true
+
+	case s.state == stateShutdown:
+		s.reply(b, b.reserve(), errorResponse(request.ID, errServerShutDown))
+		return true
+
+	case request.Method == "initialize":
+		s.state = stateInitialized
+
+	case request.Method == "shutdown":
+		s.state = stateShutdown
+		// Work in progress is abandoned, since nobody will use the result.
+		s.cancelRequests(func(p *pendingRequest) bool { return p.started }, errRequestCancelled)
+	}
+
+	reqCtx, cancel := context.WithCancelCause(ctx)
+	s.pendingMu.Lock()
+	s.pending[string(request.ID)] = &pendingRequest{
+		RequestMessage: &request,
+		ctx:            reqCtx,
+		cancel:         cancel,
+		uri:            documentURI(request.Params),
+		batch:          b,
+		slot:           b.reserve(),
+	}
+	s.pendingMu.Unlock()
+
+	queue.push(&request)
+	return true
+}
+
+// processResponse delivers a response from the client to the request that
+// is waiting for it.
+func (s *Server) processResponse(payload []byte) {
+	var response responsePayload
+	if err := json.Unmarshal(payload, &response); err != nil {
+		slog.Error("Bad response", "error", err)
+		return
+	}
+
+	s.callsMu.Lock()
+	call, ok := s.calls[string(response.ID)]
+	delete(s.calls, string(response.ID))
+	s.callsMu.Unlock()
+
+	if !ok {
+		slog.Warn("Response to unknown request", "request_id", string(response.ID))
+		return
+	}
+	call <- &response
+}
+
+// dispatch handles queued messages in order until the queue is closed.
+func (s *Server) dispatch(ctx context.Context, queue *messageQueue) {
+	for {
+		request, ok := queue.pop()
+		if !ok {
+			return
+		}
+
+		if len(request.ID) == 0 {
+			if err := s.handleNotification(ctx, request.Method, request.Params); err != nil {
+				slog.Error("Error handling notification", "method", request.Method, "error", err)
+			}
+			continue
+		}
+
+		p, ok := s.startRequest(request.ID)
+		if !ok {
+			// It was cancelled and answered while it was queued.
+			continue
+		}
+
+		switch request.Method {
+		case "initialize", "shutdown":
+			// Nothing that arrives later starts before these are done.
+			s.handle(p)
+
+		default:
+			s.running.Add(1)
+			go func() {
+				defer s.running.Done()
+				s.handle(p)
+			}()
+		}
+	}
+}
+
+func (s *Server) startRequest(id json.RawMessage) (*pendingRequest, bool) {
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+
+	p, ok := s.pending[string(id)]
+	if ok {
+		p.started = true
+	}
+	return p, ok
+}
+
+// cancelRequests cancels the context of every pending request that matches.
+// Requests that have not started yet are answered right away, so that stale
+// requests don't wait for the slow ones ahead of them.
+func (s *Server) cancelRequests(match func(p *pendingRequest) bool, cause *ResponseError) {
+	var unstarted []*pendingRequest
+
+	s.pendingMu.Lock()
+	for id, p := range s.pending {
+		if !match(p) {
+			continue
+		}
+		p.cancel(cause)
+		if !p.started {
+			delete(s.pending, id)
+			unstarted = append(unstarted, p)
+		}
 	}
+	s.pendingMu.Unlock()
 
-	logger := slog.With("request_id", request.ID, "method", request.Method)
-	debugEnabled := logger.Enabled(context.TODO(), slog.LevelDebug)
+	for _, p := range unstarted {
+		s.respond(p, nil, cause)
+	}
+}
 
-	if debugEnabled {
-		logger.Debug("Received request", "params", string(request.Params))
+func (s *Server) handle(p *pendingRequest) {
+	logger := slog.With("request_id", p.ID, "method", p.Method)
+	if logger.Enabled(p.ctx, slog.LevelDebug) {
+		logger.Debug("Received request", "params", string(p.Params))
+	}
+
+	result, err := s.handleRequest(p.ctx, p.Method, p.Params)
+
+	s.pendingMu.Lock()
+	delete(s.pending, string(p.ID))
+	s.pendingMu.Unlock()
+
+	s.respond(p, result, err)
+	p.cancel(nil)
+}
+
+func (s *Server) respond(p *pendingRequest, result any, err error) {
+	logger := slog.With("request_id", p.ID, "method", p.Method)
+
+	// A handler that gives up because of a cancellation usually returns
+	// the context error, which is replaced by the reason.
+	if err != nil && p.ctx.Err() != nil {
+		err = context.Cause(p.ctx)
 	}
 
-	result, err := s.handleRequest(request.Method, request.Params)
 	if err == nil {
-		err = s.writeMessage(&ResponseMessage{
+		s.reply(p.batch, p.slot, &ResponseMessage{
 			JSONRPC: "2.0",
-			ID:      request.ID,
+			ID:      p.ID,
 			Result:  result,
 		})
 	} else {
-		logger.Error("Error handling request", "error", err)
 		var asResponseError *ResponseError
 		if !errors.As(err, &asResponseError) {
 			asResponseError = &ResponseError{
@@ -109,81 +437,200 @@ func (s *Server) processMessage(payload []byte) bool {
 				Message: err.Error(),
 			}
 		}
-		err = s.writeMessage(&ErrorResponseMessage{
-			JSONRPC: "2.0",
-			ID:      request.ID,
-			Error:   asResponseError,
-		})
-	}
-
-	if err != nil {
-		logger.Error("Failed to write response", "error", err)
+		if asResponseError.Code == CodeRequestCancelled || asResponseError.Code == CodeContentModified {
+			logger.Debug("Request cancelled", "reason", asResponseError.Message)
+		} else {
+			logger.Error("Error handling request", "error", err)
+		}
+		s.reply(p.batch, p.slot, errorResponse(p.ID, asResponseError))
 	}
 
-	if debugEnabled {
+	if logger.Enabled(p.ctx, slog.LevelDebug) {
 		logger.Debug("Sent response", "response", fmt.Sprintf("%#v", result))
 	}
+}
 
-	return true
+// respondParseError answers a message that couldn't be read. It has a null
+// ID, since the ID of the message is not known.
+func (s *Server) respondParseError(err error) {
+	slog.Error("Bad message", "error", err)
+	s.reply(nil, 0, errorResponse(nil, &ResponseError{Code: CodeParseError, Message: err.Error()}))
 }
 
-func jsonRPCSplit(data []byte, _ bool) (advance int, token []byte, err error) {
-	const headerDelimiter = "\r\n\r\n"
+func errorResponse(id json.RawMessage, err *ResponseError) *ErrorResponseMessage {
+	return &ErrorResponseMessage{JSONRPC: "2.0", ID: id, Error: err}
+}
 
-	i := bytes.Index(data, []byte(headerDelimiter))
-	if i == -1 {
-		return 0, nil, nil
+// reply sends a response, or adds it to its slot in a batch. The batch is
+// sent once every request in it is answered.
+func (s *Server) reply(b *batch, slot int, message Message) {
+	if b == nil {
+		if err := s.writeMessage(message); err != nil {
+			slog.Error("Failed to write response", "error", err)
+		}
+		return
 	}
+	if b.set(slot, message) {
+		s.writeBatch(b)
+	}
+}
 
-	payloadIndex := i + len(headerDelimiter)
-
-	header, err := textproto.NewReader(bufio.NewReader(bytes.NewReader(data[:payloadIndex]))).ReadMIMEHeader()
+func (s *Server) writeBatch(b *batch) {
+	data, err := json.Marshal(b.responses)
+	if err == nil {
+		err = s.writeRaw(data)
+	}
 	if err != nil {
-		return 0, nil, fmt.Errorf("bad header: %w", err)
+		slog.Error("Failed to write batch response", "error", err)
 	}
+}
 
-	contentLength, err := strconv.Atoi(header.Get("content-length"))
-	if err != nil {
-		return 0, nil, fmt.Errorf("bad content-length: %w", err)
+// batch collects the responses to the requests of a batch, in the order
+// that the requests arrived. Notifications have no response, and a batch
+// without responses is never sent.
+type batch struct {
+	mu        sync.Mutex
+	responses []Message
+	remaining int
+	sealed    bool
+}
+
+// reserve returns the slot for the response to a request. A nil batch
+// reserves nothing.
+func (b *batch) reserve() int {
+	if b == nil {
+		return 0
 	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.responses = append(b.responses, nil)
+	b.remaining++
+	return len(b.responses) - 1
+}
+
+// set stores a response, and reports whether the batch is ready to send.
+func (b *batch) set(slot int, message Message) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.responses[slot] = message
+	b.remaining--
+	return b.sealed && b.remaining == 0
+}
+
+// seal marks that every message of the batch has been read, and reports
+// whether the batch is ready to send.
+func (b *batch) seal() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sealed = true
+	return b.remaining == 0 && len(b.responses) > 0
+}
+
+// documentURI returns the URI of the text document that the params of a
+// message refer to, or an empty string.
+func documentURI(paramsRaw json.RawMessage) string {
+	var params struct {
+		TextDocument TextDocumentIdentifier `json:"textDocument"`
+	}
+	if json.Unmarshal(paramsRaw, &params) != nil {
+		return ""
+	}
+	return params.TextDocument.URI
+}
 
-	restBytes := data[payloadIndex:]
+// messageQueue is an unbounded FIFO queue of messages waiting to be
+// dispatched.
+type messageQueue struct {
+	mu     sync.Mutex
+	items  []*RequestMessage
+	closed bool
+	ready  chan struct{}
+}
+
+func newMessageQueue() *messageQueue {
+	return &messageQueue{ready: make(chan struct{}, 1)}
+}
+
+func (q *messageQueue) push(m *RequestMessage) {
+	q.mu.Lock()
+	q.items = append(q.items, m)
+	q.mu.Unlock()
+	q.signal()
+}
+
+// close makes pop return false once the queued messages are drained.
+func (q *messageQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	q.signal()
+}
 
-	if len(restBytes) < contentLength {
-		return 0, nil, nil
+func (q *messageQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
 	}
+}
 
-	return payloadIndex + contentLength, restBytes[:contentLength], nil
+// pop blocks until there is a message, or returns false once the queue is
+// closed and empty.
+func (q *messageQueue) pop() (*RequestMessage, bool) {
+	for {
+		q.mu.Lock()
+		if len(q.items) > 0 {
+			m := q.items[0]
+			q.items = q.items[1:]
+			q.mu.Unlock()
+			return m, true
+		}
+		closed := q.closed
+		q.mu.Unlock()
+		if closed {
+			return nil, false
+		}
+		<-q.ready
+	}
 }
 
-func (s *Server) handleNotification(method string, paramsRaw json.RawMessage) error {
+func (s *Server) handleNotification(ctx context.Context, method string, paramsRaw json.RawMessage) error {
 	switch method {
 	case "initialized":
-
-	case "$/cancelRequest":
-		// TODO(asnyder): Handle cancelRequest and make everything
-		// async.
+		if h, ok := s.Handler.(InitializedHandler); ok {
+			return h.Initialized(ctx)
+		}
 
 	case "textDocument/didOpen":
 		var params DidOpenTextDocumentParams
 		if err := parseParams(paramsRaw, &params); err != nil {
 			return err
 		}
-		return s.Handler.DidOpenTextDocument(context.TODO(), params)
+		return s.Handler.DidOpenTextDocument(ctx, params)
 
 	case "textDocument/didClose":
 		var params DidCloseTextDocumentParams
 		if err := parseParams(paramsRaw, &params); err != nil {
 			return err
 		}
-		return s.Handler.DidCloseTextDocument(context.TODO(), params)
+		return s.Handler.DidCloseTextDocument(ctx, params)
 
 	case "textDocument/didChange":
 		var params DidChangeTextDocumentParams
 		if err := parseParams(paramsRaw, &params); err != nil {
 			return err
 		}
-		return s.Handler.DidChangeTextDocument(context.TODO(), params)
+		return s.Handler.DidChangeTextDocument(ctx, params)
+
+	case "workspace/didChangeConfiguration":
+		h, ok := s.Handler.(DidChangeConfigurationHandler)
+		if !ok {
+			return nil
+		}
+		var params DidChangeConfigurationParams
+		if err := parseParams(paramsRaw, &params); err != nil {
+			return err
+		}
+		return h.DidChangeConfiguration(ctx, params)
 
 	default:
 		slog.Warn("Unknown notification", "method", method)
@@ -191,26 +638,21 @@ func (s *Server) handleNotification(method string, paramsRaw json.RawMessage) er
 	return nil
 }
 
-func (s *Server) handleRequest(method string, paramsRaw json.RawMessage) (any, error) {
+func (s *Server) handleRequest(ctx context.Context, method string, paramsRaw json.RawMessage) (any, error) {
 	switch method {
 	case "initialize":
-		// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#initializeParams
-		var params struct {
-			ClientInfo struct {
-				Name    string `json:"name"`
-				Version string `json:"version"`
-			} `json:"clientInfo"`
-			Capabilities ClientCapabilities `json:"capabilities"`
-		}
+		var params InitializeParams
 		if err := parseParams(paramsRaw, &params); err != nil {
 			return nil, err
 		}
 
 		slog.Info("Client info", "name", params.ClientInfo.Name, "version", params.ClientInfo.Version)
 
-		serverCapabilities, err := s.Handler.Initialize(context.TODO(), params.Capabilities)
-		if err != nil {
-			return nil, err
+		serverCapabilities := serverCapabilities(s.Handler)
+		if h, ok := s.Handler.(InitializeHandler); ok {
+			if err := h.Initialize(ctx, params, serverCapabilities); err != nil {
+				return nil, err
+			}
 		}
 
 		// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#initializeResult
@@ -220,27 +662,74 @@ func (s *Server) handleRequest(method string, paramsRaw json.RawMessage) (any, e
 		}{Capabilities: serverCapabilities, ServerInfo: s.Info}, nil
 
 	case "shutdown":
+		if h, ok := s.Handler.(ShutdownHandler); ok {
+			return nil, h.Shutdown(ctx)
+		}
 		return nil, nil
 
 	case "textDocument/completion":
-		var params CompletionParams
-		if err := parseParams(paramsRaw, &params); err != nil {
-			return nil, err
-		}
-		return s.Handler.Completion(context.TODO(), params)
+		return route(ctx, s.Handler, method, paramsRaw, CompletionHandler.Completion)
 
 	case "textDocument/hover":
-		var params HoverParams
-		if err := parseParams(paramsRaw, &params); err != nil {
-			return nil, err
-		}
-		return s.Handler.Hover(context.TODO(), params)
+		return route(ctx, s.Handler, method, paramsRaw, HoverHandler.Hover)
+
+	case "textDocument/definition":
+		return route(ctx, s.Handler, method, paramsRaw, DefinitionHandler.Definition)
+
+	case "textDocument/references":
+		return route(ctx, s.Handler, method, paramsRaw, ReferencesHandler.References)
+
+	case "textDocument/inlayHint":
+		return route(ctx, s.Handler, method, paramsRaw, InlayHintHandler.InlayHint)
+
+	case "textDocument/formatting":
+		return route(ctx, s.Handler, method, paramsRaw, FormattingHandler.Formatting)
+
+	case "textDocument/rangeFormatting":
+		return route(ctx, s.Handler, method, paramsRaw, RangeFormattingHandler.RangeFormatting)
+
+	case "textDocument/onTypeFormatting":
+		return route(ctx, s.Handler, method, paramsRaw, OnTypeFormattingHandler.OnTypeFormatting)
+
+	case "textDocument/codeAction":
+		return route(ctx, s.Handler, method, paramsRaw, CodeActionHandler.CodeAction)
+
+	case "textDocument/diagnostic":
+		return route(ctx, s.Handler, method, paramsRaw, DiagnosticHandler.Diagnostic)
+
+	case "textDocument/semanticTokens/full":
+		return route(ctx, s.Handler, method, paramsRaw, SemanticTokensHandler.SemanticTokensFull)
+
+	case "textDocument/documentLink":
+		return route(ctx, s.Handler, method, paramsRaw, DocumentLinkHandler.DocumentLink)
+
+	case "workspace/executeCommand":
+		return route(ctx, s.Handler, method, paramsRaw, ExecuteCommandHandler.ExecuteCommand)
 
 	default:
-		return nil, &ResponseError{
-			Code:    CodeMethodNotFound,
-			Message: fmt.Sprintf("Unknown method %q", method),
-		}
+		return nil, errMethodNotFound(method)
+	}
+}
+
+// route calls a method of an optional handler interface with the parsed
+// params. A handler that doesn't implement the interface doesn't support the
+// method.
+func route[H, P, R any](ctx context.Context, handler Handler, method string, paramsRaw json.RawMessage, fn func(H, context.Context, P) (R, error)) (any, error) {
+	h, ok := handler.(H)
+	if !ok {
+		return nil, errMethodNotFound(method)
+	}
+	var params P
+	if err := parseParams(paramsRaw, &params); err != nil {
+		return nil, err
+	}
+	return fn(h, ctx, params)
+}
+
+func errMethodNotFound(method string) *ResponseError {
+	return &ResponseError{
+		Code:    CodeMethodNotFound,
+		Message: fmt.Sprintf("Unknown method %q", method),
 	}
 }
 
@@ -255,6 +744,92 @@ func parseParams(paramsRaw json.RawMessage, result any) error {
 	return nil
 }
 
+// Notify implements Client.
+func (s *Server) Notify(_ context.Context, method string, params any) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("marshal params: %w", err)
+	}
+
+	return s.writeMessage(&NotificationMessage{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  data,
+	})
+}
+
+// Request implements Client. It gives up after the RequestTimeout, or when
+// the context is done, and then tells the client that the request is
+// cancelled.
+func (s *Server) Request(ctx context.Context, method string, params, result any) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("marshal params: %w", err)
+	}
+
+	id := json.RawMessage(strconv.FormatInt(s.lastCallID.Add(1), 10))
+	call := make(chan *responsePayload, 1)
+	s.callsMu.Lock()
+	s.calls[string(id)] = call
+	s.callsMu.Unlock()
+
+	forget := func() {
+		s.callsMu.Lock()
+		delete(s.calls, string(id))
+		s.callsMu.Unlock()
+	}
+
+	logger := slog.With("request_id", id, "method", method)
+	logger.Debug("Sending request", "params", string(data))
+
+	if err := s.writeMessage(&RequestMessage{
+		JSONRPC: "2.0",
+		ID:      id,
+		Method:  method,
+		Params:  data,
+	}); err != nil {
+		forget()
+		return err
+	}
+
+	timer := time.NewTimer(cmp.Or(s.RequestTimeout, 30*time.Second))
+	defer timer.Stop()
+
+	var response *responsePayload
+	select {
+	case response = <-call:
+	case <-ctx.Done():
+		err = context.Cause(ctx)
+	case <-timer.C:
+		err = fmt.Errorf("%s: timed out waiting for response", method)
+	case <-s.done:
+		err = fmt.Errorf("%s: server stopped", method)
+	}
+
+	if err != nil {
+		forget()
+		if cancelErr := s.Notify(ctx, "$/cancelRequest", CancelParams{ID: id}); cancelErr != nil {
+			logger.Error("Failed to cancel request", "error", cancelErr)
+		}
+		return err
+	}
+
+	logger.Debug("Received response", "result", string(response.Result))
+
+	if response.Error != nil {
+		return response.Error
+	}
+	if result == nil || len(response.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(response.Result, result); err != nil {
+		return fmt.Errorf("%s: unmarshal result: %w", method, err)
+	}
+	return nil
+}
+
+var _ Client = (*Server)(nil)
+
 func (s *Server) writeMessage(message Message) error {
 	data, err := json.Marshal(message)
 	if err != nil {
@@ -269,6 +844,11 @@ func (s *Server) writeMessage(message Message) error {
 }
 
 func (s *Server) writeRaw(data []byte) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.Recorder.record(DirectionOut, data)
+
 	if s.Stdout == nil {
 		s.Stdout = os.Stdout
 	}
@@ -0,0 +1,300 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package lsp_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+	"github.com/armsnyder/gdshader-language-server/internal/testutil"
+	"github.com/samber/lo"
+)
+
+// slowHandler blocks hover requests until they are cancelled, and changes
+// until blockChanges is closed.
+type slowHandler struct {
+	lsp.Handler
+	hovering     chan string
+	blockChanges chan struct{}
+}
+
+func (h *slowHandler) Hover(ctx context.Context, params lsp.HoverParams) (*lsp.Hover, error) {
+	h.hovering <- params.TextDocument.URI
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (h *slowHandler) Completion(context.Context, lsp.CompletionParams) (*lsp.CompletionList, error) {
+	return &lsp.CompletionList{Items: []lsp.CompletionItem{}}, nil
+}
+
+func (h *slowHandler) DidOpenTextDocument(context.Context, lsp.DidOpenTextDocumentParams) error {
+	return nil
+}
+
+func (h *slowHandler) DidChangeTextDocument(context.Context, lsp.DidChangeTextDocumentParams) error {
+	if h.blockChanges != nil {
+		<-h.blockChanges
+	}
+	return nil
+}
+
+// configHandler asks the client for configuration on hover, and shows the
+// result.
+type configHandler struct {
+	slowHandler
+	client lsp.Client
+}
+
+func (h *configHandler) Hover(ctx context.Context, _ lsp.HoverParams) (*lsp.Hover, error) {
+	config, err := lsp.Configuration(ctx, h.client, lsp.ConfigurationItem{Section: "gdshader"})
+	if err != nil {
+		return nil, err
+	}
+	return &lsp.Hover{Contents: lsp.MarkupContent{Kind: lsp.MarkupPlainText, Value: string(config[0])}}, nil
+}
+
+// startServer serves, completes the initialize handshake, and returns
+// functions to send messages to the server and a channel of the messages
+// that it writes.
+func startServer(t *testing.T, server *lsp.Server) (send func(string), received <-chan string) {
+	t.Helper()
+	send, received, _ = serve(t, server)
+
+	send(`{"jsonrpc":"2.0","id":0,"method":"initialize","params":{"capabilities":{}}}`)
+	NewWithT(t).Eventually(received).Should(Receive(HavePrefix(`{"jsonrpc":"2.0","id":0,"result":`)))
+	send(`{"jsonrpc":"2.0","method":"initialized","params":{}}`)
+
+	return send, received
+}
+
+// serve runs the server and returns functions to send messages to the
+// server, a channel of the messages that it writes, and a channel of the
+// error that Serve returns.
+func serve(t *testing.T, server *lsp.Server) (send func(string), received <-chan string, served <-chan error) {
+	t.Helper()
+	testutil.SetupLogger(t)
+
+	stdinReader, stdinWriter := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+	server.Stdin = stdinReader
+	server.Stdout = stdoutWriter
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.Serve()
+		close(errs)
+	}()
+
+	messages := make(chan string, 16)
+	go func() {
+		reader := textproto.NewReader(bufio.NewReader(stdoutReader))
+		for {
+			header, err := reader.ReadMIMEHeader()
+			if err != nil {
+				return
+			}
+			payload := make([]byte, lo.Must(strconv.Atoi(header.Get("Content-Length"))))
+			if _, err := io.ReadFull(reader.R, payload); err != nil {
+				return
+			}
+			messages <- string(payload)
+		}
+	}()
+
+	send = func(s string) {
+		_, _ = io.WriteString(stdinWriter, "Content-Length: "+strconv.Itoa(len(s))+"\r\n\r\n"+s)
+	}
+
+	t.Cleanup(func() {
+		// The server stops when the client closes the connection, if it
+		// has not exited already.
+		_ = stdinWriter.Close()
+		select {
+		case <-errs:
+		case <-time.After(5 * time.Second):
+			t.Error("Server did not exit in time")
+		}
+		_ = stdoutWriter.Close()
+	})
+
+	return send, messages, errs
+}
+
+func TestServer_CancelRequest(t *testing.T) {
+	g := NewWithT(t)
+	handler := &slowHandler{hovering: make(chan string, 1)}
+	send, received := startServer(t, &lsp.Server{Handler: handler})
+
+	send(`{"jsonrpc":"2.0","id":1,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///a.gdshader"}}}`)
+	g.Eventually(handler.hovering).Should(Receive())
+	send(`{"jsonrpc":"2.0","method":"$/cancelRequest","params":{"id":1}}`)
+
+	g.Eventually(received).Should(Receive(Equal(`{"jsonrpc":"2.0","id":1,"error":{"code":-32800,"message":"Request cancelled"}}`)))
+}
+
+func TestServer_ContentModified(t *testing.T) {
+	g := NewWithT(t)
+	handler := &slowHandler{hovering: make(chan string, 1)}
+	send, received := startServer(t, &lsp.Server{Handler: handler})
+
+	send(`{"jsonrpc":"2.0","id":1,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///a.gdshader"}}}`)
+	g.Eventually(handler.hovering).Should(Receive())
+	send(`{"jsonrpc":"2.0","method":"textDocument/didChange","params":{"textDocument":{"uri":"file:///a.gdshader"},"contentChanges":[]}}`)
+
+	g.Eventually(received).Should(Receive(Equal(`{"jsonrpc":"2.0","id":1,"error":{"code":-32801,"message":"Content modified"}}`)))
+}
+
+func TestServer_ConcurrentRequests(t *testing.T) {
+	g := NewWithT(t)
+	handler := &slowHandler{hovering: make(chan string, 1)}
+	send, received := startServer(t, &lsp.Server{Handler: handler})
+
+	send(`{"jsonrpc":"2.0","id":1,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///a.gdshader"}}}`)
+	g.Eventually(handler.hovering).Should(Receive())
+	send(`{"jsonrpc":"2.0","id":2,"method":"textDocument/completion","params":{"textDocument":{"uri":"file:///a.gdshader"}}}`)
+
+	g.Eventually(received).Should(Receive(Equal(`{"jsonrpc":"2.0","id":2,"result":{"isIncomplete":false,"items":[]}}`)))
+
+	send(`{"jsonrpc":"2.0","method":"$/cancelRequest","params":{"id":1}}`)
+	g.Eventually(received).Should(Receive(Equal(`{"jsonrpc":"2.0","id":1,"error":{"code":-32800,"message":"Request cancelled"}}`)))
+}
+
+func TestServer_CancelQueuedRequest(t *testing.T) {
+	g := NewWithT(t)
+	handler := &slowHandler{blockChanges: make(chan struct{})}
+	send, received := startServer(t, &lsp.Server{Handler: handler})
+
+	// The completion waits for the change that arrived before it.
+	send(`{"jsonrpc":"2.0","method":"textDocument/didChange","params":{"textDocument":{"uri":"file:///a.gdshader"},"contentChanges":[]}}`)
+	send(`{"jsonrpc":"2.0","id":1,"method":"textDocument/completion","params":{"textDocument":{"uri":"file:///b.gdshader"}}}`)
+	send(`{"jsonrpc":"2.0","method":"$/cancelRequest","params":{"id":1}}`)
+
+	g.Eventually(received).Should(Receive(Equal(`{"jsonrpc":"2.0","id":1,"error":{"code":-32800,"message":"Request cancelled"}}`)))
+
+	close(handler.blockChanges)
+	send(`{"jsonrpc":"2.0","id":2,"method":"textDocument/completion","params":{"textDocument":{"uri":"file:///b.gdshader"}}}`)
+	g.Eventually(received).Should(Receive(Equal(`{"jsonrpc":"2.0","id":2,"result":{"isIncomplete":false,"items":[]}}`)))
+}
+
+func TestServer_Request(t *testing.T) {
+	g := NewWithT(t)
+	handler := &configHandler{}
+	server := &lsp.Server{Handler: handler}
+	handler.client = server
+	send, received := startServer(t, server)
+
+	send(`{"jsonrpc":"2.0","id":1,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///a.gdshader"}}}`)
+	g.Eventually(received).Should(Receive(Equal(`{"jsonrpc":"2.0","id":1,"method":"workspace/configuration","params":{"items":[{"section":"gdshader"}]}}`)))
+	send(`{"jsonrpc":"2.0","id":1,"result":[{"indent":4}]}`)
+
+	g.Eventually(received).Should(Receive(Equal(`{"jsonrpc":"2.0","id":1,"result":{"contents":{"kind":"plaintext","value":"{\"indent\":4}"}}}`)))
+}
+
+func TestServer_Request_Error(t *testing.T) {
+	g := NewWithT(t)
+	handler := &configHandler{}
+	server := &lsp.Server{Handler: handler}
+	handler.client = server
+	send, received := startServer(t, server)
+
+	send(`{"jsonrpc":"2.0","id":1,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///a.gdshader"}}}`)
+	g.Eventually(received).Should(Receive(HavePrefix(`{"jsonrpc":"2.0","id":1,"method":"workspace/configuration"`)))
+	send(`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"Unsupported"}}`)
+
+	g.Eventually(received).Should(Receive(Equal(`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"Unsupported"}}`)))
+}
+
+func TestServer_Request_Timeout(t *testing.T) {
+	g := NewWithT(t)
+	handler := &configHandler{}
+	server := &lsp.Server{Handler: handler, RequestTimeout: 10 * time.Millisecond}
+	handler.client = server
+	send, received := startServer(t, server)
+
+	send(`{"jsonrpc":"2.0","id":1,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///a.gdshader"}}}`)
+	g.Eventually(received).Should(Receive(HavePrefix(`{"jsonrpc":"2.0","id":1,"method":"workspace/configuration"`)))
+	g.Eventually(received).Should(Receive(Equal(`{"jsonrpc":"2.0","method":"$/cancelRequest","params":{"id":1}}`)))
+	g.Eventually(received).Should(Receive(Equal(`{"jsonrpc":"2.0","id":1,"error":{"code":-32603,"message":"workspace/configuration: timed out waiting for response"}}`)))
+
+	// A late response is ignored.
+	send(`{"jsonrpc":"2.0","id":1,"result":[null]}`)
+	send(`{"jsonrpc":"2.0","id":2,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///a.gdshader"}}}`)
+	g.Eventually(received).Should(Receive(HavePrefix(`{"jsonrpc":"2.0","id":2,"method":"workspace/configuration"`)))
+}
+
+func TestServer_BadMessage(t *testing.T) {
+	g := NewWithT(t)
+	send, received := startServer(t, &lsp.Server{Handler: &slowHandler{}})
+
+	send(`{"jsonrpc":"2.0","id":1,`)
+	g.Eventually(received).Should(Receive(HavePrefix(`{"jsonrpc":"2.0","id":null,"error":{"code":-32700,`)))
+
+	send(`{"jsonrpc":"2.0","id":2,"method":"textDocument/completion","params":{"textDocument":{"uri":"file:///a.gdshader"}}}`)
+	g.Eventually(received).Should(Receive(Equal(`{"jsonrpc":"2.0","id":2,"result":{"isIncomplete":false,"items":[]}}`)))
+}
+
+func TestServer_LargeMessage(t *testing.T) {
+	g := NewWithT(t)
+	send, received := startServer(t, &lsp.Server{Handler: &slowHandler{}})
+
+	// The text is larger than the buffer of a bufio.Scanner.
+	text := strings.Repeat("// comment\n", 1<<16)
+	send(`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"file:///a.gdshader","text":"` + strings.ReplaceAll(text, "\n", `\n`) + `"}}}`)
+	send(`{"jsonrpc":"2.0","id":1,"method":"textDocument/completion","params":{"textDocument":{"uri":"file:///a.gdshader"}}}`)
+	g.Eventually(received).Should(Receive(Equal(`{"jsonrpc":"2.0","id":1,"result":{"isIncomplete":false,"items":[]}}`)))
+}
+
+// definitionHandler only implements document sync and definitions.
+type definitionHandler struct {
+	lsp.Filesystem
+}
+
+func (h *definitionHandler) Definition(_ context.Context, params lsp.DefinitionParams) ([]lsp.Location, error) {
+	return []lsp.Location{{URI: params.TextDocument.URI}}, nil
+}
+
+func TestServer_OptionalHandlers(t *testing.T) {
+	g := NewWithT(t)
+	send, received, _ := serve(t, &lsp.Server{Handler: &definitionHandler{}})
+
+	send(`{"jsonrpc":"2.0","id":0,"method":"initialize","params":{"capabilities":{}}}`)
+	g.Eventually(received).Should(Receive(Equal(`{"jsonrpc":"2.0","id":0,"result":{"capabilities":{"textDocumentSync":{"openClose":true,"change":2},"definitionProvider":true},"serverInfo":{"name":"","version":""}}}`)))
+
+	send(`{"jsonrpc":"2.0","id":1,"method":"textDocument/definition","params":{"textDocument":{"uri":"file:///a.gdshader"},"position":{"line":0,"character":0}}}`)
+	g.Eventually(received).Should(Receive(Equal(`{"jsonrpc":"2.0","id":1,"result":[{"uri":"file:///a.gdshader","range":{"start":{"line":0,"character":0},"end":{"line":0,"character":0}}}]}`)))
+
+	send(`{"jsonrpc":"2.0","id":2,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///a.gdshader"},"position":{"line":0,"character":0}}}`)
+	g.Eventually(received).Should(Receive(Equal(`{"jsonrpc":"2.0","id":2,"error":{"code":-32601,"message":"Unknown method \"textDocument/hover\""}}`)))
+}
+
+// shutdownHandler records when it is shut down.
+type shutdownHandler struct {
+	definitionHandler
+	shutdown chan struct{}
+}
+
+func (h *shutdownHandler) Shutdown(context.Context) error {
+	close(h.shutdown)
+	return nil
+}
+
+func TestServer_Shutdown(t *testing.T) {
+	g := NewWithT(t)
+	handler := &shutdownHandler{shutdown: make(chan struct{})}
+	send, received := startServer(t, &lsp.Server{Handler: handler})
+
+	send(`{"jsonrpc":"2.0","id":1,"method":"shutdown"}`)
+	g.Eventually(received).Should(Receive(Equal(`{"jsonrpc":"2.0","id":1,"result":null}`)))
+	g.Expect(handler.shutdown).To(BeClosed())
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package lsp
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+)
+
+// Snapshot is an immutable view of a document at one version. It is safe
+// for concurrent use, and stays consistent while the document it was taken
+// from keeps changing.
+type Snapshot struct {
+	version    int
+	languageID string
+	encoding   PositionEncodingKind
+	text       []byte
+	lineStart  []int
+}
+
+// Version is the version of the document that the client sent. It increases
+// after each change.
+func (s *Snapshot) Version() int {
+	return s.version
+}
+
+// LanguageID is the language of the document that the client sent, such as
+// "gdshader".
+func (s *Snapshot) LanguageID() string {
+	return s.languageID
+}
+
+// Bytes returns the full content of the snapshot. The caller must not
+// modify it.
+func (s *Snapshot) Bytes() []byte {
+	return s.text
+}
+
+// Len returns the number of bytes in the snapshot.
+func (s *Snapshot) Len() int {
+	return len(s.text)
+}
+
+// Lines returns the number of lines in the snapshot, counted the same way as
+// [Document.Lines].
+func (s *Snapshot) Lines() int {
+	return len(s.lineStart)
+}
+
+// ReadAt implements io.ReaderAt.
+func (s *Snapshot) ReadAt(p []byte, off int64) (n int, err error) {
+	if off >= int64(len(s.text)) {
+		return 0, io.EOF
+	}
+	n = copy(p, s.text[off:])
+	if n < len(p) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// PositionEncoding is how the characters of positions are counted.
+func (s *Snapshot) PositionEncoding() PositionEncodingKind {
+	return cmp.Or(s.encoding, PositionEncodingUTF16)
+}
+
+// PositionToOffset converts a Position (line and character) to a byte offset
+// in the snapshot. Characters are counted in the position encoding of the
+// document.
+func (s *Snapshot) PositionToOffset(pos Position) (int, error) {
+	if pos.Line >= len(s.lineStart) {
+		return 0, fmt.Errorf("invalid line: %d", pos.Line)
+	}
+
+	start, end := s.lineStart[pos.Line], len(s.text)
+	if pos.Line+1 < len(s.lineStart) {
+		end = s.lineStart[pos.Line+1]
+	}
+
+	count := 0
+	deltaOffset, done, err := decodeUntilTargetOffset(s.text[start:end], pos.Character, &count, s.encoding)
+	if err != nil {
+		return 0, err
+	}
+	if done {
+		return start + deltaOffset, nil
+	}
+	if count >= pos.Character {
+		return end, nil
+	}
+
+	return 0, fmt.Errorf("line %d: target units %d out of bounds (only %d %s units)", pos.Line, pos.Character, count, s.encoding)
+}
+
+// OffsetToPosition converts a byte offset in the snapshot to a Position. It
+// is the inverse of [Snapshot.PositionToOffset]. Offsets past the end are
+// clamped to the end of the snapshot.
+func (s *Snapshot) OffsetToPosition(offset int) Position {
+	offset = max(0, min(offset, len(s.text)))
+	line := lineOf(s.lineStart, offset)
+	return Position{Line: line, Character: countUnits(s.text[s.lineStart[line]:offset], s.encoding)}
+}
+
+var _ io.ReaderAt = (*Snapshot)(nil)
@@ -0,0 +1,95 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package lsp
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"sync"
+)
+
+// Listen listens for clients at an address such as "tcp://localhost:7000"
+// or "unix:///tmp/gdshader.sock".
+func Listen(address string) (net.Listener, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("bad listen address: %w", err)
+	}
+
+	switch u.Scheme {
+	case "tcp":
+		if u.Host == "" {
+			return nil, fmt.Errorf("bad listen address %q: missing host and port", address)
+		}
+		return net.Listen("tcp", u.Host)
+	case "unix":
+		if u.Path == "" {
+			return nil, fmt.Errorf("bad listen address %q: missing path", address)
+		}
+		return net.Listen("unix", u.Path)
+	default:
+		return nil, fmt.Errorf("bad listen address %q: scheme must be tcp or unix", address)
+	}
+}
+
+// ServeListener serves every client that connects to the listener with its
+// own server from newServer, so that clients don't share any state. It
+// blocks until the listener is closed, and then disconnects the clients
+// that are still connected.
+func ServeListener(listener net.Listener, newServer func() *Server) error {
+	var (
+		mu      sync.Mutex
+		conns   = make(map[net.Conn]struct{})
+		serving sync.WaitGroup
+	)
+
+	defer func() {
+		mu.Lock()
+		for conn := range conns {
+			_ = conn.Close()
+		}
+		mu.Unlock()
+		serving.Wait()
+	}()
+
+	slog.Info("Listening", "address", listener.Addr())
+
+	for {
+		conn, err := listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("accept: %w", err)
+		}
+
+		mu.Lock()
+		conns[conn] = struct{}{}
+		mu.Unlock()
+
+		serving.Add(1)
+		go func() {
+			defer serving.Done()
+			defer func() {
+				mu.Lock()
+				delete(conns, conn)
+				mu.Unlock()
+				_ = conn.Close()
+			}()
+
+			logger := slog.With("remote", conn.RemoteAddr())
+			logger.Info("Client connected")
+			server := newServer()
+			server.Stdin = conn
+			server.Stdout = conn
+			if err := server.Serve(); err != nil {
+				logger.Error("Client connection failed", "error", err)
+			}
+			logger.Info("Client disconnected")
+		}()
+	}
+}
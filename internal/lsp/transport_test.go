@@ -0,0 +1,41 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package lsp_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+)
+
+func TestListen(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		wantErr string
+	}{
+		{name: "TCP", address: "tcp://127.0.0.1:0"},
+		{name: "Unix", address: "unix://" + filepath.Join(t.TempDir(), "test.sock")},
+		{name: "MissingScheme", address: "127.0.0.1:0", wantErr: "bad listen address"},
+		{name: "UnknownScheme", address: "udp://127.0.0.1:0", wantErr: "scheme must be tcp or unix"},
+		{name: "MissingHost", address: "tcp://", wantErr: "missing host and port"},
+		{name: "MissingPath", address: "unix://", wantErr: "missing path"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			listener, err := lsp.Listen(tt.address)
+			if tt.wantErr != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tt.wantErr)))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(listener.Close()).To(Succeed())
+		})
+	}
+}
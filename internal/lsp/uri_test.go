@@ -0,0 +1,34 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package lsp_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+)
+
+func TestURIToPath(t *testing.T) {
+	g := NewWithT(t)
+
+	path, err := lsp.URIToPath("file:///project/my%20shaders/water.gdshader")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(path).To(Equal(filepath.FromSlash("/project/my shaders/water.gdshader")))
+
+	_, err = lsp.URIToPath("untitled:Untitled-1")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestPathToURI(t *testing.T) {
+	g := NewWithT(t)
+
+	dir := t.TempDir()
+	uri := lsp.PathToURI(filepath.Join(dir, "my shaders", "water.gdshader"))
+	g.Expect(uri).To(HavePrefix("file:///"))
+	g.Expect(uri).To(HaveSuffix("/my%20shaders/water.gdshader"))
+	g.Expect(lsp.URIToPath(uri)).To(Equal(filepath.Join(dir, "my shaders", "water.gdshader")))
+}
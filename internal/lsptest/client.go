@@ -0,0 +1,460 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package lsptest
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+)
+
+// Timeout limits how long the client waits for the server.
+const Timeout = 5 * time.Second
+
+// Options configure a client.
+type Options struct {
+	// Capabilities are sent to the server in "initialize".
+	Capabilities lsp.ClientCapabilities
+	// WorkspaceFolders are sent to the server in "initialize".
+	WorkspaceFolders []lsp.WorkspaceFolder
+	// HandleRequest answers requests from the server. Without it, they fail
+	// with MethodNotFound.
+	HandleRequest func(method string, params json.RawMessage) (any, error)
+}
+
+// Client drives a server over in-memory pipes, the way that an editor
+// would. Its methods fail the test if the server doesn't respond as
+// expected, so they must be called from the test goroutine.
+type Client struct {
+	// Capabilities are the capabilities that the server responded with.
+	Capabilities lsp.ServerCapabilities
+
+	t       testing.TB
+	options Options
+	stdin   io.Writer
+	writeMu sync.Mutex
+
+	mu       sync.Mutex
+	lastID   int
+	pending  map[string]chan *response
+	versions map[string]int
+	// opened counts the opens and changes of each document, so that
+	// diagnostics without a version can be matched to the latest one.
+	opened      map[string]int
+	diagnostics map[string]published
+	// notifications are the params of the other notifications, by method.
+	notifications map[string][]json.RawMessage
+	// updated is closed and replaced when a notification arrives.
+	updated chan struct{}
+}
+
+type response struct {
+	Result json.RawMessage    `json:"result"`
+	Error  *lsp.ResponseError `json:"error"`
+}
+
+type published struct {
+	params lsp.PublishDiagnosticsParams
+	opened int
+}
+
+// Start serves a server over in-memory pipes, and initializes it. The
+// server is shut down when the test ends.
+func Start(t testing.TB, server *lsp.Server, options Options) *Client {
+	t.Helper()
+
+	stdinReader, stdinWriter := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+	server.Stdin = stdinReader
+	server.Stdout = stdoutWriter
+
+	c := &Client{
+		t:             t,
+		options:       options,
+		stdin:         stdinWriter,
+		pending:       make(map[string]chan *response),
+		versions:      make(map[string]int),
+		opened:        make(map[string]int),
+		diagnostics:   make(map[string]published),
+		notifications: make(map[string][]json.RawMessage),
+		updated:       make(chan struct{}),
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve()
+		_ = stdinReader.Close()
+		_ = stdoutWriter.Close()
+	}()
+	go c.read(stdoutReader)
+
+	t.Cleanup(func() {
+		_ = c.Call("shutdown", nil, nil)
+		c.Notify("exit", nil)
+		select {
+		case err := <-served:
+			if err != nil {
+				t.Errorf("Server exited with error: %v", err)
+			}
+		case <-time.After(Timeout):
+			t.Error("Server did not exit in time")
+		}
+		_ = stdinWriter.Close()
+	})
+
+	var result struct {
+		Capabilities lsp.ServerCapabilities `json:"capabilities"`
+	}
+	params := lsp.InitializeParams{Capabilities: options.Capabilities, WorkspaceFolders: options.WorkspaceFolders}
+	if err := c.Call("initialize", params, &result); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	c.Capabilities = result.Capabilities
+	c.Notify("initialized", struct{}{})
+
+	return c
+}
+
+// Call sends a request and decodes the result of the response. An error
+// response is returned as a *lsp.ResponseError.
+func (c *Client) Call(method string, params, result any) error {
+	c.mu.Lock()
+	c.lastID++
+	id := strconv.Itoa(c.lastID)
+	responses := make(chan *response, 1)
+	c.pending[id] = responses
+	c.mu.Unlock()
+
+	if err := c.write(map[string]any{"jsonrpc": "2.0", "id": json.RawMessage(id), "method": method, "params": params}); err != nil {
+		return err
+	}
+
+	select {
+	case r := <-responses:
+		if r.Error != nil {
+			return r.Error
+		}
+		if result == nil {
+			return nil
+		}
+		return json.Unmarshal(r.Result, result)
+	case <-time.After(Timeout):
+		return fmt.Errorf("%s: timed out waiting for response", method)
+	}
+}
+
+// Notify sends a notification.
+func (c *Client) Notify(method string, params any) {
+	if err := c.write(map[string]any{"jsonrpc": "2.0", "method": method, "params": params}); err != nil {
+		c.t.Errorf("%s: %v", method, err)
+	}
+}
+
+// Open opens a document with the text of a fixture, and returns the
+// positions of its markers.
+func (c *Client) Open(uri, fixture string) Fixture {
+	f := ParseFixture(fixture, c.Capabilities.PositionEncoding)
+
+	c.mu.Lock()
+	c.versions[uri] = 1
+	c.opened[uri]++
+	c.mu.Unlock()
+
+	c.Notify("textDocument/didOpen", lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{URI: uri, LanguageID: "gdshader", Version: 1, Text: f.Text},
+	})
+	return f
+}
+
+// Change replaces the text of a document with the text of a fixture, and
+// returns the positions of its markers.
+func (c *Client) Change(uri, fixture string) Fixture {
+	f := ParseFixture(fixture, c.Capabilities.PositionEncoding)
+
+	c.mu.Lock()
+	c.versions[uri]++
+	version := c.versions[uri]
+	c.opened[uri]++
+	c.mu.Unlock()
+
+	c.Notify("textDocument/didChange", lsp.DidChangeTextDocumentParams{
+		TextDocument:   lsp.VersionedTextDocumentIdentifier{URI: uri, Version: version},
+		ContentChanges: []lsp.TextDocumentContentChangeEvent{{Text: f.Text}},
+	})
+	return f
+}
+
+// Edit replaces a range of a document with text, the way that an editor
+// sends typing.
+func (c *Client) Edit(uri string, r lsp.Range, text string) {
+	c.mu.Lock()
+	c.versions[uri]++
+	version := c.versions[uri]
+	c.opened[uri]++
+	c.mu.Unlock()
+
+	c.Notify("textDocument/didChange", lsp.DidChangeTextDocumentParams{
+		TextDocument:   lsp.VersionedTextDocumentIdentifier{URI: uri, Version: version},
+		ContentChanges: []lsp.TextDocumentContentChangeEvent{{Range: &r, Text: text}},
+	})
+}
+
+// Close closes a document.
+func (c *Client) Close(uri string) {
+	c.mu.Lock()
+	delete(c.versions, uri)
+	c.opened[uri]++
+	c.mu.Unlock()
+
+	c.Notify("textDocument/didClose", lsp.DidCloseTextDocumentParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+	})
+}
+
+// Complete requests completion at a position.
+func (c *Client) Complete(uri string, pos lsp.Position) *lsp.CompletionList {
+	c.t.Helper()
+	var result *lsp.CompletionList
+	c.must("textDocument/completion", lsp.CompletionParams{TextDocumentPositionParams: positionParams(uri, pos)}, &result)
+	return result
+}
+
+// Hover requests hover information at a position.
+func (c *Client) Hover(uri string, pos lsp.Position) *lsp.Hover {
+	c.t.Helper()
+	var result *lsp.Hover
+	c.must("textDocument/hover", lsp.HoverParams{TextDocumentPositionParams: positionParams(uri, pos)}, &result)
+	return result
+}
+
+// Definition requests the definition of the symbol at a position.
+func (c *Client) Definition(uri string, pos lsp.Position) []lsp.Location {
+	c.t.Helper()
+	var result []lsp.Location
+	c.must("textDocument/definition", lsp.DefinitionParams{TextDocumentPositionParams: positionParams(uri, pos)}, &result)
+	return result
+}
+
+// References requests the references to the symbol at a position,
+// including its declaration.
+func (c *Client) References(uri string, pos lsp.Position) []lsp.Location {
+	c.t.Helper()
+	var result []lsp.Location
+	c.must("textDocument/references", lsp.ReferenceParams{
+		TextDocumentPositionParams: positionParams(uri, pos),
+		Context:                    lsp.ReferenceContext{IncludeDeclaration: true},
+	}, &result)
+	return result
+}
+
+// DocumentLinks requests the links in a document.
+func (c *Client) DocumentLinks(uri string) []lsp.DocumentLink {
+	c.t.Helper()
+	var result []lsp.DocumentLink
+	c.must("textDocument/documentLink", lsp.DocumentLinkParams{TextDocument: lsp.TextDocumentIdentifier{URI: uri}}, &result)
+	return result
+}
+
+// CodeActions requests the quick fixes for a range of a document.
+func (c *Client) CodeActions(uri string, r lsp.Range) []lsp.CodeAction {
+	c.t.Helper()
+	var result []lsp.CodeAction
+	c.must("textDocument/codeAction", lsp.CodeActionParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+		Range:        r,
+		Context:      lsp.CodeActionContext{Diagnostics: []lsp.Diagnostic{}, Only: []lsp.CodeActionKind{lsp.CodeActionQuickFix}},
+	}, &result)
+	return result
+}
+
+// WaitDiagnostics waits for the server to publish diagnostics for the
+// latest version of a document, and returns them.
+func (c *Client) WaitDiagnostics(uri string) []lsp.Diagnostic {
+	c.t.Helper()
+	timeout := time.After(Timeout)
+	for {
+		c.mu.Lock()
+		p, ok := c.diagnostics[uri]
+		current := ok && p.opened == c.opened[uri]
+		if v, open := c.versions[uri]; current && p.params.Version != nil && open {
+			current = *p.params.Version == v
+		}
+		updated := c.updated
+		c.mu.Unlock()
+
+		if current {
+			return p.params.Diagnostics
+		}
+
+		select {
+		case <-updated:
+		case <-timeout:
+			c.t.Fatalf("Timed out waiting for diagnostics of %s", uri)
+		}
+	}
+}
+
+// Diagnostics returns the diagnostics that the server last published for a
+// document, without waiting for the latest version.
+func (c *Client) Diagnostics(uri string) []lsp.Diagnostic {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.diagnostics[uri].params.Diagnostics
+}
+
+// WaitNotifications waits for the server to send at least n notifications
+// of a method other than "textDocument/publishDiagnostics", and returns the
+// params of all of them so far.
+func (c *Client) WaitNotifications(method string, n int) []json.RawMessage {
+	c.t.Helper()
+	timeout := time.After(Timeout)
+	for {
+		c.mu.Lock()
+		notifications := slices.Clone(c.notifications[method])
+		updated := c.updated
+		c.mu.Unlock()
+
+		if len(notifications) >= n {
+			return notifications
+		}
+
+		select {
+		case <-updated:
+		case <-timeout:
+			c.t.Fatalf("Timed out waiting for %d %s notifications, got %d", n, method, len(notifications))
+		}
+	}
+}
+
+func (c *Client) must(method string, params, result any) {
+	c.t.Helper()
+	if err := c.Call(method, params, result); err != nil {
+		c.t.Fatalf("%s: %v", method, err)
+	}
+}
+
+func positionParams(uri string, pos lsp.Position) lsp.TextDocumentPositionParams {
+	return lsp.TextDocumentPositionParams{TextDocument: lsp.TextDocumentIdentifier{URI: uri}, Position: pos}
+}
+
+func (c *Client) write(message any) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_, err = io.WriteString(c.stdin, "Content-Length: "+strconv.Itoa(len(data))+"\r\n\r\n"+string(data))
+	return err
+}
+
+// read handles the messages from the server until it closes the stream.
+func (c *Client) read(stdout io.Reader) {
+	reader := textproto.NewReader(bufio.NewReader(stdout))
+	for {
+		header, err := reader.ReadMIMEHeader()
+		if err != nil {
+			return
+		}
+		length, err := strconv.Atoi(header.Get("Content-Length"))
+		if err != nil {
+			return
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(reader.R, payload); err != nil {
+			return
+		}
+
+		var message struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if json.Unmarshal(payload, &message) != nil {
+			continue
+		}
+
+		switch {
+		case message.Method == "":
+			c.handleResponse(message.ID, payload)
+		case len(message.ID) > 0:
+			go c.handleRequest(message.ID, message.Method, message.Params)
+		case message.Method == "textDocument/publishDiagnostics":
+			c.handlePublishDiagnostics(message.Params)
+		default:
+			c.handleNotification(message.Method, message.Params)
+		}
+	}
+}
+
+func (c *Client) handleResponse(id json.RawMessage, payload []byte) {
+	var r response
+	if json.Unmarshal(payload, &r) != nil {
+		return
+	}
+
+	c.mu.Lock()
+	responses, ok := c.pending[string(id)]
+	delete(c.pending, string(id))
+	c.mu.Unlock()
+
+	if ok {
+		responses <- &r
+	}
+}
+
+func (c *Client) handleRequest(id json.RawMessage, method string, params json.RawMessage) {
+	var result any
+	err := error(&lsp.ResponseError{Code: lsp.CodeMethodNotFound, Message: fmt.Sprintf("Unknown method %q", method)})
+	if c.options.HandleRequest != nil {
+		result, err = c.options.HandleRequest(method, params)
+	}
+
+	if err == nil {
+		_ = c.write(map[string]any{"jsonrpc": "2.0", "id": id, "result": result})
+		return
+	}
+
+	var responseError *lsp.ResponseError
+	if !errors.As(err, &responseError) {
+		responseError = &lsp.ResponseError{Code: lsp.CodeInternalError, Message: err.Error()}
+	}
+	_ = c.write(map[string]any{"jsonrpc": "2.0", "id": id, "error": responseError})
+}
+
+func (c *Client) handlePublishDiagnostics(paramsRaw json.RawMessage) {
+	var params lsp.PublishDiagnosticsParams
+	if json.Unmarshal(paramsRaw, &params) != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.diagnostics[params.URI] = published{params: params, opened: c.opened[params.URI]}
+	c.notifyUpdated()
+}
+
+func (c *Client) handleNotification(method string, params json.RawMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.notifications[method] = append(c.notifications[method], params)
+	c.notifyUpdated()
+}
+
+// notifyUpdated wakes the waiters. It must be called with mu held.
+func (c *Client) notifyUpdated() {
+	close(c.updated)
+	c.updated = make(chan struct{})
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package lsptest_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+	"github.com/armsnyder/gdshader-language-server/internal/lsptest"
+	"github.com/armsnyder/gdshader-language-server/internal/testutil"
+)
+
+// wordHandler defines each word at its first occurrence, and reports
+// every "TODO" as a diagnostic.
+type wordHandler struct {
+	lsp.Filesystem
+	client lsp.Client
+}
+
+func (h *wordHandler) DidOpenTextDocument(ctx context.Context, params lsp.DidOpenTextDocumentParams) error {
+	if err := h.Filesystem.DidOpenTextDocument(ctx, params); err != nil {
+		return err
+	}
+	return h.publish(ctx, params.TextDocument.URI)
+}
+
+func (h *wordHandler) DidChangeTextDocument(ctx context.Context, params lsp.DidChangeTextDocumentParams) error {
+	if err := h.Filesystem.DidChangeTextDocument(ctx, params); err != nil {
+		return err
+	}
+	return h.publish(ctx, params.TextDocument.URI)
+}
+
+func (h *wordHandler) publish(ctx context.Context, uri string) error {
+	doc, err := h.Snapshot(uri)
+	if err != nil {
+		return err
+	}
+	version := doc.Version()
+	params := lsp.PublishDiagnosticsParams{URI: uri, Version: &version, Diagnostics: []lsp.Diagnostic{}}
+	text := string(doc.Bytes())
+	for offset := 0; ; offset += len("TODO") {
+		i := strings.Index(text[offset:], "TODO")
+		if i < 0 {
+			break
+		}
+		offset += i
+		params.Diagnostics = append(params.Diagnostics, lsp.Diagnostic{
+			Range:   lsp.Range{Start: doc.OffsetToPosition(offset), End: doc.OffsetToPosition(offset + len("TODO"))},
+			Message: "TODO",
+		})
+	}
+	return h.client.Notify(ctx, "textDocument/publishDiagnostics", params)
+}
+
+func (h *wordHandler) Definition(_ context.Context, params lsp.DefinitionParams) ([]lsp.Location, error) {
+	doc, err := h.Snapshot(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	word, _, err := doc.WordAt(params.Position)
+	if err != nil || word == "" {
+		return nil, err
+	}
+	offset := strings.Index(string(doc.Bytes()), word)
+	return []lsp.Location{{URI: params.TextDocument.URI, Range: lsp.Range{
+		Start: doc.OffsetToPosition(offset),
+		End:   doc.OffsetToPosition(offset + len(word)),
+	}}}, nil
+}
+
+func (h *wordHandler) Hover(ctx context.Context, _ lsp.HoverParams) (*lsp.Hover, error) {
+	var result string
+	if err := h.client.Request(ctx, "custom/name", nil, &result); err != nil {
+		return nil, err
+	}
+	return &lsp.Hover{Contents: lsp.MarkupContent{Kind: lsp.MarkupPlainText, Value: result}}, nil
+}
+
+func startWordServer(t *testing.T, options lsptest.Options) *lsptest.Client {
+	testutil.SetupLogger(t)
+	handler := &wordHandler{}
+	server := &lsp.Server{Handler: handler}
+	handler.client = server
+	return lsptest.Start(t, server, options)
+}
+
+func TestClient_Definition(t *testing.T) {
+	g := NewWithT(t)
+	c := startWordServer(t, lsptest.Options{})
+
+	f := c.Open("file:///a.gdshader", "uniform float /*^def*/x;\nvoid f() { float y = /*^use*/x; }\n")
+
+	g.Expect(c.Definition("file:///a.gdshader", f.At("use"))).To(Equal([]lsp.Location{{
+		URI:   "file:///a.gdshader",
+		Range: lsp.Range{Start: f.At("def"), End: lsp.Position{Line: f.At("def").Line, Character: f.At("def").Character + 1}},
+	}}))
+}
+
+func TestClient_WaitDiagnostics(t *testing.T) {
+	g := NewWithT(t)
+	c := startWordServer(t, lsptest.Options{})
+
+	f := c.Open("file:///a.gdshader", "// /*^todo*/TODO\n")
+	g.Expect(c.WaitDiagnostics("file:///a.gdshader")).To(Equal([]lsp.Diagnostic{{
+		Range:   lsp.Range{Start: f.At("todo"), End: lsp.Position{Character: f.At("todo").Character + 4}},
+		Message: "TODO",
+	}}))
+
+	c.Change("file:///a.gdshader", "// Done\n")
+	g.Expect(c.WaitDiagnostics("file:///a.gdshader")).To(BeEmpty())
+
+	c.Edit("file:///a.gdshader", lsp.Range{Start: lsp.Position{Character: 3}, End: lsp.Position{Character: 7}}, "TODO")
+	g.Expect(c.WaitDiagnostics("file:///a.gdshader")).To(HaveLen(1))
+}
+
+func TestClient_HandleRequest(t *testing.T) {
+	g := NewWithT(t)
+	c := startWordServer(t, lsptest.Options{
+		HandleRequest: func(method string, _ json.RawMessage) (any, error) {
+			return method, nil
+		},
+	})
+
+	c.Open("file:///a.gdshader", "")
+	g.Expect(c.Hover("file:///a.gdshader", lsp.Position{}).Contents.Value).To(Equal("custom/name"))
+}
+
+func TestParseFixture(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding lsp.PositionEncodingKind
+		want     lsp.Position
+	}{
+		{name: "UTF16", encoding: lsp.PositionEncodingUTF16, want: lsp.Position{Line: 1, Character: 6}},
+		{name: "UTF8", encoding: lsp.PositionEncodingUTF8, want: lsp.Position{Line: 1, Character: 8}},
+		{name: "UTF32", encoding: lsp.PositionEncodingUTF32, want: lsp.Position{Line: 1, Character: 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			f := lsptest.ParseFixture("/*^start*/a\n// 😀 /*^end*/b", tt.encoding)
+			g.Expect(f.Text).To(Equal("a\n// 😀 b"))
+			g.Expect(f.At("start")).To(Equal(lsp.Position{}))
+			g.Expect(f.At("end")).To(Equal(tt.want))
+		})
+	}
+}
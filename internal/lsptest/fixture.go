@@ -0,0 +1,73 @@
+// Copyright (c) 2026 Adam Snyder <https://armsnyder.com> and contributors
+// SPDX-License-Identifier: MIT
+
+package lsptest
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/armsnyder/gdshader-language-server/internal/lsp"
+)
+
+// marker is a comment such as /*^def*/ that marks the position after it.
+var marker = regexp.MustCompile(`/\*\^(\w+)\*/`)
+
+// Fixture is the text of a document, with the positions of the markers that
+// were removed from it.
+type Fixture struct {
+	Text    string
+	Markers map[string]lsp.Position
+}
+
+// ParseFixture removes the markers from a text. A marker such as /*^def*/
+// is named "def", and marks the position of the character after it.
+// Characters are counted in the position encoding.
+func ParseFixture(text string, encoding lsp.PositionEncodingKind) Fixture {
+	f := Fixture{Markers: make(map[string]lsp.Position)}
+
+	var b strings.Builder
+	var pos lsp.Position
+	advance := func(s string) {
+		b.WriteString(s)
+		for _, r := range s {
+			if r == '\n' {
+				pos.Line++
+				pos.Character = 0
+				continue
+			}
+			switch encoding {
+			case lsp.PositionEncodingUTF8:
+				pos.Character += utf8.RuneLen(r)
+			case lsp.PositionEncodingUTF32:
+				pos.Character++
+			default:
+				pos.Character += utf16.RuneLen(r)
+			}
+		}
+	}
+
+	last := 0
+	for _, m := range marker.FindAllStringSubmatchIndex(text, -1) {
+		advance(text[last:m[0]])
+		f.Markers[text[m[2]:m[3]]] = pos
+		last = m[1]
+	}
+	advance(text[last:])
+
+	f.Text = b.String()
+	return f
+}
+
+// At returns the position of a marker. It panics if there is no such marker,
+// since that is a mistake in the test.
+func (f Fixture) At(name string) lsp.Position {
+	pos, ok := f.Markers[name]
+	if !ok {
+		panic(fmt.Sprintf("fixture has no marker %q", name))
+	}
+	return pos
+}
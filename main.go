@@ -4,12 +4,17 @@
 package main
 
 import (
+	"context"
 	_ "embed"
+	"errors"
 	"flag"
+	"fmt"
 	"log/slog"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
 
 	"github.com/armsnyder/gdshader-language-server/internal/app"
 	"github.com/armsnyder/gdshader-language-server/internal/lsp"
@@ -20,26 +25,125 @@ var version string
 
 func main() {
 	var flags struct {
-		Debug bool
+		Debug  bool
+		Listen string
+		Record string
 	}
 
 	flag.BoolVar(&flags.Debug, "debug", false, "Enable debug logging")
+	flag.StringVar(&flags.Listen, "listen", "", "Serve clients that connect to tcp://host:port or unix:///path, instead of stdio")
+	flag.StringVar(&flags.Record, "record", "", "Record every message of the session to a file, which can be replayed with the replay command")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage:\n  %[1]s [flags]\n  %[1]s replay <recording>\n\nFlags:\n", filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
 
 	flag.Parse()
 
 	setupLogger(flags.Debug)
 
+	if flag.Arg(0) == "replay" {
+		if flag.NArg() != 2 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		if err := replay(flag.Arg(1)); err != nil {
+			slog.Error("Replay failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if flags.Listen == "" {
+		if err := serveStdio(flags.Record); err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if flags.Record != "" {
+		slog.Error("Recording is not supported in listen mode")
+		os.Exit(2)
+	}
+
+	if err := listen(flags.Listen); err != nil {
+		slog.Error("Failed to serve", "error", err)
+		os.Exit(1)
+	}
+}
+
+// serveStdio serves one client over stdio, and records the session to a
+// file if it is set.
+func serveStdio(record string) error {
+	server := newServer()
+	if record != "" {
+		f, err := os.Create(record)
+		if err != nil {
+			slog.Error("Failed to create recording", "error", err)
+			return err
+		}
+		defer f.Close()
+		server.Recorder = lsp.NewRecorder(f)
+	}
+	return server.Serve()
+}
+
+// replay replays a recorded session against a new server, and prints how
+// the responses differ from the recording.
+func replay(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	recording, err := lsp.ReadRecording(f)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+
+	diff, err := lsp.Replay(newServer(), recording)
+	if err != nil {
+		return err
+	}
+	if diff != "" {
+		fmt.Print(diff)
+		return errors.New("responses differ from the recording")
+	}
+	return nil
+}
+
+// newServer returns a server with its own handler, so that clients don't
+// share any state.
+func newServer() *lsp.Server {
+	handler := &app.Handler{}
 	server := &lsp.Server{
 		Info: lsp.ServerInfo{
 			Name:    "gdshader-language-server",
 			Version: strings.TrimSpace(version),
 		},
-		Handler: &app.Handler{},
+		Handler: handler,
 	}
+	handler.Client = server
+	return server
+}
 
-	if err := server.Serve(); err != nil {
-		os.Exit(1)
+// listen serves clients that connect to the address until the process is
+// interrupted.
+func listen(address string) error {
+	listener, err := lsp.Listen(address)
+	if err != nil {
+		return err
 	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		_ = listener.Close()
+	}()
+
+	return lsp.ServeListener(listener, newServer)
 }
 
 func setupLogger(debug bool) { //nolint:revive
@@ -54,9 +54,13 @@ async function activate(context) {
 
     /** @type {import('vscode-languageclient/node').LanguageClientOptions} */
     const clientOptions = {
-      documentSelector: [{ scheme: "file", language: "gdshader" }],
+      documentSelector: [
+        { scheme: "file", language: "gdshader" },
+        { scheme: "file", language: "gdshaderinc" },
+      ],
       synchronize: {
         fileEvents: vscode.workspace.createFileSystemWatcher("**/.clientrc"),
+        configurationSection: "gdshader",
       },
       outputChannel: logger(),
       traceOutputChannel: logger(),